@@ -5,15 +5,31 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"plaxo-orchestra/internal/analyzer"
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/contracts"
+	"plaxo-orchestra/internal/manifest"
 	"plaxo-orchestra/internal/orchestrator"
+	"plaxo-orchestra/internal/patch"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/retrieval"
+	"plaxo-orchestra/internal/sandbox"
+	"plaxo-orchestra/internal/structured"
+	"plaxo-orchestra/internal/usage"
+	"plaxo-orchestra/internal/workflow"
+	"sort"
 	"strings"
 	"time"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Uso: plaxo <comando> [argumentos]")
+	args, flags := parseGlobalFlags(os.Args[1:])
+	
+	if len(args) < 1 {
+		fmt.Println("Uso: plaxo [--backend q|openai|ollama] [--model <modelo>] [--record|--replay <cassette>] [--locale pt-BR|en] <comando> [argumentos]")
 		fmt.Println("Comandos:")
 		fmt.Println("  chat \"<mensagem>\"    - Executa comando único inteligente")
 		fmt.Println("  interactive          - Modo interativo com IA avançada")
@@ -23,6 +39,13 @@ func main() {
 		fmt.Println("  metrics              - Métricas de performance")
 		fmt.Println("  spec                 - Gera especificação do projeto")
 		fmt.Println("  watch                - Monitora mudanças no projeto")
+		fmt.Println("  prompts [export]     - Lista ou exporta os templates de prompt")
+		fmt.Println("  patches [undo [id]]  - Lista ou desfaz alterações aplicadas pelos agentes")
+		fmt.Println("  plan \"<mensagem>\" [--format mermaid|dot|json] [--out arquivo] - Mostra o workflow planejado sem executar")
+		fmt.Println("  runs [show|resume|export <id>] - Lista, detalha, retoma ou exporta execuções de workflows")
+		fmt.Println("  approve [<run> <etapa>] - Lista ou resolve aprovações pendentes e retoma a execução")
+		fmt.Println("  contracts            - Lista os contratos entre domínios e os requisitos sem fornecedor")
+		fmt.Println("  migrate [--dry-run]  - Converte agentes antigos (instructions.txt) para o manifesto agents/agent.yaml")
 		os.Exit(1)
 	}
 
@@ -31,31 +54,72 @@ func main() {
 		fmt.Printf("Erro obtendo diretório atual: %v\n", err)
 		os.Exit(1)
 	}
+	
+	// Seleciona o backend de IA (orchestra.yaml, sobrescrito pelas flags)
+	backendConfig := backend.LoadConfig(workingDir)
+	if flags["backend"] != "" {
+		backendConfig.Type = flags["backend"]
+	}
+	if flags["model"] != "" {
+		backendConfig.Model = flags["model"]
+	}
+	if flags["record"] != "" {
+		backendConfig.Mode, backendConfig.Cassette = backend.ModeRecord, flags["record"]
+	}
+	if flags["replay"] != "" {
+		backendConfig.Mode, backendConfig.Cassette = backend.ModeReplay, flags["replay"]
+	}
+	selectedBackend, err := backend.New(backendConfig)
+	if err != nil {
+		fmt.Printf("Erro configurando backend: %v\n", err)
+		os.Exit(1)
+	}
+	
+	// Contabiliza tokens de todas as chamadas desta sessão em .plaxo/usage
+	tracker := usage.NewTracker(workingDir, usage.Pricing{
+		PromptPer1K:     backendConfig.PromptCost,
+		CompletionPer1K: backendConfig.CompletionCost,
+	})
+	backend.SetDefault(usage.Meter(selectedBackend, tracker))
+	
+	// Prompts: .plaxo/prompts do projeto sobrescreve o conjunto embutido
+	locale := flags["locale"]
+	if locale == "" {
+		locale = prompts.DetectLocale(workingDir)
+	}
+	prompts.SetDefault(prompts.NewLoader(workingDir, locale))
+	structured.PersistStats(workingDir)
+	sandbox.PersistStats(workingDir)
 
 	// Usa o orquestrador aprimorado com IA
 	enhancedOrch := orchestrator.NewEnhancedOrchestrator(workingDir)
 	ctx := context.Background()
 
-	switch os.Args[1] {
+	switch args[0] {
 	case "chat":
-		if len(os.Args) < 3 {
+		if len(args) < 2 {
 			fmt.Println("Uso: plaxo chat \"<mensagem>\"")
 			os.Exit(1)
 		}
 		
-		message := strings.Join(os.Args[2:], " ")
+		message := strings.Join(args[1:], " ")
 		
-		// Timeout context for requests
+		// Timeout context for requests; Ctrl+C interrompe e mantém o checkpoint
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 		defer cancel()
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
 		
-		if err := enhancedOrch.ProcessWithIntelligence(ctx, message); err != nil {
+		err := enhancedOrch.ProcessWithIntelligence(ctx, message)
+		enhancedOrch.Close()
+		if err != nil {
 			fmt.Printf("Erro: %v\n", err)
 			os.Exit(1)
 		}
 
 	case "interactive":
 		runEnhancedInteractive(enhancedOrch)
+		enhancedOrch.Close()
 
 	case "spread":
 		runAgentSpread(workingDir)
@@ -83,12 +147,516 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "prompts":
+		runPrompts(workingDir, args[1:])
+
+	case "patches":
+		runPatches(workingDir, args[1:])
+
+	case "plan":
+		runPlan(enhancedOrch, args[1:])
+		enhancedOrch.Close()
+
+	case "runs":
+		runRuns(workingDir, enhancedOrch, args[1:])
+		enhancedOrch.Close()
+
+	case "approve":
+		runApprove(workingDir, enhancedOrch, args[1:])
+		enhancedOrch.Close()
+
+	case "contracts":
+		runContracts(workingDir)
+
+	case "migrate":
+		runMigrate(workingDir, args[1:])
+
 	default:
-		fmt.Printf("Comando desconhecido: %s\n", os.Args[1])
+		fmt.Printf("Comando desconhecido: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+func runPrompts(workingDir string, args []string) {
+	loader := prompts.Default()
+	
+	if len(args) > 0 && args[0] == "export" {
+		locale := loader.Locale()
+		if len(args) > 1 {
+			locale = args[1]
+		}
+		dir, err := prompts.Export(workingDir, locale)
+		if err != nil {
+			fmt.Printf("❌ Erro exportando prompts: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Prompts exportados para %s\n", dir)
+		fmt.Println("💡 Edite os arquivos .tmpl para ajustar os prompts deste projeto")
+		return
+	}
+	
+	fmt.Printf("📝 Prompts (locale: %s):\n", loader.Locale())
+	for _, name := range prompts.Names() {
+		t, err := loader.Load(name)
+		if err != nil {
+			fmt.Printf("  ❌ %s: %v\n", name, err)
+			continue
+		}
+		fmt.Printf("  %s v%d (%s)\n", name, t.Version, t.Source)
+	}
+}
+
+func runPatches(workingDir string, args []string) {
+	if len(args) > 0 && args[0] == "undo" {
+		id := ""
+		if len(args) > 1 {
+			id = args[1]
+		}
+		record, err := patch.Undo(workingDir, id)
+		if err != nil {
+			fmt.Printf("❌ Erro desfazendo alterações: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("↩️  Alterações %s desfeitas (%d arquivos)\n", record.ID, len(record.Files))
+		return
+	}
+	
+	records, err := patch.List(workingDir)
+	if err != nil {
+		fmt.Printf("❌ Erro lendo alterações: %v\n", err)
+		os.Exit(1)
+	}
+	if len(records) == 0 {
+		fmt.Println("📭 Nenhuma alteração aplicada pelos agentes")
+		return
+	}
+	
+	fmt.Println("📝 Alterações aplicadas:")
+	for _, record := range records {
+		status := "✅"
+		if record.UndoneAt != nil {
+			status = "↩️ "
+		}
+		fmt.Printf("  %s %s [%s] %s\n", status, record.ID, record.Domain, record.CreatedAt.Format("2006-01-02 15:04:05"))
+		for _, change := range record.Files {
+			fmt.Printf("      %s\n", change.Path)
+		}
+	}
+}
+
+func runRuns(workingDir string, orch *orchestrator.EnhancedOrchestrator, args []string) {
+	if len(args) == 0 || args[0] == "list" {
+		runs, err := workflow.ListRuns(workingDir)
+		if err != nil {
+			fmt.Printf("❌ Erro lendo execuções: %v\n", err)
+			os.Exit(1)
+		}
+		if len(runs) == 0 {
+			fmt.Println("📭 Nenhuma execução registrada")
+			return
+		}
+		
+		fmt.Println("🗂️  Execuções:")
+		for _, run := range runs {
+			input := run.Input
+			if len(input) > 60 {
+				input = input[:57] + "..."
+			}
+			fmt.Printf("  %s %s [%s/%s] %d/%d etapas  %s\n", runStatusIcon(run.Status), run.ID, run.Kind, run.Workflow, run.Completed(), len(run.Steps), input)
+		}
+		return
+	}
+	
+	args, format, out := exportFlags(args)
+	if len(args) < 2 || (args[0] != "show" && args[0] != "resume" && args[0] != "export") {
+		fmt.Println("Uso: plaxo runs [list] | runs show <id> | runs resume <id> | runs export <id> [--format mermaid|dot|json] [--out arquivo]")
+		os.Exit(1)
+	}
+	
+	run, err := workflow.LoadRun(workingDir, args[1])
+	if err != nil {
+		fmt.Printf("❌ Erro: %v\n", err)
+		os.Exit(1)
+	}
+	
+	switch args[0] {
+	case "show":
+		showRun(run)
+		return
+	case "export":
+		writeExport(run.Rebuild(), run.Input, run.Results, format, out)
+		return
+	}
+	
+	if !run.Resumable() {
+		fmt.Printf("✅ Execução %s já foi concluída\n", run.ID)
+		return
+	}
+	
+	if err := resumeRun(workingDir, orch, run); err != nil {
+		fmt.Printf("❌ Erro: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runPlan planeja o pedido como o chat faria e exporta o DAG, sem executar nenhuma etapa.
+func runPlan(orch *orchestrator.EnhancedOrchestrator, args []string) {
+	args, format, out := exportFlags(args)
+	if len(args) == 0 {
+		fmt.Println("Uso: plaxo plan \"<mensagem>\" [--format mermaid|dot|json] [--out arquivo]")
+		os.Exit(1)
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	
+	wf, err := orch.Plan(ctx, strings.Join(args, " "))
+	if err != nil {
+		fmt.Printf("❌ Erro planejando workflow: %v\n", err)
+		os.Exit(1)
+	}
+	writeExport(wf, strings.Join(args, " "), nil, format, out)
+}
+
+// exportFlags separa --format (padrão mermaid) e --out dos demais argumentos.
+func exportFlags(args []string) ([]string, string, string) {
+	format, out := workflow.FormatMermaid, ""
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--format", "--out":
+			if i+1 >= len(args) {
+				fmt.Printf("❌ Valor ausente para %s\n", args[i])
+				os.Exit(1)
+			}
+			if args[i] == "--format" {
+				format = args[i+1]
+			} else {
+				out = args[i+1]
+			}
+			i++
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return rest, format, out
+}
+
+// writeExport imprime o DAG exportado ou grava em arquivo com --out.
+func writeExport(wf *workflow.Workflow, input string, results map[string]*workflow.Result, format, out string) {
+	content, err := workflow.Export(wf, input, results, format)
+	if err != nil {
+		fmt.Printf("❌ Erro exportando workflow: %v\n", err)
+		os.Exit(1)
+	}
+	
+	if out == "" {
+		fmt.Print(content)
+		return
+	}
+	if err := os.WriteFile(out, []byte(content), 0644); err != nil {
+		fmt.Printf("❌ Erro gravando %s: %v\n", out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Workflow exportado para %s\n", out)
+}
+
+func runContracts(workingDir string) {
+	registry, err := contracts.LoadAll(workingDir)
+	if err != nil {
+		fmt.Printf("❌ Erro lendo contratos: %v\n", err)
+		os.Exit(1)
+	}
+	if len(registry) == 0 {
+		fmt.Println("📭 Nenhum contrato registrado (são extraídos das análises na coordenação entre domínios)")
+		return
+	}
+	
+	var domains []string
+	for domain := range registry {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	
+	fmt.Println("📜 Contratos:")
+	for _, domain := range domains {
+		contract := registry[domain]
+		fmt.Printf("\n  %s (%s)\n", domain, contracts.Path(workingDir, domain))
+		for _, side := range []struct {
+			label   string
+			surface contracts.Surface
+		}{{"fornece", contract.Provides}, {"requer", contract.Requires}} {
+			for _, kind := range contracts.Kinds {
+				for _, item := range side.surface.Items(kind) {
+					from := ""
+					if item.From != "" {
+						from = " ← " + item.From
+					}
+					fmt.Printf("    %s %s: %s%s\n", side.label, kind, item.Name, from)
+				}
+			}
+		}
+	}
+	
+	problems := contracts.Check(registry)
+	if len(problems) == 0 {
+		fmt.Println("\n✅ Todos os requisitos têm fornecedor")
+		return
+	}
+	fmt.Println("\n⚠️  Requisitos sem fornecedor:")
+	for _, problem := range problems {
+		fmt.Printf("  • %s\n", problem)
+	}
+}
+
+func runMigrate(workingDir string, args []string) {
+	dryRun := len(args) > 0 && args[0] == "--dry-run"
+	
+	migrations, err := manifest.Migrate(workingDir, dryRun)
+	if err != nil {
+		fmt.Printf("❌ Erro migrando agentes: %v\n", err)
+		os.Exit(1)
+	}
+	if len(migrations) == 0 {
+		fmt.Printf("✅ Todos os agentes já usam o manifesto versão %d\n", manifest.Version)
+		return
+	}
+	
+	verb := "Migrado"
+	if dryRun {
+		verb = "Pendente"
+	}
+	for _, migration := range migrations {
+		rel, _ := filepath.Rel(workingDir, migration.Dir)
+		fmt.Printf("  📦 %s: %s (%s) → %s v%d\n", verb, migration.Domain, rel, manifest.FileName, manifest.Version)
+		fmt.Printf("     a partir de: %s\n", strings.Join(migration.Upgraded, ", "))
+	}
+	if dryRun {
+		fmt.Println("💡 Execute 'plaxo migrate' sem --dry-run para gravar os manifestos")
+	}
+}
+
+// resumeRun entrega a execução a quem sabe retomá-la, conforme o tipo.
+func resumeRun(workingDir string, orch *orchestrator.EnhancedOrchestrator, run *workflow.Run) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	
+	switch run.Kind {
+	case workflow.RunKindChat:
+		return orch.ResumeRun(ctx, run)
+	case workflow.RunKindCoordination:
+		return orch.ResumeCoordination(ctx, run)
+	case workflow.RunKindOrchestrate:
+		agentManager := orchestrator.NewAgentManager(workingDir)
+		if err := agentManager.LoadConfiguration(); err != nil {
+			return err
+		}
+		return agentManager.ResumeOrchestration(ctx, run)
+	}
+	return fmt.Errorf("execuções do tipo %s não podem ser retomadas", run.Kind)
+}
+
+func runApprove(workingDir string, orch *orchestrator.EnhancedOrchestrator, args []string) {
+	if len(args) == 0 {
+		// Os pedidos gravados incluem as escritas escaladas pelo sandbox, que não são etapas do plano
+		pending, err := approval.ListPending(workingDir)
+		if err != nil {
+			fmt.Printf("❌ Erro lendo aprovações: %v\n", err)
+			os.Exit(1)
+		}
+		
+		for _, req := range pending {
+			fmt.Printf("  ⏸️  orchestra approve %s %s  (%s)\n", req.Run, req.Step, req.Title)
+		}
+		if len(pending) == 0 {
+			fmt.Println("📭 Nenhuma aprovação pendente")
+		}
+		return
+	}
+	
+	if len(args) < 2 {
+		fmt.Println("Uso: plaxo approve <run> <etapa> [--reject a,b] [--skip c] [--note motivo]")
+		os.Exit(1)
+	}
+	
+	req, err := approval.Load(workingDir, args[0], args[1])
+	if err != nil {
+		fmt.Printf("❌ Erro: %v\n", err)
+		os.Exit(1)
+	}
+	
+	note := ""
+	decisions := make(map[string]approval.Decision)
+	for i := 2; i < len(args); i++ {
+		flag := args[i]
+		if i+1 >= len(args) {
+			fmt.Printf("❌ Valor ausente para %s\n", flag)
+			os.Exit(1)
+		}
+		i++
+		
+		switch flag {
+		case "--reject", "--skip":
+			decision := approval.Rejected
+			if flag == "--skip" {
+				decision = approval.Skipped
+			}
+			for _, key := range strings.Split(args[i], ",") {
+				if req.Item(strings.TrimSpace(key)) == nil {
+					fmt.Printf("❌ Item desconhecido: %s\n", key)
+					os.Exit(1)
+				}
+				decisions[strings.TrimSpace(key)] = decision
+			}
+		case "--note":
+			note = args[i]
+		default:
+			fmt.Printf("❌ Opção desconhecida: %s\n", flag)
+			os.Exit(1)
+		}
+	}
+	
+	for key, decision := range decisions {
+		item := req.Item(key)
+		item.Decision = decision
+		if decision == approval.Rejected {
+			item.Note = note
+		}
+	}
+	req.Set(approval.Approved)
+	if err := approval.Save(workingDir, req); err != nil {
+		fmt.Printf("❌ Erro gravando aprovação: %v\n", err)
+		os.Exit(1)
+	}
+	
+	for _, decision := range []approval.Decision{approval.Approved, approval.Skipped, approval.Rejected} {
+		if keys := req.Keys(decision); len(keys) > 0 {
+			fmt.Printf("🧑‍⚖️ %s: %s\n", decision.Label(), strings.Join(keys, ", "))
+		}
+	}
+	
+	run, err := workflow.LoadRun(workingDir, req.Run)
+	if err != nil {
+		fmt.Printf("❌ Erro: %v\n", err)
+		os.Exit(1)
+	}
+	
+	// A etapa de aprovação roda de novo para ler a decisão gravada
+	delete(run.Results, req.Step)
+	if err := resumeRun(workingDir, orch, run); err != nil {
+		fmt.Printf("❌ Erro: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+func showRun(run *workflow.Run) {
+	fmt.Printf("🗂️  Execução %s\n", run.ID)
+	fmt.Printf("   Tipo: %s (%s)\n", run.Kind, run.Workflow)
+	fmt.Printf("   Status: %s %s\n", runStatusIcon(run.Status), run.Status)
+	fmt.Printf("   Início: %s  Atualizada: %s\n", run.CreatedAt.Format("2006-01-02 15:04:05"), run.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("   Input: %s\n", run.Input)
+	if run.Error != "" {
+		fmt.Printf("   Erro: %s\n", run.Error)
+	}
+	if run.Blackboard != nil {
+		if summary := run.Blackboard.Summary(); summary != "" {
+			fmt.Println("   Quadro compartilhado:")
+			for _, line := range strings.Split(summary, "\n") {
+				fmt.Printf("     %s\n", line)
+			}
+		}
+	}
+	
+	if run.Consultations != nil {
+		if exchanges := run.Consultations.Exchanges(); len(exchanges) > 0 {
+			fmt.Println("   Consultas entre agentes:")
+			for _, exchange := range exchanges {
+				fmt.Printf("     💬 %s → %s: %s\n", exchange.From, exchange.To, exchange.Question)
+				if exchange.Answered() {
+					fmt.Printf("        ↩️  %s\n", strings.ReplaceAll(strings.TrimSpace(exchange.Answer), "\n", "\n           "))
+				} else {
+					fmt.Printf("        🚫 %s\n", exchange.Error)
+				}
+			}
+		}
+	}
+	
+	for _, step := range run.Steps {
+		status := workflow.StatusPending
+		result := run.Results[step.ID]
+		if result != nil {
+			status = result.Status
+		}
+		
+		fmt.Printf("\n%s %s (%s)", runStatusIcon(status), step.ID, step.Agent)
+		if len(step.DependsOn) > 0 {
+			fmt.Printf(" ← %s", strings.Join(step.DependsOn, ", "))
+		}
+		fmt.Println()
+		if result == nil {
+			continue
+		}
+		if result.Attempts > 0 {
+			fmt.Printf("   %d tentativas, %v\n", result.Attempts, result.Duration().Round(time.Millisecond))
+		}
+		if result.Error != "" {
+			fmt.Printf("   Erro: %s\n", result.Error)
+		}
+		if result.Output != "" {
+			fmt.Println(strings.Repeat("─", 50))
+			fmt.Println(result.Output)
+		}
+	}
+}
+
+func runStatusIcon(status workflow.Status) string {
+	switch status {
+	case workflow.StatusSucceeded:
+		return "✅"
+	case workflow.StatusFailed:
+		return "❌"
+	case workflow.StatusRunning:
+		return "🔄"
+	case workflow.StatusWaiting:
+		return "⏸️ "
+	case workflow.StatusSkipped:
+		return "⏭️ "
+	}
+	return "⏳"
+}
+
+// parseGlobalFlags separa as flags globais (--nome valor ou --nome=valor) dos argumentos do comando.
+func parseGlobalFlags(argv []string) ([]string, map[string]string) {
+	known := map[string]bool{"backend": true, "model": true, "record": true, "replay": true, "locale": true}
+	flags := make(map[string]string)
+	var args []string
+	
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		if len(args) > 0 || !strings.HasPrefix(arg, "--") {
+			args = append(args, arg)
+			continue
+		}
+		
+		name := strings.TrimPrefix(arg, "--")
+		value := ""
+		if eq := strings.Index(name, "="); eq != -1 {
+			name, value = name[:eq], name[eq+1:]
+		} else if i+1 < len(argv) {
+			i++
+			value = argv[i]
+		}
+		
+		if !known[name] {
+			fmt.Printf("Flag desconhecida: --%s\n", name)
+			os.Exit(1)
+		}
+		flags[name] = value
+	}
+	
+	return args, flags
+}
+
 func runEnhancedInteractive(orch *orchestrator.EnhancedOrchestrator) {
 	fmt.Println("🧠 Plaxo Orchestra v2.0 - Modo Interativo com Streaming")
 	fmt.Println("Comandos especiais:")
@@ -195,9 +763,35 @@ func showAdvancedInsights(orch *orchestrator.EnhancedOrchestrator) {
 		fmt.Printf("📅 Padrões Temporais: %d identificados\n", patterns)
 	}
 	
+	// Token usage
+	if summary, ok := insights["usage"].(*usage.Summary); ok && summary.Total.Calls > 0 {
+		fmt.Printf("🪙 Tokens: %d em %d chamadas (%d sessões)\n", summary.Total.Tokens(), summary.Total.Calls, len(summary.BySession))
+		if summary.Total.Cost > 0 {
+			fmt.Printf("💰 Custo estimado: $%.4f\n", summary.Total.Cost)
+		}
+	}
+	
 	fmt.Println()
 }
 
+func printUsageTotals(title string, totals map[string]usage.Totals) {
+	if len(totals) == 0 {
+		return
+	}
+	
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	
+	fmt.Printf("  %s:\n", title)
+	for _, key := range keys {
+		t := totals[key]
+		fmt.Printf("    %s: %d chamadas, %d prompt + %d resposta tokens, $%.4f\n", key, t.Calls, t.PromptTokens, t.CompletionTokens, t.Cost)
+	}
+}
+
 func showMetrics(orch *orchestrator.EnhancedOrchestrator) {
 	fmt.Println("📈 Métricas de Performance:")
 	fmt.Println(strings.Repeat("=", 40))
@@ -223,6 +817,49 @@ func showMetrics(orch *orchestrator.EnhancedOrchestrator) {
 		}
 	}
 	
+	// Show token usage
+	if summary, ok := insights["usage"].(*usage.Summary); ok && summary.Total.Calls > 0 {
+		fmt.Println("\n🪙 Uso de tokens:")
+		fmt.Printf("  Total: %d chamadas, %d prompt + %d resposta tokens, $%.4f\n",
+			summary.Total.Calls, summary.Total.PromptTokens, summary.Total.CompletionTokens, summary.Total.Cost)
+		if summary.Total.EstimatedCalls > 0 {
+			fmt.Printf("  (%d chamadas com tokens estimados)\n", summary.Total.EstimatedCalls)
+		}
+		printUsageTotals("Por agente", summary.ByAgent)
+		printUsageTotals("Por etapa", summary.ByStep)
+		printUsageTotals("Por sessão", summary.BySession)
+	}
+	
+	// Show structured output reliability
+	if stats, ok := insights["structured"].(map[string]structured.Counters); ok && len(stats) > 0 {
+		fmt.Println("\n🧩 Saídas estruturadas:")
+		names := make([]string, 0, len(stats))
+		for name := range stats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := stats[name]
+			fmt.Printf("  %s: %.0f%% válidas (%d chamadas: %d de primeira, %d reparadas, %d inválidas, %d erros, %d heurísticas)\n",
+				name, c.SuccessRate()*100, c.Calls, c.FirstTry, c.Repaired, c.Invalid, c.Errors, c.Fallbacks)
+		}
+	}
+	
+	// Show sandbox violations
+	if stats, ok := insights["sandbox"].(map[string]sandbox.Counters); ok && len(stats) > 0 {
+		fmt.Println("\n🚫 Acessos fora das permissões:")
+		agents := make([]string, 0, len(stats))
+		for agent := range stats {
+			agents = append(agents, agent)
+		}
+		sort.Strings(agents)
+		for _, agent := range agents {
+			c := stats[agent]
+			fmt.Printf("  %s: %d violações (%d escritas, %d leituras), %d escaladas, %d aprovadas\n",
+				agent, c.Violations(), c.Writes, c.Reads, c.Escalated, c.Approved)
+		}
+	}
+	
 	fmt.Println()
 }
 
@@ -279,6 +916,8 @@ func runAgentSpread(workingDir string) {
 				os.Exit(1)
 			}
 			
+			indexDomains(workingDir, structure)
+			
 			fmt.Println("\n🎉 Agentes distribuídos com sucesso!")
 			fmt.Println("\n📋 Próximos passos:")
 			fmt.Println("  1. Execute: plaxo interactive")
@@ -290,6 +929,28 @@ func runAgentSpread(workingDir string) {
 	}
 }
 
+// indexDomains monta o índice de código de cada domínio para os prompts dos agentes.
+func indexDomains(workingDir string, structure *analyzer.AppStructure) {
+	fmt.Println("\n🔎 Indexando código dos domínios...")
+	for name, domain := range structure.Domains {
+		index, err := retrieval.Open(workingDir, name)
+		if err != nil {
+			fmt.Printf("⚠️  Erro abrindo índice de %s: %v\n", name, err)
+			continue
+		}
+		
+		changed, err := index.Update(domain.Files)
+		if err == nil {
+			err = index.Save()
+		}
+		if err != nil {
+			fmt.Printf("⚠️  Erro indexando %s: %v\n", name, err)
+			continue
+		}
+		fmt.Printf("  📇 %s: %d arquivos atualizados\n", name, changed)
+	}
+}
+
 func runAgentManager(workingDir string) {
 	fmt.Println("🤖 Plaxo Orchestra - Agent Manager")
 	fmt.Println("=================================")
@@ -312,7 +973,8 @@ func runAgentManager(workingDir string) {
 		fmt.Println("\n🤖 Comandos disponíveis:")
 		fmt.Println("  list                    - Listar todos os agentes")
 		fmt.Println("  <domain>.<command>      - Executar comando específico")
-		fmt.Println("  orchestrate <command>   - Executar comando de orquestração")
+		fmt.Println("  orchestrate <nome> [input] - Executar workflow de orquestração")
+		fmt.Println("  orchestrations          - Listar workflows de orquestração")
 		fmt.Println("  domains                 - Listar domínios disponíveis")
 		fmt.Println("  quit                    - Sair")
 		
@@ -342,9 +1004,16 @@ func runAgentManager(workingDir string) {
 				fmt.Printf("  %s: %v\n", domain, commands)
 			}
 			
+		case input == "orchestrations" || input == "orchestrate":
+			agentManager.ListOrchestrations()
+			
 		case strings.HasPrefix(input, "orchestrate "):
-			command := strings.TrimPrefix(input, "orchestrate ")
-			if err := agentManager.ExecuteOrchestrationCommand(command); err != nil {
+			parts := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(input, "orchestrate ")), " ", 2)
+			userInput := ""
+			if len(parts) > 1 {
+				userInput = parts[1]
+			}
+			if err := agentManager.ExecuteOrchestrationCommand(parts[0], userInput); err != nil {
 				fmt.Printf("❌ Erro: %v\n", err)
 			}
 			
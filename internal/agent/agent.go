@@ -1,18 +1,43 @@
 package agent
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
+	"plaxo-orchestra/internal/analyzer"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/blackboard"
+	"plaxo-orchestra/internal/budget"
+	"plaxo-orchestra/internal/consult"
+	"plaxo-orchestra/internal/contracts"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/memory"
+	"plaxo-orchestra/internal/patch"
 	"plaxo-orchestra/internal/pool"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/retrieval"
+	"plaxo-orchestra/internal/tools"
 	"strings"
+	"sync"
 )
 
+// Quantidade de trechos de código anexados a cada tarefa
+const relevantSnippets = 5
+
+// Quantidade de tarefas anteriores lembradas em cada tarefa
+const relevantMemories = 5
+
+// Rodadas de chamadas de ferramenta e consultas antes de seguir com a última resposta
+const maxTurnRounds = 5
+
 type Agent struct {
 	Name         string
 	Domain       string
 	Instructions string
-	Memory       []string
+	Manifest     *manifest.Manifest
+	// Memória de longo prazo (agents/memory.jsonl), aberta na primeira tarefa
+	Memory       *memory.Store
+	memoryOnce   sync.Once
 	WorkingDir   string
 	Pool         *pool.AgentPool
 }
@@ -26,82 +51,433 @@ func NewAgent(domain, workingDir string, agentPool *pool.AgentPool) *Agent {
 		Name:       name,
 		Domain:     domain,
 		WorkingDir: workingDir,
-		Memory:     make([]string, 0),
 		Pool:       agentPool,
 	}
 }
 
+// LoadInstructions carrega o manifesto do agente (agents/agent.yaml, ou o antigo instructions.txt).
 func (a *Agent) LoadInstructions() error {
-	// Suporte para bounded contexts
-	parts := strings.Split(a.Domain, "/")
-	var instructionsPath string
+	m, err := manifest.Find(a.WorkingDir, a.Domain)
+	if err != nil {
+		return fmt.Errorf("instructions not found for domain %s: %v", a.Domain, err)
+	}
+	a.SetManifest(m)
+	return nil
+}
+
+// SetManifest usa um manifesto já lido, como o do supervisor em orchestra_agents.
+func (a *Agent) SetManifest(m *manifest.Manifest) {
+	a.Manifest = m
+	a.Instructions = m.Instructions
+	if m.Model != "" {
+		a.Pool.SetModel(a.Domain, m.Model)
+	}
+}
+
+// remember grava a tarefa na memória do agente com o resumo da resposta e os arquivos propostos,
+// compactando as tarefas antigas quando passam do limite.
+func (a *Agent) remember(ctx context.Context, task, output string, taskErr error) {
+	store := a.memory()
+	if store == nil {
+		return
+	}
 	
-	if len(parts) == 1 {
-		// Domínio simples: user/agents/instructions.txt
-		instructionsPath = filepath.Join(a.WorkingDir, parts[0], "agents", "instructions.txt")
-	} else {
-		// Bounded context: user/profile/agents/instructions.txt
-		instructionsPath = filepath.Join(a.WorkingDir, parts[0], parts[1], "agents", "instructions.txt")
+	entry := memory.Entry{Task: memory.Task(ctx, task), Outcome: memory.Success, Summary: memory.Summarize(output)}
+	if taskErr != nil {
+		entry.Outcome, entry.Error = memory.Failure, taskErr.Error()
+	}
+	if proposal, err := patch.Propose(a.WorkingDir, a.Domain, output); err == nil {
+		for _, file := range proposal.Files {
+			entry.Files = append(entry.Files, file.Path)
+		}
+	}
+	if err := store.Add(entry); err != nil {
+		fmt.Printf("⚠️  Erro gravando memória de %s: %v\n", a.Domain, err)
+		return
 	}
 	
-	if _, err := os.Stat(instructionsPath); os.IsNotExist(err) {
-		return fmt.Errorf("instructions not found for domain %s", a.Domain)
+	if store.NeedsCompaction() {
+		if err := store.Compact(ctx, a.summarizeMemory); err != nil {
+			fmt.Printf("⚠️  Erro compactando memória de %s: %v\n", a.Domain, err)
+		}
+	}
+}
+
+// summarizeMemory pede ao modelo o novo resumo; sem resposta, junta as tarefas num resumo mecânico.
+func (a *Agent) summarizeMemory(ctx context.Context, previous string, entries []memory.Entry) (string, error) {
+	prompt := prompts.Render("memory_compaction", map[string]interface{}{
+		"Domain":   a.Domain,
+		"Previous": previous,
+		"Entries":  entries,
+	})
+	response, err := a.Pool.Backend().Complete(ctx, backend.Request{AgentID: "memory_compactor", Prompt: prompt})
+	if err != nil || strings.TrimSpace(response.Content) == "" {
+		return memory.Digest(previous, entries), nil
+	}
+	fmt.Printf("🗜️  Memória de %s compactada (%d tarefas resumidas)\n", a.Domain, len(entries))
+	return response.Content, nil
+}
+
+// memory abre a memória do agente na primeira vez em que é usada, uma vez só mesmo com etapas em paralelo.
+func (a *Agent) memory() *memory.Store {
+	a.memoryOnce.Do(func() {
+		if a.Memory != nil {
+			return
+		}
+		store, err := memory.Open(a.agentDir())
+		if err != nil {
+			fmt.Printf("⚠️  Erro lendo memória de %s: %v\n", a.Domain, err)
+			return
+		}
+		a.Memory = store
+	})
+	return a.Memory
+}
+
+// recall devolve o resumo periódico e as tarefas anteriores mais relacionadas à nova tarefa.
+func (a *Agent) recall(task string) string {
+	store := a.memory()
+	if store == nil {
+		return ""
 	}
 	
-	content, err := os.ReadFile(instructionsPath)
+	var lines []string
+	if summary := store.Summary(); summary != nil {
+		lines = append(lines, summary.String())
+	}
+	for _, entry := range store.Relevant(task, relevantMemories) {
+		lines = append(lines, "- "+entry.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// agentDir é o diretório do manifesto, ou <domínio>/agents antes de carregá-lo.
+func (a *Agent) agentDir() string {
+	if a.Manifest != nil {
+		return a.Manifest.Dir
+	}
+	return filepath.Join(a.WorkingDir, filepath.FromSlash(a.Domain), manifest.DirName)
+}
+
+func (a *Agent) Execute(task string) (string, error) {
+	return a.ExecuteContext(context.Background(), task)
+}
+
+func (a *Agent) ExecuteContext(ctx context.Context, task string) (string, error) {
+	board := blackboard.From(ctx)
+	var peers []string
+	if router := consult.From(ctx); router != nil {
+		peers = router.Peers(ctx, a.Domain)
+	}
+	prompt := a.buildPrompt(task, board, peers)
+
+	output, err := a.Pool.ExecuteContext(ctx, a.Domain, prompt)
+	if err == nil {
+		output, err = a.continueTurn(ctx, output, func(ctx context.Context, followUp string) (string, error) {
+			return a.Pool.ExecuteContext(ctx, a.Domain, followUp)
+		})
+	}
+	
+	// Decisões, tipos e perguntas publicados chegam aos próximos agentes da execução
+	if err == nil && board != nil {
+		board.Record(a.Domain, output)
+	}
+	a.remember(ctx, task, output, err)
+	
+	return output, err
+}
+
+// Consult responde a consulta de outro domínio numa chamada à parte, com as instruções, o contrato e o código
+// do domínio. A sessão do agente no pool e a memória dele ficam de fora: a consulta não muda o que ele lembra.
+func (a *Agent) Consult(ctx context.Context, from, question string) (string, error) {
+	var peers []string
+	if router := consult.From(ctx); router != nil {
+		peers = router.Peers(ctx, a.Domain)
+	}
+	prompt := a.buildConsultation(from, question, peers)
+
+	output, err := a.Pool.Complete(ctx, a.Domain, prompt)
 	if err != nil {
-		return err
+		return "", err
 	}
+	// Sem sessão, cada continuação leva a pergunta de novo
+	return a.continueTurn(ctx, output, func(ctx context.Context, followUp string) (string, error) {
+		return a.Pool.Complete(ctx, a.Domain, prompt+"\n\n"+followUp)
+	})
+}
+
+// Complete responde um pedido de coordenação (roteamento, plano, revisão, resumo) numa chamada sem sessão, com as
+// instruções do manifesto e a memória do agente, mas sem o envelope da tarefa, ferramentas ou consultas.
+// Nada é gravado: reparos da saída estruturada chamam de novo, e quem decide registra o resultado com Remember.
+func (a *Agent) Complete(ctx context.Context, prompt string) (string, error) {
+	return a.Pool.Complete(ctx, a.Domain, a.buildCompletion(ctx, prompt))
+}
+
+// Remember grava na memória do agente uma decisão tomada com Complete.
+func (a *Agent) Remember(ctx context.Context, task, decision string) {
+	a.remember(ctx, task, decision, nil)
+}
+
+// continueTurn atende as chamadas "@tool" e as consultas "@ask" da resposta na mesma rodada e continua o turno
+// com send, até o agente responder sem pedir nada ou acabarem as rodadas.
+func (a *Agent) continueTurn(ctx context.Context, output string, send func(context.Context, string) (string, error)) (string, error) {
+	allowed := a.allowedTools()
+	router := consult.From(ctx)
 	
-	a.Instructions = string(content)
-	return nil
+	for round := 1; ; round++ {
+		var calls []tools.Call
+		if len(allowed) > 0 {
+			calls = tools.Parse(output)
+		}
+		var questions []consult.Question
+		if router != nil {
+			questions = consult.Parse(output)
+		}
+		if len(calls) == 0 && len(questions) == 0 {
+			return output, nil
+		}
+		if round > maxTurnRounds {
+			fmt.Printf("⚠️  %s passou de %d rodadas de ferramentas e consultas; seguindo com a última resposta\n", a.Domain, maxTurnRounds)
+			return output, nil
+		}
+		
+		var results []tools.Result
+		if len(calls) > 0 {
+			for _, call := range calls {
+				fmt.Printf("🔧 %s → %s\n", a.Domain, call)
+			}
+			results = tools.Run(ctx, a.scope(), allowed, calls)
+		}
+		var exchanges []consult.Exchange
+		var peers []string
+		answered := false
+		if len(questions) > 0 {
+			exchanges = router.Ask(ctx, a.Domain, questions)
+			for _, exchange := range exchanges {
+				answered = answered || exchange.Answered()
+			}
+		}
+		if router != nil {
+			peers = router.Peers(ctx, a.Domain)
+		}
+		
+		followUp := prompts.Render("turn_results", map[string]interface{}{
+			"Domain":    a.Domain,
+			"Previous":  output,
+			"Results":   results,
+			"Exchanges": exchanges,
+			"Tools":     len(allowed) > 0,
+			"Peers":     peers,
+			"Remaining": maxTurnRounds - round,
+		})
+		next, err := send(ctx, followUp)
+		if err != nil {
+			return "", fmt.Errorf("erro continuando %s após ferramentas e consultas: %v", a.Domain, err)
+		}
+		output = next
+		
+		// Só consultas e nenhuma respondida: os limites já foram atingidos, não há por que perguntar de novo
+		if len(calls) == 0 && !answered {
+			return output, nil
+		}
+	}
 }
 
-func (a *Agent) SaveMemory(entry string) error {
-	a.Memory = append(a.Memory, entry)
+// allowedTools são as ferramentas do manifesto que estão registradas.
+func (a *Agent) allowedTools() []string {
+	if a.Manifest == nil {
+		return nil
+	}
+	var names []string
+	for _, tool := range tools.Allowed(a.Manifest.Tools) {
+		names = append(names, tool.Name())
+	}
+	return names
+}
+
+// scope limita as ferramentas ao que o agente pode ler: as permissões do manifesto ou os diretórios de código.
+// Um manifesto sem caminhos (o supervisor) lê o projeto inteiro.
+func (a *Agent) scope() tools.Scope {
+	scope := tools.Scope{Root: a.WorkingDir, Agent: a.Domain}
+	if a.Manifest != nil {
+		scope.Paths = a.Manifest.Policy(a.WorkingDir).Read
+		return scope
+	}
+	for _, dir := range a.codeDirs() {
+		if rel, err := filepath.Rel(a.WorkingDir, dir); err == nil {
+			scope.Paths = append(scope.Paths, filepath.ToSlash(rel))
+		}
+	}
+	return scope
+}
+
+// buildPrompt monta o prompt da tarefa dentro do orçamento de tokens do backend do pool.
+func (a *Agent) buildPrompt(task string, board *blackboard.Board, peers []string) string {
+	data := map[string]interface{}{
+		"Domain":       a.Domain,
+		"Instructions": "",
+		"Memory":       "",
+		"Files":        "",
+		"Task":         "",
+		"Contract":     "",
+		"Providers":    "",
+		"Unmatched":    []contracts.Problem{},
+		"Shared":       board != nil,
+		"Peers":        peers,
+		"Tools":        []tools.Tool{},
+		"Blackboard":   "",
+	}
+	if a.Manifest != nil {
+		data["Tools"] = tools.Allowed(a.Manifest.Tools)
+	}
+	overhead := backend.EstimateTokens(prompts.Render("agent_task", data))
 	
-	// Suporte para bounded contexts
-	parts := strings.Split(a.Domain, "/")
-	var memoryPath string
+	builder := budget.NewBuilder(backend.PromptBudget(a.Pool.Backend()) - overhead)
+	builder.Add(budget.Section{Name: "task", Content: task, Priority: 100, Required: true})
+	builder.Add(budget.Section{Name: "instructions", Content: a.Instructions, Priority: 80, Required: true})
+	contract, providers, unmatched := a.contracts()
+	builder.Add(budget.Section{Name: "contract", Content: contract, Priority: 70})
+	builder.Add(budget.Section{Name: "providers", Content: providers, Priority: 50})
+	if board != nil {
+		builder.Add(budget.Section{Name: "blackboard", Content: board.Summary(), Priority: 65})
+	}
+	builder.Add(budget.Section{Name: "files", Content: a.relevantCode(task), Priority: 60})
+	builder.Add(budget.Section{Name: "memory", Content: a.recall(task), Priority: 40})
 	
-	if len(parts) == 1 {
-		memoryPath = filepath.Join(a.WorkingDir, parts[0], "agents", "memory.txt")
-	} else {
-		memoryPath = filepath.Join(a.WorkingDir, parts[0], parts[1], "agents", "memory.txt")
+	fitted, report := builder.Build()
+	if report.Changed() {
+		fmt.Printf("✂️  Contexto de %s ajustado: %s\n", a.Name, report)
 	}
 	
-	os.MkdirAll(filepath.Dir(memoryPath), 0755)
+	data["Instructions"] = fitted["instructions"]
+	data["Task"] = fitted["task"]
+	data["Files"] = fitted["files"]
+	data["Contract"] = fitted["contract"]
+	data["Providers"] = fitted["providers"]
+	data["Blackboard"] = fitted["blackboard"]
+	if fitted["contract"] != "" {
+		data["Unmatched"] = unmatched
+	}
+	data["Memory"] = fitted["memory"]
+	return prompts.Render("agent_task", data)
+}
+
+// buildCompletion monta o prompt de Complete dentro do orçamento; a memória é buscada pelo pedido da execução.
+func (a *Agent) buildCompletion(ctx context.Context, prompt string) string {
+	data := map[string]interface{}{
+		"Instructions": "",
+		"Memory":       "",
+		"Prompt":       "",
+	}
+	overhead := backend.EstimateTokens(prompts.Render("agent_completion", data))
 	
-	f, err := os.OpenFile(memoryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
+	builder := budget.NewBuilder(backend.PromptBudget(a.Pool.Backend()) - overhead)
+	builder.Add(budget.Section{Name: "prompt", Content: prompt, Priority: 100, Required: true})
+	builder.Add(budget.Section{Name: "instructions", Content: a.Instructions, Priority: 80, Required: true})
+	builder.Add(budget.Section{Name: "memory", Content: a.recall(memory.Task(ctx, prompt)), Priority: 40})
+	
+	fitted, _ := builder.Build()
+	data["Instructions"] = fitted["instructions"]
+	data["Memory"] = fitted["memory"]
+	data["Prompt"] = fitted["prompt"]
+	return prompts.Render("agent_completion", data)
+}
+
+// buildConsultation monta o prompt de uma consulta de outro domínio, sem memória nem quadro da execução.
+func (a *Agent) buildConsultation(from, question string, peers []string) string {
+	data := map[string]interface{}{
+		"From":         from,
+		"Domain":       a.Domain,
+		"Question":     "",
+		"Instructions": "",
+		"Contract":     "",
+		"Files":        "",
+		"Peers":        peers,
+		"Tools":        []tools.Tool{},
 	}
-	defer f.Close()
+	if a.Manifest != nil {
+		data["Tools"] = tools.Allowed(a.Manifest.Tools)
+	}
+	overhead := backend.EstimateTokens(prompts.Render("consultation_question", data))
+	
+	builder := budget.NewBuilder(backend.PromptBudget(a.Pool.Backend()) - overhead)
+	builder.Add(budget.Section{Name: "question", Content: question, Priority: 100, Required: true})
+	builder.Add(budget.Section{Name: "instructions", Content: a.Instructions, Priority: 80, Required: true})
+	contract, _, _ := a.contracts()
+	builder.Add(budget.Section{Name: "contract", Content: contract, Priority: 70})
+	builder.Add(budget.Section{Name: "files", Content: a.relevantCode(question), Priority: 60})
 	
-	_, err = f.WriteString(entry + "\n")
-	return err
+	fitted, _ := builder.Build()
+	data["Question"] = fitted["question"]
+	data["Instructions"] = fitted["instructions"]
+	data["Contract"] = fitted["contract"]
+	data["Files"] = fitted["files"]
+	return prompts.Render("consultation_question", data)
 }
 
-func (a *Agent) Execute(task string) (string, error) {
-	context := fmt.Sprintf(`Domain: %s
-Instructions: %s
-Recent Memory: %s
-Task: %s`, a.Domain, a.Instructions, strings.Join(a.Memory[max(0, len(a.Memory)-5):], "\n"), task)
+// contracts devolve o contrato do domínio, o que os domínios dos quais ele depende fornecem e os requisitos sem fornecedor.
+func (a *Agent) contracts() (string, string, []contracts.Problem) {
+	registry, err := contracts.LoadAll(a.WorkingDir)
+	if err != nil {
+		fmt.Printf("⚠️  Erro lendo contratos: %v\n", err)
+		return "", "", nil
+	}
+	contract := registry[a.Domain]
+	if contract == nil {
+		return "", "", nil
+	}
+	
+	var providers []string
+	for _, domain := range contracts.Dependencies(registry, a.Domain) {
+		provided := &contracts.Contract{Domain: domain, Provides: registry[domain].Provides}
+		providers = append(providers, provided.YAML())
+	}
+	
+	var unmatched []contracts.Problem
+	for _, problem := range contracts.Check(registry) {
+		if problem.Domain == a.Domain {
+			unmatched = append(unmatched, problem)
+		}
+	}
+	return contract.YAML(), strings.Join(providers, "---\n"), unmatched
+}
 
-	output, err := a.Pool.Execute(a.Domain, context)
+// relevantCode atualiza o índice do domínio (só os arquivos alterados são relidos) e devolve os trechos mais relacionados à tarefa.
+func (a *Agent) relevantCode(task string) string {
+	index, err := retrieval.Open(a.WorkingDir, a.Domain)
+	if err != nil {
+		fmt.Printf("⚠️  Índice de código de %s indisponível: %v\n", a.Domain, err)
+		return ""
+	}
 	
-	if err == nil {
-		a.SaveMemory(fmt.Sprintf("Task: %s | Result: Success", task))
-	} else {
-		a.SaveMemory(fmt.Sprintf("Task: %s | Error: %v", task, err))
+	var files []string
+	for _, dir := range a.codeDirs() {
+		files = append(files, analyzer.CodeFiles(dir)...)
+	}
+	if _, err := index.Update(files); err != nil {
+		fmt.Printf("⚠️  Erro indexando %s: %v\n", a.Domain, err)
+	}
+	index.Prune(files)
+	if err := index.Save(); err != nil {
+		fmt.Printf("⚠️  Erro salvando índice de %s: %v\n", a.Domain, err)
 	}
 	
-	return output, err
+	return retrieval.Format(index.Search(task, relevantSnippets))
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
+// codeDirs são os diretórios de código declarados no manifesto, ou o diretório do domínio.
+func (a *Agent) codeDirs() []string {
+	if a.Manifest != nil && len(a.Manifest.Paths) > 0 {
+		var dirs []string
+		for _, path := range a.Manifest.Paths {
+			dirs = append(dirs, filepath.Join(a.WorkingDir, filepath.FromSlash(path)))
+		}
+		return dirs
+	}
+	
+	parts := strings.Split(a.Domain, "/")
+	if len(parts) > 2 {
+		parts = parts[:2]
 	}
-	return b
+	return []string{filepath.Join(append([]string{a.WorkingDir}, parts...)...)}
 }
@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/consult"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/memory"
+	"plaxo-orchestra/internal/pool"
+)
+
+// responder devolve a resposta da primeira regra cujo trecho aparece no prompt e guarda os prompts por agente.
+type responder struct {
+	mutex   sync.Mutex
+	rules   [][2]string
+	prompts map[string][]string
+}
+
+func (r *responder) Name() string { return "responder" }
+
+func (r *responder) Complete(ctx context.Context, req backend.Request) (*backend.Response, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.prompts == nil {
+		r.prompts = make(map[string][]string)
+	}
+	r.prompts[req.AgentID] = append(r.prompts[req.AgentID], req.Prompt)
+
+	// A regra vale para o fim do prompt: numa sessão, os turnos anteriores vêm antes
+	last := req.Prompt
+	if i := strings.LastIndex(last, "Nova mensagem:"); i != -1 {
+		last = last[i:]
+	}
+	for _, rule := range r.rules {
+		if strings.Contains(last, rule[0]) {
+			return &backend.Response{Content: rule[1]}, nil
+		}
+	}
+	return &backend.Response{Content: "ok"}, nil
+}
+
+func (r *responder) Stream(ctx context.Context, req backend.Request, onChunk func(string)) (*backend.Response, error) {
+	return r.Complete(ctx, req)
+}
+
+func (r *responder) Cancel() {}
+
+func newAgent(t *testing.T, root, domain string, agentPool *pool.AgentPool) *Agent {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, domain), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, domain, domain+".go"), []byte("package "+domain+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAgent(domain, root, agentPool)
+	a.SetManifest(&manifest.Manifest{
+		Domain:       domain,
+		Instructions: "cuida de " + domain,
+		Paths:        []string{domain},
+		Tools:        []string{"read_file"},
+		Dir:          filepath.Join(root, domain, manifest.DirName),
+	})
+	return a
+}
+
+func TestConsultationTurn(t *testing.T) {
+	root := t.TempDir()
+	script := &responder{rules: [][2]string{
+		{"Task: criar perfil", "@ask auth: qual o tipo do user ID?"},
+		{"pergunta ao domínio auth", "uuid"},
+		{"Respostas às suas consultas", "@tool read_file: user/user.go"},
+		{"Resultados das ferramentas", "perfil com ID uuid"},
+	}}
+	agentPool := pool.NewAgentPool()
+	agentPool.SetBackend(script)
+	defer agentPool.Close()
+
+	agents := map[string]*Agent{"auth": newAgent(t, root, "auth", agentPool), "user": newAgent(t, root, "user", agentPool)}
+	router := consult.NewRouter([]string{"auth", "user"}, 2, 6, consult.NewTrace(), func(ctx context.Context, from, to, question string) (string, error) {
+		return agents[to].Consult(ctx, from, question)
+	})
+	ctx := consult.With(context.Background(), router)
+
+	output, err := agents["user"].ExecuteContext(ctx, "criar perfil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A chamada @tool que veio depois da consulta também é atendida
+	if output != "perfil com ID uuid" {
+		t.Errorf("resposta = %q", output)
+	}
+
+	consulted := script.prompts["auth"]
+	if len(consulted) != 1 || !strings.Contains(consulted[0], "Instructions: cuida de auth") {
+		t.Fatalf("consulta deveria ser uma chamada com as instruções de auth: %q", consulted)
+	}
+
+	// A consulta não entra na sessão nem na memória de auth
+	if _, err := agents["auth"].ExecuteContext(context.Background(), "revisar login"); err != nil {
+		t.Fatal(err)
+	}
+	if last := script.prompts["auth"][1]; strings.Contains(last, "user ID") {
+		t.Errorf("sessão de auth carrega a consulta:\n%s", last)
+	}
+	if entries := agents["auth"].memory().Entries(); len(entries) != 1 || entries[0].Task != "revisar login" {
+		t.Errorf("memória de auth = %+v", entries)
+	}
+}
+
+func TestRememberStoresTaskNotPrompt(t *testing.T) {
+	root := t.TempDir()
+	agentPool := pool.NewAgentPool()
+	agentPool.SetBackend(&responder{})
+	defer agentPool.Close()
+	a := newAgent(t, root, "auth", agentPool)
+
+	// Etapas em paralelo abrem a memória uma vez só
+	var wg sync.WaitGroup
+	ctx := memory.WithInput(context.Background(), "adicionar login")
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := a.ExecuteContext(ctx, "Analise o pedido abaixo do ponto de vista do domínio auth:\nadicionar login"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries := a.memory().Entries()
+	if len(entries) != 4 {
+		t.Fatalf("%d lembranças, esperado 4", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Task != "adicionar login" {
+			t.Errorf("tarefa lembrada = %q", entry.Task)
+		}
+	}
+}
+
+func TestCompleteIsRaw(t *testing.T) {
+	root := t.TempDir()
+	script := &responder{rules: [][2]string{{"quais domínios", "@tool read_file: auth/auth.go\n@ask user: e você?"}}}
+	agentPool := pool.NewAgentPool()
+	agentPool.SetBackend(script)
+	defer agentPool.Close()
+	a := newAgent(t, root, "auth", agentPool)
+
+	ctx := memory.WithInput(context.Background(), "adicionar login")
+	ctx = consult.With(ctx, consult.NewRouter([]string{"auth", "user"}, 2, 6, consult.NewTrace(), func(ctx context.Context, from, to, question string) (string, error) {
+		t.Error("Complete não deve consultar outros domínios")
+		return "", nil
+	}))
+	a.Remember(ctx, "adicionar login", "Encaminhado para auth: login é do auth")
+
+	output, err := a.Complete(ctx, "Decida quais domínios atendem o pedido")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(output, "@tool") {
+		t.Errorf("a resposta deveria voltar sem rodar ferramentas: %q", output)
+	}
+
+	prompts := script.prompts["auth"]
+	if len(prompts) != 1 {
+		t.Fatalf("%d chamadas, esperado 1", len(prompts))
+	}
+	prompt := prompts[0]
+	for _, want := range []string{"cuida de auth", "Encaminhado para auth", "Decida quais domínios"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt sem %q:\n%s", want, prompt)
+		}
+	}
+	for _, unwanted := range []string{"Domain:", "@tool <nome>", "@ask <domínio>"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("prompt com %q:\n%s", unwanted, prompt)
+		}
+	}
+	if entries := a.memory().Entries(); len(entries) != 1 {
+		t.Errorf("%d lembranças, esperado só a decisão registrada com Remember", len(entries))
+	}
+}
@@ -1,10 +1,12 @@
 package analyzer
 
 import (
-	"encoding/json"
-	"fmt"
-	"os/exec"
+	"context"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/structured"
 	"strings"
+	"time"
 )
 
 type BoundedContext struct {
@@ -14,49 +16,26 @@ type BoundedContext struct {
 }
 
 type ProjectAnalysis struct {
-	BoundedContexts []BoundedContext `json:"bounded_contexts"`
+	BoundedContexts []BoundedContext `json:"bounded_contexts" minitems:"1"`
 }
 
 func AnalyzeProjectRequirements(input string) ([]BoundedContext, error) {
-	prompt := fmt.Sprintf(`
-Analise esta requisição de software e identifique os bounded contexts necessários:
-"%s"
+	prompt := prompts.Render("bounded_contexts", map[string]interface{}{"Input": input})
 
-Retorne APENAS um JSON válido no formato:
-{
-  "bounded_contexts": [
-    {"domain": "user", "context": "authentication", "description": "Login e autenticação"},
-    {"domain": "user", "context": "profile", "description": "Perfis de usuário"}
-  ]
-}
-
-Regras:
-- Crie bounded contexts específicos e granulares
-- Evite contextos muito amplos
-- Use nomes em inglês
-- Máximo 3-4 contextos por domínio
-- Seja específico para o que foi pedido
-`, input)
-
-	cmd := exec.Command("q", "chat", "--message", prompt)
-	output, err := cmd.Output()
-	if err != nil {
-		return getDefaultContexts(input), nil // Fallback
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
 
-	// Extrai JSON da resposta
-	response := string(output)
-	jsonStart := strings.Index(response, "{")
-	jsonEnd := strings.LastIndex(response, "}") + 1
-	
-	if jsonStart == -1 || jsonEnd <= jsonStart {
-		return getDefaultContexts(input), nil
+	complete := func(ctx context.Context, prompt string) (string, error) {
+		output, err := backend.Default().Complete(ctx, backend.Request{AgentID: "project_analyzer", Prompt: prompt})
+		if err != nil {
+			return "", err
+		}
+		return output.Content, nil
 	}
 	
-	jsonStr := response[jsonStart:jsonEnd]
-	
 	var analysis ProjectAnalysis
-	if err := json.Unmarshal([]byte(jsonStr), &analysis); err != nil {
+	if err := structured.Generate(ctx, complete, structured.Request{Name: "bounded_contexts", Prompt: prompt}, &analysis); err != nil {
+		structured.Fallback("bounded_contexts", err)
 		return getDefaultContexts(input), nil
 	}
 	
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"plaxo-orchestra/internal/manifest"
 	"strings"
+	
+	"gopkg.in/yaml.v2"
 )
 
 type AppStructure struct {
@@ -185,23 +188,42 @@ func (aa *AppAnalyzer) analyzeDomains(structure *AppStructure) error {
 }
 
 func (aa *AppAnalyzer) countFiles(dirPath string) []string {
+	return CodeFiles(dirPath)
+}
+
+var codeExts = []string{".py", ".js", ".ts", ".go", ".java", ".php", ".rb", ".cs", ".rs", ".jsx", ".tsx", ".vue"}
+
+// IsCodeFile indica se o arquivo tem extensão de código reconhecida.
+func IsCodeFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, codeExt := range codeExts {
+		if ext == codeExt {
+			return true
+		}
+	}
+	return false
+}
+
+// CodeFiles lista os arquivos de código sob dirPath, ignorando diretórios ocultos e de dependências.
+func CodeFiles(dirPath string) []string {
 	var files []string
 	
 	filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
+		if err != nil {
 			return nil
 		}
 		
-		ext := strings.ToLower(filepath.Ext(path))
-		codeExts := []string{".py", ".js", ".ts", ".go", ".java", ".php", ".rb", ".cs", ".rs", ".jsx", ".tsx", ".vue"}
-		
-		for _, codeExt := range codeExts {
-			if ext == codeExt {
-				files = append(files, path)
-				break
+		if info.IsDir() {
+			name := info.Name()
+			if path != dirPath && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
 			}
+			return nil
 		}
 		
+		if IsCodeFile(path) {
+			files = append(files, path)
+		}
 		return nil
 	})
 	
@@ -268,51 +290,34 @@ func (aa *AppAnalyzer) DeployAgents(structure *AppStructure) error {
 }
 
 func (aa *AppAnalyzer) createAgentStructure(domain, agentPath string, structure *AppStructure) error {
-	// Criar diretório do agente
-	if err := os.MkdirAll(agentPath, 0755); err != nil {
-		return err
-	}
-	
-	// Criar arquivo de configuração do agente
-	configContent := aa.generateAgentConfig(domain, structure)
-	configFile := filepath.Join(agentPath, "agent.yaml")
-	
-	return os.WriteFile(configFile, []byte(configContent), 0644)
+	// Manifesto do agente (agents/agent.yaml), o mesmo formato lido pelo chat e pelo gerenciador de agentes
+	return manifest.Save(aa.generateAgentConfig(domain, agentPath, structure))
 }
 
-func (aa *AppAnalyzer) generateAgentConfig(domain string, structure *AppStructure) string {
-	domainInfo := structure.Domains[domain]
+func (aa *AppAnalyzer) generateAgentConfig(domain, agentPath string, structure *AppStructure) *manifest.Manifest {
+	config := manifest.New(structure.RootPath, domain, "")
+	config.Dir = agentPath
+	config.TechStack = structure.TechStack
 	
-	config := fmt.Sprintf(`# Agente especializado para domínio: %s
-name: %s_agent
-domain: %s
-complexity: %d
-files_count: %d
-tech_stack: %v
-
-# Responsabilidades
-responsibilities:
-  - Análise de código do domínio %s
-  - Refatoração e otimização
-  - Testes e validação
-  - Documentação técnica
-
-# Contexto do domínio
-context:
-  path: %s
-  files: %d
-  
-# Comandos especializados
-commands:
-  analyze: "Analisar código do domínio %s"
-  refactor: "Refatorar código seguindo melhores práticas"
-  test: "Criar/executar testes para o domínio"
-  document: "Gerar documentação técnica"
-`, 
-		domain, domain, domain, 
-		domainInfo.Complexity, len(domainInfo.Files), structure.TechStack,
-		domain, domainInfo.Path, len(domainInfo.Files), domain)
+	domainInfo := structure.Domains[domain]
+	if domainInfo == nil {
+		// Agente de coordenação geral: atua sobre todos os domínios
+		config.Paths = nil
+		config.Responsibilities = []string{
+			"Coordenação entre os agentes de domínio",
+			"Decisões de arquitetura que envolvem mais de um domínio",
+			"Revisão da integração entre domínios",
+		}
+		config.Instructions = manifest.DefaultInstructions(domain, config.Responsibilities)
+		return config
+	}
 	
+	if rel, err := filepath.Rel(structure.RootPath, domainInfo.Path); err == nil {
+		config.Paths = []string{filepath.ToSlash(rel)}
+	}
+	config.Complexity = domainInfo.Complexity
+	config.FilesCount = len(domainInfo.Files)
+	config.Instructions = manifest.DefaultInstructions(domain, config.Responsibilities)
 	return config
 }
 
@@ -336,14 +341,89 @@ agents:
 		}
 	}
 	
-	config += `
-# Comandos de orquestração
-orchestration:
-  analyze_all: "Analisar todos os domínios"
-  refactor_all: "Refatorar aplicação completa"
-  test_all: "Executar todos os testes"
-  deploy_all: "Preparar deploy da aplicação"
-`
+	// Preserva workflows e seções definidos pelo time ao redistribuir
+	orchestration, extra := existingSections(configPath)
+	if orchestration == "" {
+		orchestration = defaultOrchestration
+	}
+	config += "\n# Workflows de orquestração (orchestrate <nome> [input])\n" + orchestration + extra
 	
 	return os.WriteFile(configPath, []byte(config), 0644)
 }
+
+const defaultOrchestration = `orchestration:
+  analyze_all:
+    description: "Analisar todos os domínios"
+    steps:
+      - id: analyze
+        agent: "*"
+        command: analyze
+        input: "Analisar código completo. {{.Input}}"
+  refactor_all:
+    description: "Refatorar aplicação completa"
+    steps:
+      - id: refactor
+        agent: "*"
+        command: refactor
+        input: "Refatorar seguindo melhores práticas. {{.Input}}"
+  test_all:
+    description: "Executar todos os testes"
+    steps:
+      - id: test
+        agent: "*"
+        command: test
+        input: "Executar testes completos. {{.Input}}"
+  deploy_all:
+    description: "Preparar deploy da aplicação"
+    mode: sequential
+    steps:
+      - id: test
+        agent: "*"
+        command: test
+        input: "Verificar se o domínio está pronto para deploy. {{.Input}}"
+      - id: document
+        agent: "*"
+        command: document
+        input: |
+          Preparar documentação para deploy considerando os testes:
+          {{.Steps.test}}
+`
+
+// generatedSections são as chaves reescritas a cada spread.
+var generatedSections = map[string]bool{
+	"app_name":      true,
+	"complexity":    true,
+	"tech_stack":    true,
+	"total_domains": true,
+	"agents":        true,
+	"orchestration": true,
+}
+
+// existingSections devolve a seção orchestration e as demais seções não geradas de um orchestra.yaml existente.
+func existingSections(configPath string) (string, string) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", ""
+	}
+	
+	var sections yaml.MapSlice
+	if err := yaml.Unmarshal(data, &sections); err != nil {
+		return "", ""
+	}
+	
+	var orchestration, extra string
+	for _, section := range sections {
+		key, _ := section.Key.(string)
+		out, err := yaml.Marshal(yaml.MapSlice{section})
+		if err != nil {
+			continue
+		}
+		switch {
+		case key == "orchestration":
+			orchestration = string(out)
+		case !generatedSections[key]:
+			extra += "\n" + string(out)
+		}
+	}
+	return orchestration, extra
+}
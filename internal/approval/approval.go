@@ -0,0 +1,210 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"plaxo-orchestra/internal/workflow"
+)
+
+type Decision string
+
+const (
+	Pending  Decision = ""
+	Approved Decision = "approved"
+	// Rejected barra o item e faz a execução terminar com falha; Skipped só o deixa de fora
+	Rejected Decision = "rejected"
+	Skipped  Decision = "skipped"
+)
+
+func (d Decision) Label() string {
+	switch d {
+	case Approved:
+		return "Aprovados"
+	case Rejected:
+		return "Rejeitados"
+	case Skipped:
+		return "Pulados"
+	}
+	return "Pendentes"
+}
+
+// Item é uma parte do que está sendo aprovado (ex: a análise de um domínio); Content pode ser editado.
+type Item struct {
+	Key      string   `json:"key"`
+	Content  string   `json:"content"`
+	Decision Decision `json:"decision"`
+	Note     string   `json:"note,omitempty"`
+}
+
+// Request é um ponto de aprovação de uma execução, gravado em .plaxo/approvals/<run>/<step>.json.
+type Request struct {
+	Run        string     `json:"run"`
+	Step       string     `json:"step"`
+	Title      string     `json:"title"`
+	Items      []*Item    `json:"items"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	// Attachment é o que quem pediu precisa para retomar depois da decisão (ex: a proposta de uma escrita escalada)
+	Attachment json.RawMessage `json:"attachment,omitempty"`
+}
+
+// Gate decide um pedido de aprovação; devolve workflow.ErrWaiting se a decisão ficar para depois.
+type Gate interface {
+	Decide(ctx context.Context, req *Request) (*Request, error)
+}
+
+func (r *Request) Item(key string) *Item {
+	for _, item := range r.Items {
+		if item.Key == key {
+			return item
+		}
+	}
+	return nil
+}
+
+// Resolved indica que todos os itens têm decisão.
+func (r *Request) Resolved() bool {
+	for _, item := range r.Items {
+		if item.Decision == Pending {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys devolve os itens com a decisão indicada, na ordem do pedido.
+func (r *Request) Keys(decision Decision) []string {
+	var keys []string
+	for _, item := range r.Items {
+		if item.Decision == decision {
+			keys = append(keys, item.Key)
+		}
+	}
+	return keys
+}
+
+// Set aplica a mesma decisão aos itens pendentes.
+func (r *Request) Set(decision Decision) {
+	for _, item := range r.Items {
+		if item.Decision == Pending {
+			item.Decision = decision
+		}
+	}
+}
+
+func (r *Request) Encode() string {
+	data, _ := json.MarshalIndent(r, "", "  ")
+	return string(data)
+}
+
+// Decode lê um pedido salvo como saída de etapa.
+func Decode(output string) (*Request, error) {
+	var req Request
+	if err := json.Unmarshal([]byte(output), &req); err != nil {
+		return nil, fmt.Errorf("aprovação inválida: %v", err)
+	}
+	return &req, nil
+}
+
+func Path(root, run, step string) string {
+	return filepath.Join(root, ".plaxo", "approvals", run, step+".json")
+}
+
+// Load lê um pedido pendente ou resolvido.
+func Load(root, run, step string) (*Request, error) {
+	data, err := os.ReadFile(Path(root, run, step))
+	if err != nil {
+		return nil, fmt.Errorf("aprovação %s/%s não encontrada: %v", run, step, err)
+	}
+	return Decode(string(data))
+}
+
+// ListPending lê os pedidos ainda sem decisão de todas as execuções, em ordem de execução e etapa.
+func ListPending(root string) ([]*Request, error) {
+	runs, err := os.ReadDir(filepath.Join(root, ".plaxo", "approvals"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*Request
+	for _, run := range runs {
+		if !run.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(root, ".plaxo", "approvals", run.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			step, ok := strings.CutSuffix(file.Name(), ".json")
+			if !ok || file.IsDir() {
+				continue
+			}
+			req, err := Load(root, run.Name(), step)
+			if err != nil {
+				return nil, err
+			}
+			if !req.Resolved() {
+				pending = append(pending, req)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// Save grava o pedido, marcando quando foi resolvido.
+func Save(root string, req *Request) error {
+	if req.Resolved() && req.ResolvedAt == nil {
+		now := time.Now()
+		req.ResolvedAt = &now
+	}
+
+	path := Path(root, req.Run, req.Step)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(req.Encode()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// FileGate grava o pedido pendente e pausa a execução até `orchestra approve`.
+type FileGate struct {
+	Root string
+}
+
+func (g FileGate) Decide(ctx context.Context, req *Request) (*Request, error) {
+	if saved, err := Load(g.Root, req.Run, req.Step); err == nil {
+		if saved.Resolved() {
+			return saved, nil
+		}
+		return nil, fmt.Errorf("%w: orchestra approve %s %s", workflow.ErrWaiting, req.Run, req.Step)
+	}
+
+	req.CreatedAt = time.Now()
+	if err := Save(g.Root, req); err != nil {
+		return nil, fmt.Errorf("erro gravando aprovação pendente: %v", err)
+	}
+	fmt.Printf("⏸️  %s aguardando aprovação em %s\n", req.Title, Path(g.Root, req.Run, req.Step))
+	fmt.Printf("💡 Edite o campo content se quiser e rode: orchestra approve %s %s\n", req.Run, req.Step)
+	return nil, fmt.Errorf("%w: orchestra approve %s %s", workflow.ErrWaiting, req.Run, req.Step)
+}
+
+// Default pergunta no terminal quando há um; senão usa o arquivo de aprovação pendente.
+func Default(root string) Gate {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return NewTerminalGate(root, os.Stdin, os.Stdout)
+	}
+	return FileGate{Root: root}
+}
@@ -0,0 +1,143 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"plaxo-orchestra/internal/workflow"
+)
+
+func request(keys ...string) *Request {
+	req := &Request{Run: "run", Step: "aprovar", Title: "Aprovar análises"}
+	for _, key := range keys {
+		req.Items = append(req.Items, &Item{Key: key, Content: key + " ok"})
+	}
+	return req
+}
+
+func TestTerminalGateDecide(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Decision
+		note  string
+	}{
+		{"aprova tudo", "a\ns\n", []Decision{Approved, Approved}, ""},
+		{"rejeita com motivo e pula", "r\nfalta teste\np\n", []Decision{Rejected, Skipped}, "falta teste"},
+		{"resposta desconhecida pergunta de novo", "talvez\na\nn\n\n", []Decision{Approved, Rejected}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gate := NewTerminalGate(t.TempDir(), strings.NewReader(test.input), io.Discard)
+			req, err := gate.Decide(context.Background(), request("auth", "user"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, item := range req.Items {
+				if item.Decision != test.want[i] {
+					t.Errorf("%s: %s, esperado %s", item.Key, item.Decision, test.want[i])
+				}
+			}
+			if req.Items[0].Note != test.note {
+				t.Errorf("nota = %q, esperado %q", req.Items[0].Note, test.note)
+			}
+			if _, err := Load(gate.root, "run", "aprovar"); err != nil {
+				t.Errorf("decisão não gravada: %v", err)
+			}
+		})
+	}
+}
+
+func TestTerminalGateCancel(t *testing.T) {
+	in, write := io.Pipe()
+	gate := NewTerminalGate(t.TempDir(), in, io.Discard)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := gate.Decide(ctx, request("auth"))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("esperava prazo esgotado, veio %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("leitura do terminal ignorou o cancelamento")
+	}
+
+	// A linha digitada depois vale para a próxima pergunta em vez de se perder
+	go write.Write([]byte("a\n"))
+	req, err := gate.Decide(context.Background(), request("auth"))
+	if err != nil || req.Items[0].Decision != Approved {
+		t.Fatalf("decisão = %+v, erro %v", req.Items[0], err)
+	}
+}
+
+func TestFileGate(t *testing.T) {
+	gate := FileGate{Root: t.TempDir()}
+
+	for i := 0; i < 2; i++ {
+		if _, err := gate.Decide(context.Background(), request("auth")); !errors.Is(err, workflow.ErrWaiting) {
+			t.Fatalf("esperava ErrWaiting, veio %v", err)
+		}
+	}
+
+	saved, err := Load(gate.Root, "run", "aprovar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved.Items[0].Content = "editado"
+	saved.Set(Approved)
+	if err := Save(gate.Root, saved); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := gate.Decide(context.Background(), request("auth"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !req.Resolved() || req.ResolvedAt == nil || req.Items[0].Content != "editado" {
+		t.Errorf("pedido = %+v", req.Items[0])
+	}
+}
+
+func TestListPending(t *testing.T) {
+	root := t.TempDir()
+
+	approved := request("auth")
+	approved.Step = "revisar"
+	approved.Set(Approved)
+	escalated := request("user/registration")
+	escalated.Step = "implementar-sandbox-user-registration-3f2a"
+	for _, req := range []*Request{request("auth"), approved, escalated} {
+		if err := Save(root, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(Path(root, "run", "outro")+".tmp", []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := ListPending(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []string
+	for _, req := range pending {
+		steps = append(steps, req.Run+"/"+req.Step)
+	}
+	if want := []string{"run/aprovar", "run/implementar-sandbox-user-registration-3f2a"}; !reflect.DeepEqual(steps, want) {
+		t.Errorf("pendentes = %v, esperado %v", steps, want)
+	}
+}
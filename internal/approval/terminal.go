@@ -0,0 +1,182 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// TerminalGate mostra cada item e pergunta no terminal, permitindo editar o conteúdo antes de aprovar.
+type TerminalGate struct {
+	root string
+	in   io.Reader
+	out  io.Writer
+
+	// pending é a leitura em andamento; se o contexto for cancelado antes da resposta,
+	// a próxima pergunta aproveita a linha em vez de disputar o terminal com outra leitura
+	pending chan line
+	mutex   sync.Mutex
+}
+
+type line struct {
+	text string
+	err  error
+}
+
+func NewTerminalGate(root string, in io.Reader, out io.Writer) *TerminalGate {
+	return &TerminalGate{root: root, in: in, out: out}
+}
+
+func (t *TerminalGate) Decide(ctx context.Context, req *Request) (*Request, error) {
+	// Uma pergunta por vez no terminal, mesmo com etapas em paralelo
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// Uma decisão já tomada com `orchestra approve` vale também aqui
+	if saved, err := Load(t.root, req.Run, req.Step); err == nil && saved.Resolved() {
+		return saved, nil
+	}
+
+	fmt.Fprintf(t.out, "\n⏸️  %s\n", req.Title)
+	for i, item := range req.Items {
+		if item.Decision != Pending {
+			continue
+		}
+		if err := t.decide(ctx, item, i+1, len(req.Items)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := Save(t.root, req); err != nil {
+		fmt.Fprintf(t.out, "⚠️  Erro gravando aprovação: %v\n", err)
+	}
+	return req, nil
+}
+
+func (t *TerminalGate) decide(ctx context.Context, item *Item, index, total int) error {
+	fmt.Fprintf(t.out, "\n📋 [%d/%d] %s\n", index, total, item.Key)
+	fmt.Fprintln(t.out, strings.Repeat("─", 50))
+	fmt.Fprintln(t.out, item.Content)
+	fmt.Fprintln(t.out, strings.Repeat("─", 50))
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fmt.Fprint(t.out, "❓ [a]provar/[e]ditar/[r]ejeitar/[p]ular: ")
+		answer, err := t.readLine(ctx)
+		if err != nil {
+			fmt.Fprintln(t.out)
+			return fmt.Errorf("aprovação interrompida: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "a", "aprovar", "s", "sim", "y", "yes":
+			item.Decision = Approved
+			return nil
+		case "r", "rejeitar", "n", "não", "nao", "no":
+			item.Decision = Rejected
+			fmt.Fprint(t.out, "Motivo (opcional): ")
+			item.Note, _ = t.readLine(ctx)
+			return nil
+		case "p", "pular", "skip":
+			item.Decision = Skipped
+			return nil
+		case "e", "editar", "edit":
+			edited, err := t.edit(ctx, item.Content)
+			if err != nil {
+				fmt.Fprintf(t.out, "⚠️  Erro editando: %v\n", err)
+				continue
+			}
+			item.Content = edited
+			fmt.Fprintln(t.out, "✏️  Conteúdo atualizado")
+		}
+	}
+}
+
+// edit abre o $EDITOR com o conteúdo; sem editor, lê as novas linhas do terminal até uma linha com ".".
+func (t *TerminalGate) edit(ctx context.Context, content string) (string, error) {
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		file, err := os.CreateTemp("", "plaxo-approval-*.md")
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(file.Name())
+
+		if _, err := file.WriteString(content); err != nil {
+			file.Close()
+			return "", err
+		}
+		file.Close()
+
+		args := strings.Fields(editor)
+		cmd := exec.CommandContext(ctx, args[0], append(args[1:], file.Name())...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return "", err
+		}
+
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\n"), nil
+	}
+
+	fmt.Fprintln(t.out, "Digite o novo conteúdo e termine com uma linha contendo apenas \".\":")
+	var lines []string
+	for {
+		text, err := t.readLine(ctx)
+		if err != nil || text == "." {
+			break
+		}
+		lines = append(lines, text)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// readLine espera a próxima linha do terminal ou o cancelamento do contexto.
+func (t *TerminalGate) readLine(ctx context.Context) (string, error) {
+	if t.pending == nil {
+		pending := make(chan line, 1)
+		go func() {
+			text, err := readLine(t.in)
+			pending <- line{text: text, err: err}
+		}()
+		t.pending = pending
+	}
+
+	select {
+	case read := <-t.pending:
+		t.pending = nil
+		return read.text, read.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// readLine lê byte a byte para não consumir entrada além da linha (o stdin é compartilhado com o modo interativo).
+func readLine(in io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if len(line) > 0 {
+				return string(line), nil
+			}
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package backend
+
+import (
+	"context"
+	"sync"
+	"unicode/utf8"
+)
+
+// Backend abstrai o modelo de linguagem usado pelos agentes.
+type Backend interface {
+	Name() string
+	Complete(ctx context.Context, req Request) (*Response, error)
+	Stream(ctx context.Context, req Request, onChunk func(string)) (*Response, error)
+	Cancel()
+}
+
+type Request struct {
+	AgentID string
+	Prompt  string
+}
+
+type Response struct {
+	Content string
+	Usage   Usage
+}
+
+// Usage conta os tokens de uma chamada; Estimated indica que o backend não informou os valores.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	Estimated        bool
+}
+
+// EstimateTokens aproxima a contagem de tokens (~4 caracteres por token).
+func EstimateTokens(text string) int {
+	runes := utf8.RuneCountInString(text)
+	if runes == 0 {
+		return 0
+	}
+	return (runes + 3) / 4
+}
+
+// EstimateUsage preenche o uso estimado para backends que não reportam tokens.
+func EstimateUsage(prompt, completion string) Usage {
+	return Usage{
+		PromptTokens:     EstimateTokens(prompt),
+		CompletionTokens: EstimateTokens(completion),
+		Estimated:        true,
+	}
+}
+
+var (
+	defaultBackend Backend
+	defaultMutex   sync.RWMutex
+)
+
+// Default retorna o backend configurado para o processo (Amazon Q CLI se nada foi definido).
+func Default() Backend {
+	defaultMutex.RLock()
+	b := defaultBackend
+	defaultMutex.RUnlock()
+
+	if b != nil {
+		return b
+	}
+
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	if defaultBackend == nil {
+		defaultBackend = NewQBackend(Config{Type: TypeQ})
+	}
+	return defaultBackend
+}
+
+func SetDefault(b Backend) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultBackend = b
+}
+
+// inflight guarda as chamadas em andamento para permitir Cancel.
+type inflight struct {
+	mutex   sync.Mutex
+	next    int
+	cancels map[int]context.CancelFunc
+}
+
+func (f *inflight) track(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	f.mutex.Lock()
+	if f.cancels == nil {
+		f.cancels = make(map[int]context.CancelFunc)
+	}
+	id := f.next
+	f.next++
+	f.cancels[id] = cancel
+	f.mutex.Unlock()
+
+	return ctx, func() {
+		f.mutex.Lock()
+		delete(f.cancels, id)
+		f.mutex.Unlock()
+		cancel()
+	}
+}
+
+func (f *inflight) cancelAll() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for id, cancel := range f.cancels {
+		cancel()
+		delete(f.cancels, id)
+	}
+}
+
+type modelKey struct{}
+
+// WithModel faz as chamadas feitas com ctx usarem model no lugar do modelo configurado (ex: model do manifesto do agente).
+func WithModel(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelKey{}, model)
+}
+
+// modelFor devolve o modelo pedido em ctx, ou o configurado no backend.
+func modelFor(ctx context.Context, configured string) string {
+	if model, _ := ctx.Value(modelKey{}).(string); model != "" {
+		return model
+	}
+	return configured
+}
@@ -0,0 +1,288 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// Cassette guarda os pares prompt/resposta de uma sessão gravada.
+type Cassette struct {
+	Version    int       `json:"version"`
+	Backend    string    `json:"backend"`
+	RecordedAt time.Time `json:"recorded_at"`
+	// Janela do backend gravado, para que o replay monte os mesmos prompts
+	ContextWindow int           `json:"context_window,omitempty"`
+	Interactions  []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	AgentID  string `json:"agent_id"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReplayMismatchError indica um prompt que não existe na cassette.
+type ReplayMismatchError struct {
+	AgentID string
+	Prompt  string
+	Closest *Interaction
+	Diff    string
+}
+
+func (e *ReplayMismatchError) Error() string {
+	if e.Closest == nil {
+		return fmt.Sprintf("replay: nenhuma interação restante para o agente %q", e.AgentID)
+	}
+	return fmt.Sprintf("replay: prompt do agente %q não confere com a cassette\n%s", e.AgentID, e.Diff)
+}
+
+// CassettePath resolve o nome de uma cassette para .plaxo/cassettes/<nome>.json.
+func CassettePath(workingDir, name string) string {
+	if strings.HasSuffix(name, ".json") || strings.ContainsRune(name, filepath.Separator) {
+		return name
+	}
+	return filepath.Join(workingDir, ".plaxo", "cassettes", name+".json")
+}
+
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette não encontrada: %v", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("cassette inválida %s: %v", path, err)
+	}
+	return &cassette, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RecordingBackend repassa as chamadas para outro backend e grava cada interação.
+type RecordingBackend struct {
+	inner    Backend
+	path     string
+	cassette *Cassette
+	mutex    sync.Mutex
+}
+
+func NewRecordingBackend(inner Backend, path string) *RecordingBackend {
+	return &RecordingBackend{
+		inner: inner,
+		path:  path,
+		cassette: &Cassette{
+			Version:       1,
+			Backend:       inner.Name(),
+			RecordedAt:    time.Now(),
+			ContextWindow: ContextWindow(inner),
+		},
+	}
+}
+
+func (r *RecordingBackend) Name() string {
+	return r.inner.Name()
+}
+
+func (r *RecordingBackend) ContextWindow() int {
+	return ContextWindow(r.inner)
+}
+
+func (r *RecordingBackend) Complete(ctx context.Context, req Request) (*Response, error) {
+	response, err := r.inner.Complete(ctx, req)
+	r.record(req, response, err)
+	return response, err
+}
+
+func (r *RecordingBackend) Stream(ctx context.Context, req Request, onChunk func(string)) (*Response, error) {
+	response, err := r.inner.Stream(ctx, req, onChunk)
+	r.record(req, response, err)
+	return response, err
+}
+
+func (r *RecordingBackend) Cancel() {
+	r.inner.Cancel()
+}
+
+func (r *RecordingBackend) record(req Request, response *Response, err error) {
+	interaction := Interaction{AgentID: req.AgentID, Prompt: req.Prompt}
+	if response != nil {
+		interaction.Response = response.Content
+	}
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if saveErr := r.cassette.Save(r.path); saveErr != nil {
+		fmt.Printf("⚠️  Erro gravando cassette: %v\n", saveErr)
+	}
+}
+
+// ReplayBackend responde a partir de uma cassette, sem acessar nenhum modelo.
+type ReplayBackend struct {
+	cassette *Cassette
+	used     []bool
+	mutex    sync.Mutex
+}
+
+func NewReplayBackend(cassette *Cassette) *ReplayBackend {
+	return &ReplayBackend{
+		cassette: cassette,
+		used:     make([]bool, len(cassette.Interactions)),
+	}
+}
+
+func (r *ReplayBackend) Name() string {
+	return "replay"
+}
+
+func (r *ReplayBackend) ContextWindow() int {
+	return r.cassette.ContextWindow
+}
+
+func (r *ReplayBackend) Complete(ctx context.Context, req Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	interaction, err := r.next(req)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, fmt.Errorf("%s", interaction.Error)
+	}
+	return &Response{Content: interaction.Response, Usage: EstimateUsage(req.Prompt, interaction.Response)}, nil
+}
+
+func (r *ReplayBackend) Stream(ctx context.Context, req Request, onChunk func(string)) (*Response, error) {
+	response, err := r.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if onChunk != nil {
+		for _, line := range strings.SplitAfter(response.Content, "\n") {
+			if line != "" {
+				onChunk(line)
+			}
+		}
+	}
+	return response, nil
+}
+
+func (r *ReplayBackend) Cancel() {}
+
+// Remaining retorna quantas interações gravadas ainda não foram consumidas.
+func (r *ReplayBackend) Remaining() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := 0
+	for _, used := range r.used {
+		if !used {
+			count++
+		}
+	}
+	return count
+}
+
+// next consome a primeira interação não usada com o mesmo agente e prompt.
+// Chamadas paralelas podem chegar fora da ordem gravada, por isso a busca não é sequencial.
+func (r *ReplayBackend) next(req Request) (*Interaction, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var closest *Interaction
+	for i := range r.cassette.Interactions {
+		if r.used[i] {
+			continue
+		}
+		interaction := &r.cassette.Interactions[i]
+		if interaction.AgentID == req.AgentID && interaction.Prompt == req.Prompt {
+			r.used[i] = true
+			return interaction, nil
+		}
+		if closest == nil || (closest.AgentID != req.AgentID && interaction.AgentID == req.AgentID) {
+			closest = interaction
+		}
+	}
+
+	mismatch := &ReplayMismatchError{AgentID: req.AgentID, Prompt: req.Prompt, Closest: closest}
+	if closest != nil {
+		mismatch.Diff = diffLines(closest.Prompt, req.Prompt)
+	}
+	return nil, mismatch
+}
+
+// diffLines gera um diff linha a linha (LCS) entre o prompt gravado e o recebido.
+func diffLines(recorded, actual string) string {
+	a := strings.Split(recorded, "\n")
+	b := strings.Split(actual, "\n")
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff strings.Builder
+	diff.WriteString("--- gravado\n+++ recebido\n")
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return diff.String()
+}
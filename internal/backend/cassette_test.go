@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessao.json")
+	recorder := NewRecordingBackend(&echoBackend{window: 4096, response: "resposta"}, path)
+	for _, req := range []Request{{AgentID: "auth", Prompt: "login\nsenha"}, {AgentID: "user", Prompt: "perfil"}} {
+		if _, err := recorder.Complete(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cassette.Backend != "echo" || cassette.ContextWindow != 4096 || len(cassette.Interactions) != 2 {
+		t.Fatalf("cassette = %+v", cassette)
+	}
+
+	tests := []struct {
+		name    string
+		request Request
+		want    string
+		diff    string
+	}{
+		{name: "fora da ordem gravada", request: Request{AgentID: "user", Prompt: "perfil"}, want: "resposta"},
+		{name: "prompt diferente", request: Request{AgentID: "auth", Prompt: "login\nsenha forte"}, diff: "- senha\n+ senha forte"},
+		{name: "agente diferente", request: Request{AgentID: "billing", Prompt: "login\nsenha"}, diff: "  login\n  senha"},
+	}
+
+	replay := NewReplayBackend(cassette)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := replay.Complete(context.Background(), test.request)
+			if test.diff == "" {
+				if err != nil || response.Content != test.want {
+					t.Fatalf("resposta = %v, %v", response, err)
+				}
+				return
+			}
+			var mismatch *ReplayMismatchError
+			if !errors.As(err, &mismatch) || !strings.Contains(mismatch.Diff, test.diff) {
+				t.Fatalf("esperava diff com %q, veio %v", test.diff, err)
+			}
+		})
+	}
+	if replay.Remaining() != 1 {
+		t.Errorf("%d interações restantes, esperado 1", replay.Remaining())
+	}
+	if replay.ContextWindow() != 4096 {
+		t.Errorf("janela = %d", replay.ContextWindow())
+	}
+}
+
+func TestReplayExhausted(t *testing.T) {
+	replay := NewReplayBackend(&Cassette{Interactions: []Interaction{{AgentID: "auth", Prompt: "p", Response: "r"}}})
+	if _, err := replay.Complete(context.Background(), Request{AgentID: "auth", Prompt: "p"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := replay.Complete(context.Background(), Request{AgentID: "auth", Prompt: "p"})
+	var mismatch *ReplayMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Closest != nil {
+		t.Fatalf("esperava cassette esgotada, veio %v", err)
+	}
+}
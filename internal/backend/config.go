@@ -0,0 +1,110 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	TypeQ      = "q"
+	TypeOpenAI = "openai"
+	TypeOllama = "ollama"
+)
+
+// Config é a seção "backend" do orchestra.yaml.
+type Config struct {
+	Type      string `yaml:"type"`
+	Model     string `yaml:"model"`
+	Endpoint  string `yaml:"endpoint"`
+	APIKeyEnv string `yaml:"api_key_env"`
+	Timeout   int    `yaml:"timeout"`
+
+	// Custo em dólares por 1000 tokens, usado na contabilidade de uso
+	PromptCost     float64 `yaml:"cost_per_1k_prompt"`
+	CompletionCost float64 `yaml:"cost_per_1k_completion"`
+
+	// Janela de contexto em tokens; zero usa o padrão conhecido do modelo
+	ContextTokens int `yaml:"context_tokens"`
+
+	// Mode "record" grava as interações em Cassette; "replay" responde a partir dela.
+	Mode     string `yaml:"mode"`
+	Cassette string `yaml:"cassette"`
+
+	WorkingDir string `yaml:"-"`
+}
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 0
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// LoadConfig lê a seção backend do orchestra.yaml do projeto, se existir.
+func LoadConfig(workingDir string) Config {
+	cfg := Config{Type: TypeQ, WorkingDir: workingDir}
+
+	data, err := os.ReadFile(filepath.Join(workingDir, "orchestra.yaml"))
+	if err != nil {
+		return cfg
+	}
+
+	// Os campos ausentes no arquivo mantêm os valores padrão
+	file := struct {
+		Backend Config `yaml:"backend"`
+	}{Backend: cfg}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return cfg
+	}
+
+	if file.Backend.Type == "" {
+		file.Backend.Type = TypeQ
+	}
+	file.Backend.WorkingDir = workingDir
+	return file.Backend
+}
+
+func New(cfg Config) (Backend, error) {
+	switch cfg.Mode {
+	case "":
+		return newBackend(cfg)
+	case ModeReplay:
+		cassette, err := LoadCassette(CassettePath(cfg.WorkingDir, cfg.cassetteName()))
+		if err != nil {
+			return nil, err
+		}
+		return NewReplayBackend(cassette), nil
+	case ModeRecord:
+		inner, err := newBackend(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewRecordingBackend(inner, CassettePath(cfg.WorkingDir, cfg.cassetteName())), nil
+	default:
+		return nil, fmt.Errorf("modo de backend desconhecido: %s", cfg.Mode)
+	}
+}
+
+func newBackend(cfg Config) (Backend, error) {
+	switch cfg.Type {
+	case "", TypeQ:
+		return NewQBackend(cfg), nil
+	case TypeOpenAI:
+		return NewOpenAIBackend(cfg), nil
+	case TypeOllama:
+		return NewOllamaBackend(cfg), nil
+	default:
+		return nil, fmt.Errorf("backend desconhecido: %s", cfg.Type)
+	}
+}
+
+func (c Config) cassetteName() string {
+	if c.Cassette == "" {
+		return "default"
+	}
+	return c.Cassette
+}
@@ -0,0 +1,47 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want Config
+	}{
+		{"sem seção backend", "workflows: {}\n", Config{Type: TypeQ}},
+		{
+			"sem type, só preços",
+			"backend:\n  cost_per_1k_prompt: 0.003\n  cost_per_1k_completion: 0.015\n",
+			Config{Type: TypeQ, PromptCost: 0.003, CompletionCost: 0.015},
+		},
+		{
+			"sem type, demais campos",
+			"backend:\n  model: claude-sonnet\n  timeout: 30\n  endpoint: http://localhost\n  api_key_env: KEY\n  mode: replay\n  cassette: smart\n  context_tokens: 8000\n",
+			Config{Type: TypeQ, Model: "claude-sonnet", Timeout: 30, Endpoint: "http://localhost", APIKeyEnv: "KEY", Mode: ModeReplay, Cassette: "smart", ContextTokens: 8000},
+		},
+		{
+			"com type",
+			"backend:\n  type: ollama\n  model: llama3\n  cost_per_1k_prompt: 0.001\n",
+			Config{Type: TypeOllama, Model: "llama3", PromptCost: 0.001},
+		},
+		{"yaml inválido", "backend: [\n", Config{Type: TypeQ}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "orchestra.yaml"), []byte(test.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			test.want.WorkingDir = dir
+			if got := LoadConfig(dir); got != test.want {
+				t.Errorf("LoadConfig = %+v\nesperado %+v", got, test.want)
+			}
+		})
+	}
+}
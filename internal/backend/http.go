@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// postJSON envia body como JSON e devolve a resposta crua; o chamador fecha o corpo.
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return resp, nil
+}
+
+// readLines chama fn para cada linha não vazia do corpo (SSE e NDJSON).
+func readLines(body io.Reader, fn func(line string) (stop bool, err error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		stop, err := fn(line)
+		if err != nil {
+			return err
+		}
+		if stop {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func apiKeyFromEnv(name, fallback string) string {
+	if name == "" {
+		name = fallback
+	}
+	if name == "" {
+		return ""
+	}
+	return os.Getenv(name)
+}
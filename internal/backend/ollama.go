@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OllamaBackend usa um servidor local no formato da API /api/generate do Ollama.
+type OllamaBackend struct {
+	config  Config
+	client  *http.Client
+	running inflight
+}
+
+type ollamaRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+type ollamaResponse struct {
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+func NewOllamaBackend(cfg Config) *OllamaBackend {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "http://localhost:11434"
+	}
+	if cfg.Model == "" {
+		cfg.Model = "llama3"
+	}
+	return &OllamaBackend{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.timeout()},
+	}
+}
+
+func (o *OllamaBackend) Name() string {
+	return TypeOllama
+}
+
+func (o *OllamaBackend) ContextWindow() int {
+	return o.config.contextWindow()
+}
+
+func (o *OllamaBackend) url() string {
+	return strings.TrimRight(o.config.Endpoint, "/") + "/api/generate"
+}
+
+func (o *OllamaBackend) Complete(ctx context.Context, req Request) (*Response, error) {
+	return o.Stream(ctx, req, nil)
+}
+
+func (o *OllamaBackend) Stream(ctx context.Context, req Request, onChunk func(string)) (*Response, error) {
+	ctx, done := o.running.track(ctx)
+	defer done()
+
+	body := ollamaRequest{Model: modelFor(ctx, o.config.Model), Prompt: req.Prompt, Stream: true}
+	resp, err := postJSON(ctx, o.client, o.url(), apiKeyFromEnv(o.config.APIKeyEnv, ""), body)
+	if err != nil {
+		return nil, fmt.Errorf("ollama error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage Usage
+	err = readLines(resp.Body, func(line string) (bool, error) {
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return false, fmt.Errorf("ollama error: chunk inválido: %v", err)
+		}
+		if chunk.Error != "" {
+			return false, fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		content.WriteString(chunk.Response)
+		if onChunk != nil && chunk.Response != "" {
+			onChunk(chunk.Response)
+		}
+		if chunk.Done {
+			usage = Usage{PromptTokens: chunk.PromptEvalCount, CompletionTokens: chunk.EvalCount}
+		}
+		return chunk.Done, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		usage = EstimateUsage(req.Prompt, content.String())
+	}
+	return &Response{Content: content.String(), Usage: usage}, nil
+}
+
+func (o *OllamaBackend) Cancel() {
+	o.running.cancelAll()
+}
@@ -0,0 +1,156 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OpenAIBackend fala com qualquer endpoint compatível com /chat/completions.
+type OpenAIBackend struct {
+	config  Config
+	client  *http.Client
+	running inflight
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	Stream        bool                 `json:"stream"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+		Delta   openAIMessage `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (r openAIResponse) usage(prompt, completion string) Usage {
+	if r.Usage == nil {
+		return EstimateUsage(prompt, completion)
+	}
+	return Usage{PromptTokens: r.Usage.PromptTokens, CompletionTokens: r.Usage.CompletionTokens}
+}
+
+func NewOpenAIBackend(cfg Config) *OpenAIBackend {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://api.openai.com/v1"
+	}
+	if cfg.Model == "" {
+		cfg.Model = "gpt-4o-mini"
+	}
+	return &OpenAIBackend{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.timeout()},
+	}
+}
+
+func (o *OpenAIBackend) Name() string {
+	return TypeOpenAI
+}
+
+func (o *OpenAIBackend) ContextWindow() int {
+	return o.config.contextWindow()
+}
+
+func (o *OpenAIBackend) url() string {
+	return strings.TrimRight(o.config.Endpoint, "/") + "/chat/completions"
+}
+
+func (o *OpenAIBackend) request(ctx context.Context, req Request, stream bool) openAIRequest {
+	body := openAIRequest{
+		Model:    modelFor(ctx, o.config.Model),
+		Messages: []openAIMessage{{Role: "user", Content: req.Prompt}},
+		Stream:   stream,
+	}
+	if stream {
+		body.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	return body
+}
+
+func (o *OpenAIBackend) Complete(ctx context.Context, req Request) (*Response, error) {
+	ctx, done := o.running.track(ctx)
+	defer done()
+
+	resp, err := postJSON(ctx, o.client, o.url(), apiKeyFromEnv(o.config.APIKeyEnv, "OPENAI_API_KEY"), o.request(ctx, req, false))
+	if err != nil {
+		return nil, fmt.Errorf("openai error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("openai error: resposta inválida: %v", err)
+	}
+	if len(body.Choices) == 0 {
+		return nil, fmt.Errorf("openai error: resposta sem choices")
+	}
+
+	content := body.Choices[0].Message.Content
+	return &Response{Content: content, Usage: body.usage(req.Prompt, content)}, nil
+}
+
+func (o *OpenAIBackend) Stream(ctx context.Context, req Request, onChunk func(string)) (*Response, error) {
+	ctx, done := o.running.track(ctx)
+	defer done()
+
+	resp, err := postJSON(ctx, o.client, o.url(), apiKeyFromEnv(o.config.APIKeyEnv, "OPENAI_API_KEY"), o.request(ctx, req, true))
+	if err != nil {
+		return nil, fmt.Errorf("openai error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var last openAIResponse
+	err = readLines(resp.Body, func(line string) (bool, error) {
+		if !strings.HasPrefix(line, "data:") {
+			return false, nil
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return true, nil
+		}
+
+		var chunk openAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("openai error: chunk inválido: %v", err)
+		}
+		if chunk.Usage != nil {
+			last.Usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+			if onChunk != nil && choice.Delta.Content != "" {
+				onChunk(choice.Delta.Content)
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Content: content.String(), Usage: last.usage(req.Prompt, content.String())}, nil
+}
+
+func (o *OpenAIBackend) Cancel() {
+	o.running.cancelAll()
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// startPTY inicia cmd com um pseudo-terminal como stdin/stdout/stderr e devolve o lado mestre.
+//
+// O terminal começa sem eco e sem modo canônico, para que linhas longas não sejam cortadas
+// no limite do buffer de linha do kernel; o editor de linha do chat pode reconfigurá-lo.
+func startPTY(cmd *exec.Cmd) (*os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var unlock int32
+	if err := ioctl(master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		master.Close()
+		return nil, err
+	}
+	var number uint32
+	if err := ioctl(master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&number))); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", number), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	defer slave.Close()
+
+	var termios syscall.Termios
+	if err := ioctl(slave.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios))); err != nil {
+		master.Close()
+		return nil, err
+	}
+	termios.Lflag &^= syscall.ECHO | syscall.ICANON
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := ioctl(slave.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&termios))); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	// Linhas largas o bastante para o chat não quebrar o texto colado nem a resposta
+	size := struct{ rows, cols, x, y uint16 }{rows: 50, cols: 4096}
+	if err := ioctl(slave.Fd(), syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&size))); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+func ioctl(fd, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package backend
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// startPTY só é implementado no Linux; nas demais plataformas a sessão do Q reenvia o histórico.
+func startPTY(cmd *exec.Cmd) (*os.File, error) {
+	return nil, errors.New("pseudo-terminal não suportado nesta plataforma")
+}
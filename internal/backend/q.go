@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// QBackend executa o Amazon Q CLI, um processo por chamada.
+type QBackend struct {
+	config  Config
+	running inflight
+}
+
+func NewQBackend(cfg Config) *QBackend {
+	return &QBackend{config: cfg}
+}
+
+func (q *QBackend) Name() string {
+	return TypeQ
+}
+
+func (q *QBackend) ContextWindow() int {
+	return q.config.contextWindow()
+}
+
+func (q *QBackend) command(ctx context.Context, prompt string) (*exec.Cmd, *bytes.Buffer) {
+	return q.commandArgs(ctx, DetectQCapabilities().ChatArgs(prompt, modelFor(ctx, q.config.Model)))
+}
+
+func (q *QBackend) commandArgs(ctx context.Context, args []string) (*exec.Cmd, *bytes.Buffer) {
+	cmd := exec.CommandContext(ctx, "q", args...)
+
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	return cmd, stderr
+}
+
+func (q *QBackend) Complete(ctx context.Context, req Request) (*Response, error) {
+	ctx, done := q.running.track(ctx)
+	defer done()
+
+	cmd, stderr := q.command(ctx, req.Prompt)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, q.wrapError(ctx, err, stderr.String())
+	}
+
+	content := CleanOutput(string(output))
+	return &Response{Content: content, Usage: EstimateUsage(req.Prompt, content)}, nil
+}
+
+func (q *QBackend) Stream(ctx context.Context, req Request, onChunk func(string)) (*Response, error) {
+	ctx, done := q.running.track(ctx)
+	defer done()
+
+	cmd, stderr := q.command(ctx, req.Prompt)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, q.wrapError(ctx, err, "")
+	}
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := CleanOutput(scanner.Text())
+		if line == "" && scanner.Text() != "" {
+			continue
+		}
+		line += "\n"
+		content.WriteString(line)
+		if onChunk != nil {
+			onChunk(line)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, q.wrapError(ctx, err, stderr.String())
+	}
+
+	return &Response{Content: content.String(), Usage: EstimateUsage(req.Prompt, content.String())}, nil
+}
+
+func (q *QBackend) Cancel() {
+	q.running.cancelAll()
+}
+
+func (q *QBackend) wrapError(ctx context.Context, err error, stderr string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Q CLI timeout: %w", ctx.Err())
+	}
+	if ctx.Err() == context.Canceled {
+		return fmt.Errorf("Q CLI cancelado: %w", ctx.Err())
+	}
+	return classifyQError(err, stderr)
+}
@@ -0,0 +1,188 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQNotInstalled        = errors.New("Q CLI não encontrado no PATH")
+	ErrAuthExpired          = errors.New("autenticação do Q CLI expirada (execute 'q login')")
+	ErrRateLimited          = errors.New("limite de requisições do Q CLI atingido")
+	ErrToolApprovalRequired = errors.New("Q CLI pediu aprovação para usar uma ferramenta")
+)
+
+// QError carrega o código de saída e o stderr do Q CLI; errors.Is funciona com os erros tipados acima.
+type QError struct {
+	Kind     error
+	ExitCode int
+	Stderr   string
+}
+
+func (e *QError) Error() string {
+	if e.Kind != nil {
+		return fmt.Sprintf("Q CLI error (exit %d): %v", e.ExitCode, e.Kind)
+	}
+	return fmt.Sprintf("Q CLI error (exit %d): %s", e.ExitCode, e.Stderr)
+}
+
+func (e *QError) Unwrap() error {
+	return e.Kind
+}
+
+// QCapabilities descreve o que a versão instalada do Q CLI aceita.
+type QCapabilities struct {
+	Version       string
+	NoInteractive bool
+	Message       bool
+	Model         bool
+	TrustAllTools bool
+}
+
+var (
+	qCapabilities     *QCapabilities
+	qCapabilitiesOnce sync.Once
+)
+
+// DetectQCapabilities consulta "q --version" e "q chat --help" uma única vez por processo.
+func DetectQCapabilities() *QCapabilities {
+	qCapabilitiesOnce.Do(func() {
+		qCapabilities = probeQ()
+	})
+	return qCapabilities
+}
+
+func probeQ() *QCapabilities {
+	caps := &QCapabilities{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if output, err := exec.CommandContext(ctx, "q", "--version").Output(); err == nil {
+		caps.Version = strings.TrimSpace(CleanOutput(string(output)))
+	}
+
+	help, err := exec.CommandContext(ctx, "q", "chat", "--help").CombinedOutput()
+	if err != nil && len(help) == 0 {
+		// Sem help disponível: assume a interface atual do CLI
+		caps.NoInteractive = true
+		return caps
+	}
+
+	text := string(help)
+	caps.NoInteractive = strings.Contains(text, "--no-interactive")
+	caps.Message = strings.Contains(text, "--message")
+	caps.Model = strings.Contains(text, "--model")
+	caps.TrustAllTools = strings.Contains(text, "--trust-all-tools")
+	return caps
+}
+
+// ChatArgs monta o argv de uma chamada única conforme as capacidades detectadas.
+func (c *QCapabilities) ChatArgs(prompt, model string) []string {
+	args := []string{"chat"}
+	if c.Model && model != "" {
+		args = append(args, "--model", model)
+	}
+
+	switch {
+	case c.NoInteractive:
+		return append(args, "--no-interactive", prompt)
+	case c.Message:
+		return append(args, "--message", prompt)
+	default:
+		return append(args, prompt)
+	}
+}
+
+// SessionArgs monta o argv de uma sessão interativa.
+func (c *QCapabilities) SessionArgs(model string) []string {
+	args := []string{"chat"}
+	if c.Model && model != "" {
+		args = append(args, "--model", model)
+	}
+	return args
+}
+
+var (
+	ansiPattern    = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+	spinnerPattern = regexp.MustCompile(`^\s*[⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏]+\s*`)
+)
+
+// CleanOutput remove sequências ANSI, linhas sobrescritas com \r e o spinner do Q CLI.
+func CleanOutput(text string) string {
+	text = ansiPattern.ReplaceAllString(text, "")
+
+	lines := strings.Split(text, "\n")
+	cleaned := lines[:0]
+	for _, line := range lines {
+		if idx := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); idx != -1 {
+			line = line[idx+1:]
+		}
+		line = strings.TrimRight(line, "\r")
+
+		stripped := spinnerPattern.ReplaceAllString(line, "")
+		if stripped != line && (strings.TrimSpace(stripped) == "" || strings.HasSuffix(strings.TrimSpace(stripped), "...")) {
+			// Linha de spinner ("⠋ Thinking...")
+			continue
+		}
+		cleaned = append(cleaned, line)
+	}
+	return strings.Join(cleaned, "\n")
+}
+
+// classifyQError converte a saída de erro do Q CLI em um erro tipado.
+func classifyQError(err error, stderr string) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return ErrQNotInstalled
+	}
+
+	qErr := &QError{ExitCode: -1, Stderr: strings.TrimSpace(CleanOutput(stderr))}
+	if exitError, ok := err.(*exec.ExitError); ok {
+		qErr.ExitCode = exitError.ExitCode()
+		if qErr.Stderr == "" {
+			qErr.Stderr = strings.TrimSpace(CleanOutput(string(exitError.Stderr)))
+		}
+	}
+
+	// O código de saída decide primeiro; o texto do stderr só classifica o que ele não cobre
+	if kind, ok := qExitStatuses[qErr.ExitCode]; ok {
+		if kind == ErrQNotInstalled {
+			return ErrQNotInstalled
+		}
+		qErr.Kind = kind
+		return qErr
+	}
+	for _, known := range qErrorMessages {
+		if known.pattern.MatchString(qErr.Stderr) {
+			qErr.Kind = known.kind
+			break
+		}
+	}
+	return qErr
+}
+
+// qExitStatuses mapeia os códigos de saída com significado fixo: 126/127 do shell quando o
+// executável não roda e os de sysexits.h para permissão e falha temporária.
+var qExitStatuses = map[int]error{
+	75:  ErrRateLimited,   // EX_TEMPFAIL
+	77:  ErrAuthExpired,   // EX_NOPERM
+	126: ErrQNotInstalled, // encontrado, mas sem permissão de execução
+	127: ErrQNotInstalled,
+}
+
+// qErrorMessages reconhece as mensagens do Q CLI pelo início da linha (com ou sem "error:"),
+// para que um texto qualquer que cite "login" ou "429" não mude o tipo do erro.
+var qErrorMessages = []struct {
+	kind    error
+	pattern *regexp.Regexp
+}{
+	{ErrAuthExpired, regexp.MustCompile(`(?im)^\s*(error:\s*)?(you are not logged in|not logged in\b|your (session|token) has expired|authentication (token )?expired|please (run|use) .?q login)`)},
+	{ErrRateLimited, regexp.MustCompile(`(?im)^\s*(error:\s*)?(throttlingexception\b|too many requests\b|rate limit exceeded\b|(http )?status( code)?:? 429\b)`)},
+	{ErrToolApprovalRequired, regexp.MustCompile(`(?im)^\s*(error:\s*)?(tool approval required|use --trust-all-tools\b)`)},
+}
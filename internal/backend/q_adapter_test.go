@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestClassifyQError(t *testing.T) {
+	failed := errors.New("exit status 1")
+
+	tests := []struct {
+		name   string
+		err    error
+		stderr string
+		want   error
+	}{
+		{"não instalado", exec.ErrNotFound, "", ErrQNotInstalled},
+		{"não logado", failed, "error: You are not logged in, please log in with q login", ErrAuthExpired},
+		{"sessão expirada", failed, "Your session has expired", ErrAuthExpired},
+		{"throttling", failed, "error: ThrottlingException: slow down", ErrRateLimited},
+		{"429", failed, "HTTP status 429", ErrRateLimited},
+		{"aprovação de ferramenta", failed, "error: Tool approval required but --no-interactive was specified", ErrToolApprovalRequired},
+		{"login citado no texto", failed, "failed to compile login.go: 429 errors in handler", nil},
+		{"approval citado no texto", failed, "panic: waiting for approval of order 12", nil},
+		{"erro genérico", failed, "something went wrong", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := classifyQError(test.err, test.stderr)
+			if test.want == ErrQNotInstalled {
+				if err != ErrQNotInstalled {
+					t.Fatalf("esperava ErrQNotInstalled, veio %v", err)
+				}
+				return
+			}
+
+			var qErr *QError
+			if !errors.As(err, &qErr) {
+				t.Fatalf("esperava *QError, veio %T", err)
+			}
+			if qErr.Kind != test.want {
+				t.Errorf("Kind = %v, esperava %v", qErr.Kind, test.want)
+			}
+		})
+	}
+}
+
+func TestClassifyQErrorExitCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		stderr string
+		want   error
+	}{
+		{"comando não encontrado", 127, "", ErrQNotInstalled},
+		{"sem permissão de execução", 126, "", ErrQNotInstalled},
+		{"falha temporária", 75, "", ErrRateLimited},
+		{"sem permissão", 77, "", ErrAuthExpired},
+		{"código vence o texto", 77, "error: ThrottlingException: slow down", ErrAuthExpired},
+		{"código genérico usa o texto", 1, "error: ThrottlingException: slow down", ErrRateLimited},
+		{"código genérico sem texto conhecido", 1, "something went wrong", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", test.status)).Run()
+			got := classifyQError(err, test.stderr)
+			if test.want == ErrQNotInstalled {
+				if got != ErrQNotInstalled {
+					t.Fatalf("esperava ErrQNotInstalled, veio %v", got)
+				}
+				return
+			}
+
+			var qErr *QError
+			if !errors.As(got, &qErr) {
+				t.Fatalf("esperava *QError, veio %T", got)
+			}
+			if qErr.ExitCode != test.status || qErr.Kind != test.want {
+				t.Errorf("exit %d, Kind = %v; esperava exit %d, %v", qErr.ExitCode, qErr.Kind, test.status, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// qSession mantém um processo "q chat" interativo por agente, conversando por um pseudo-terminal.
+//
+// O processo roda no diretório do projeto e a conversa vive nele, sem nada salvo em disco:
+// sessões extras do mesmo agente são processos próprios e não interferem na principal.
+// O prompt vai intacto como texto colado (bracketed paste), e a resposta termina quando o
+// chat volta a mostrar o próprio prompt de entrada e a saída fica parada por quiet.
+type qSession struct {
+	backend *QBackend
+	agentID string
+	cmd     *exec.Cmd
+	tty     *os.File
+	output  chan string
+	exited  chan struct{}
+	waitErr error
+	// pending acumula a saída desde o último envio; ready indica que o chat já espera uma mensagem
+	pending strings.Builder
+	ready   bool
+	quiet   time.Duration
+	broken  bool
+	mutex   sync.Mutex
+}
+
+// Tempo sem saída, com o prompt do chat na tela, para considerar a resposta completa
+const qQuiet = 500 * time.Millisecond
+
+var (
+	// qReadyPrompt é o prompt de entrada do chat ("> ", "!> " com ferramentas liberadas, "[perfil] > ")
+	qReadyPrompt = regexp.MustCompile(`^(\[[^\]]*\] ?)?!?> ?$`)
+	// qToolPrompt é a pergunta do chat antes de usar uma ferramenta; sem resposta, o processo ficaria parado
+	qToolPrompt = regexp.MustCompile(`(?i)allow this action\?.*\[y/n`)
+)
+
+func (q *QBackend) OpenSession(ctx context.Context, agentID string) (Session, error) {
+	// O processo vive além do contexto da chamada que o abriu
+	cmd := exec.Command("q", DetectQCapabilities().SessionArgs(modelFor(ctx, q.config.Model))...)
+	cmd.Dir = q.config.WorkingDir
+	cmd.Env = append(os.Environ(), "TERM=xterm", "NO_COLOR=1")
+
+	tty, err := startPTY(cmd)
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, ErrQNotInstalled
+		}
+		// Sem pseudo-terminal: o histórico é reenviado a cada chamada
+		return NewHistorySession(q, agentID), nil
+	}
+
+	session := &qSession{
+		backend: q,
+		agentID: agentID,
+		cmd:     cmd,
+		tty:     tty,
+		output:  make(chan string, 256),
+		exited:  make(chan struct{}),
+		quiet:   qQuiet,
+	}
+
+	go session.readLoop()
+	go func() {
+		session.waitErr = cmd.Wait()
+		close(session.exited)
+	}()
+
+	return session, nil
+}
+
+func (s *qSession) readLoop() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.tty.Read(buf)
+		if n > 0 {
+			s.output <- string(buf[:n])
+		}
+		if err != nil {
+			close(s.output)
+			return
+		}
+	}
+}
+
+func (s *qSession) Send(ctx context.Context, prompt string) (*Response, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.healthy() {
+		return nil, fmt.Errorf("sessão Q do agente %s encerrada", s.agentID)
+	}
+
+	// Na primeira mensagem, espera as boas-vindas terminarem
+	if !s.ready {
+		if _, err := s.waitReady(ctx); err != nil {
+			return nil, err
+		}
+	}
+	s.pending.Reset()
+	s.ready = false
+
+	// Como texto colado, quebras de linha não enviam a mensagem antes da hora
+	if _, err := io.WriteString(s.tty, "\x1b[200~"+prompt+"\x1b[201~\r"); err != nil {
+		s.kill()
+		return nil, fmt.Errorf("Q CLI error: %v", err)
+	}
+
+	output, err := s.waitReady(ctx)
+	if err != nil {
+		return nil, err
+	}
+	content := reply(output, prompt)
+	return &Response{Content: content, Usage: EstimateUsage(prompt, content)}, nil
+}
+
+// waitReady lê a saída até o chat mostrar o prompt de entrada e ficar quieto, e devolve o que foi lido.
+func (s *qSession) waitReady(ctx context.Context) (string, error) {
+	for {
+		var idle <-chan time.Time
+		tail := lastLine(CleanOutput(tailOf(s.pending.String(), 1024)))
+		if qReadyPrompt.MatchString(tail) {
+			idle = time.After(s.quiet)
+		}
+
+		select {
+		case chunk, ok := <-s.output:
+			if !ok {
+				<-s.exited
+				s.broken = true
+				return "", classifyQError(s.waitErr, CleanOutput(s.pending.String()))
+			}
+			s.pending.WriteString(chunk)
+			if line := lastLine(CleanOutput(tailOf(s.pending.String(), 1024))); qToolPrompt.MatchString(line) {
+				s.kill()
+				return "", &QError{Kind: ErrToolApprovalRequired, ExitCode: -1, Stderr: strings.TrimSpace(line)}
+			}
+		case <-idle:
+			s.ready = true
+			return s.pending.String(), nil
+		case <-ctx.Done():
+			// Resposta parcial deixa o processo em estado desconhecido
+			s.kill()
+			return "", s.backend.wrapError(ctx, ctx.Err(), "")
+		}
+	}
+}
+
+// reply tira da saída o prompt colado, que o chat reescreve na tela antes da resposta, e o prompt de entrada no fim.
+func reply(output, prompt string) string {
+	text := strings.TrimRight(CleanOutput(output), " \r\n")
+	if i := strings.LastIndex(text, "\n"); i != -1 && qReadyPrompt.MatchString(text[i+1:]) {
+		text = text[:i]
+	} else if qReadyPrompt.MatchString(text) {
+		text = ""
+	}
+
+	if last := lastLine(strings.TrimRight(prompt, " \r\n")); strings.TrimSpace(last) != "" {
+		if i := strings.Index(text, strings.TrimSpace(last)); i != -1 {
+			text = text[i+len(strings.TrimSpace(last)):]
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+func lastLine(text string) string {
+	return text[strings.LastIndex(text, "\n")+1:]
+}
+
+func tailOf(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	return text[len(text)-size:]
+}
+
+func (s *qSession) Healthy() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.healthy()
+}
+
+func (s *qSession) healthy() bool {
+	select {
+	case <-s.exited:
+		return false
+	default:
+		return !s.broken
+	}
+}
+
+func (s *qSession) Close() error {
+	// Sem o mutex: Close também interrompe um Send em andamento
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+
+	s.mutex.Lock()
+	s.broken = true
+	s.mutex.Unlock()
+	return s.tty.Close()
+}
+
+func (s *qSession) kill() {
+	s.broken = true
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+}
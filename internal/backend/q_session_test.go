@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeQ instala no PATH um "q chat" interativo: mostra o prompt "> ", lê o texto colado até o fim do
+// bracketed paste, grava o que recebeu em received-<n>, reescreve o texto na tela como o editor de
+// linha faz e responde com o número da mensagem no processo.
+const fakeQ = `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "q 1.0.0"; exit 0; fi
+if [ "$2" = "--help" ]; then echo "--no-interactive --model"; exit 0; fi
+end=$(printf '\033[201~')
+echo "Welcome to fake q"
+printf '> '
+turn=0
+received=""
+while IFS= read -r line; do
+	received="$received$line
+"
+	case "$line" in *"$end"*) ;; *) continue ;; esac
+	turn=$((turn+1))
+	printf '%s' "$received" > "received-$turn"
+	printf '%s' "$received"
+	echo "turn=$turn dir=$(basename "$PWD")"
+	echo "> citação no meio da resposta"
+	printf '> '
+	received=""
+done
+`
+
+func TestQSessionSend(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "q"), []byte(fakeQ), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	workingDir := t.TempDir()
+	q := NewQBackend(Config{Type: TypeQ, WorkingDir: workingDir})
+	open := func() Session {
+		session, err := q.OpenSession(context.Background(), "user/registration")
+		if err != nil {
+			t.Fatal(err)
+		}
+		qs, ok := session.(*qSession)
+		if !ok {
+			t.Skip("plataforma sem pseudo-terminal")
+		}
+		qs.quiet = 50 * time.Millisecond
+		return session
+	}
+	session := open()
+
+	prompt := "Implemente:\n```go\nfunc main() {\n\tfmt.Println(\"oi\")\n}\n```\nsem mudar o resto"
+	long := strings.Repeat("linha longa do prompt\n", 400) + "fim"
+	tests := []struct {
+		name   string
+		prompt string
+		turn   int
+	}{
+		{"primeira mensagem", prompt, 1},
+		{"mesma conversa na mensagem seguinte", prompt, 2},
+		{"prompt maior que o buffer de linha do terminal", long, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := session.Send(context.Background(), test.prompt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := fmt.Sprintf("turn=%d dir=%s\n> citação no meio da resposta", test.turn, filepath.Base(workingDir))
+			if response.Content != want {
+				t.Errorf("resposta = %q, esperado %q", response.Content, want)
+			}
+
+			received, err := os.ReadFile(filepath.Join(workingDir, fmt.Sprintf("received-%d", test.turn)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(received); got != "\x1b[200~"+test.prompt+"\x1b[201~\n" {
+				t.Errorf("prompt chegou alterado:\n%q", got)
+			}
+		})
+	}
+
+	extra := open()
+	response, err := extra.Send(context.Background(), "outra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(response.Content, "turn=1 ") {
+		t.Errorf("sessão extra deveria ter conversa própria, veio %q", response.Content)
+	}
+	extra.Close()
+
+	session.Close()
+	if session.Healthy() {
+		t.Error("sessão encerrada continua saudável")
+	}
+	if _, err := session.Send(context.Background(), "x"); err == nil {
+		t.Error("Send depois de Close deveria falhar")
+	}
+}
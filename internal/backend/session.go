@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Session é uma conversa de longa duração com o modelo, que preserva o contexto entre chamadas.
+type Session interface {
+	Send(ctx context.Context, prompt string) (*Response, error)
+	Healthy() bool
+	Close() error
+}
+
+// SessionBackend é implementado por backends capazes de manter uma conversa viva (ex: processo q chat).
+type SessionBackend interface {
+	OpenSession(ctx context.Context, agentID string) (Session, error)
+}
+
+// OpenSession abre uma sessão nativa quando o backend suporta, ou simula uma reenviando o histórico.
+func OpenSession(ctx context.Context, b Backend, agentID string) (Session, error) {
+	if sb, ok := b.(SessionBackend); ok {
+		return sb.OpenSession(ctx, agentID)
+	}
+	return NewHistorySession(b, agentID), nil
+}
+
+const maxHistoryTurns = 10
+
+type turn struct {
+	prompt   string
+	response string
+}
+
+// historySession mantém a conversa em memória e a envia junto de cada novo prompt.
+type historySession struct {
+	backend Backend
+	agentID string
+	turns   []turn
+	closed  bool
+	mutex   sync.Mutex
+}
+
+// NewHistorySession cria uma sessão que reenvia o histórico recente a cada chamada ao backend.
+func NewHistorySession(b Backend, agentID string) Session {
+	return &historySession{backend: b, agentID: agentID}
+}
+
+func (h *historySession) Send(ctx context.Context, prompt string) (*Response, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return nil, fmt.Errorf("sessão %s encerrada", h.agentID)
+	}
+
+	response, err := h.backend.Complete(ctx, Request{AgentID: h.agentID, Prompt: h.transcript(prompt)})
+	if err != nil {
+		return nil, err
+	}
+
+	h.turns = append(h.turns, turn{prompt: prompt, response: response.Content})
+	if len(h.turns) > maxHistoryTurns {
+		h.turns = h.turns[len(h.turns)-maxHistoryTurns:]
+	}
+	return response, nil
+}
+
+// transcript junta o prompt às conversas anteriores que cabem no orçamento do backend,
+// descartando as mais antigas primeiro; sem espaço para nenhuma, vai só o prompt.
+func (h *historySession) transcript(prompt string) string {
+	limit := PromptBudget(h.backend)
+	for turns := h.turns; len(turns) > 0; turns = turns[1:] {
+		if text := render(turns, prompt); EstimateTokens(text) <= limit {
+			return text
+		}
+	}
+	return prompt
+}
+
+func render(turns []turn, prompt string) string {
+	var b strings.Builder
+	b.WriteString("Conversa anterior:\n")
+	for _, t := range turns {
+		fmt.Fprintf(&b, "\n[usuário]\n%s\n\n[assistente]\n%s\n", t.prompt, t.response)
+	}
+	b.WriteString("\nNova mensagem:\n")
+	b.WriteString(prompt)
+	return b.String()
+}
+
+func (h *historySession) Healthy() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return !h.closed
+}
+
+func (h *historySession) Close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.closed = true
+	h.turns = nil
+	return nil
+}
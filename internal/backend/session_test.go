@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// echoBackend responde com um texto fixo e guarda os prompts recebidos.
+type echoBackend struct {
+	window   int
+	response string
+	prompts  []string
+}
+
+func (e *echoBackend) Name() string       { return "echo" }
+func (e *echoBackend) ContextWindow() int { return e.window }
+func (e *echoBackend) Cancel()            {}
+
+func (e *echoBackend) Complete(ctx context.Context, req Request) (*Response, error) {
+	e.prompts = append(e.prompts, req.Prompt)
+	return &Response{Content: e.response}, nil
+}
+
+func (e *echoBackend) Stream(ctx context.Context, req Request, onChunk func(string)) (*Response, error) {
+	return e.Complete(ctx, req)
+}
+
+func TestHistorySessionBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  int
+		prompts []string
+		// turnos anteriores esperados no último envio
+		want []string
+	}{
+		{
+			name:    "histórico cabe inteiro",
+			window:  4000,
+			prompts: []string{"primeiro", "segundo", "terceiro"},
+			want:    []string{"primeiro", "segundo"},
+		},
+		{
+			name:    "turnos antigos saem primeiro",
+			window:  300,
+			prompts: []string{strings.Repeat("a", 600), strings.Repeat("b", 300), "terceiro"},
+			want:    []string{strings.Repeat("b", 300)},
+		},
+		{
+			name:    "sem espaço vai só o prompt",
+			window:  200,
+			prompts: []string{strings.Repeat("a", 800), "segundo"},
+			want:    nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			echo := &echoBackend{window: test.window, response: "ok"}
+			session := NewHistorySession(echo, "auth")
+			for _, prompt := range test.prompts {
+				if _, err := session.Send(context.Background(), prompt); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sent := echo.prompts[len(echo.prompts)-1]
+			last := test.prompts[len(test.prompts)-1]
+			if len(test.want) == 0 && sent != last {
+				t.Fatalf("esperava só o prompt, veio:\n%s", sent)
+			}
+			if tokens := EstimateTokens(sent); len(test.want) > 0 && tokens > PromptBudget(echo) {
+				t.Errorf("transcrição com %d tokens passou do orçamento de %d", tokens, PromptBudget(echo))
+			}
+			for _, previous := range test.prompts[:len(test.prompts)-1] {
+				included := strings.Contains(sent, "[usuário]\n"+previous+"\n")
+				expected := false
+				for _, want := range test.want {
+					expected = expected || want == previous
+				}
+				if included != expected {
+					t.Errorf("turno %.10q incluído = %v, esperado %v", previous, included, expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package backend
+
+import "strings"
+
+// DefaultContextWindow é usado quando o backend não informa sua janela de contexto.
+const DefaultContextWindow = 8192
+
+// Windowed é implementado por backends que conhecem sua janela de contexto em tokens.
+type Windowed interface {
+	ContextWindow() int
+}
+
+// modelWindows lista janelas conhecidas por prefixo de modelo; o prefixo mais longo vence.
+var modelWindows = map[string]int{
+	"gpt-4o":        128000,
+	"gpt-4.1":       1000000,
+	"gpt-4-turbo":   128000,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"o1":            128000,
+	"o3":            200000,
+	"llama3":        8192,
+	"llama3.1":      128000,
+	"mistral":       32768,
+	"qwen2.5":       32768,
+}
+
+// ContextWindow devolve a janela de contexto de b, ou DefaultContextWindow.
+func ContextWindow(b Backend) int {
+	if windowed, ok := b.(Windowed); ok {
+		if window := windowed.ContextWindow(); window > 0 {
+			return window
+		}
+	}
+	return DefaultContextWindow
+}
+
+// PromptBudget é a parte da janela disponível para o prompt; o restante fica para a resposta.
+func PromptBudget(b Backend) int {
+	return ContextWindow(b) * 3 / 4
+}
+
+func (c Config) contextWindow() int {
+	if c.ContextTokens > 0 {
+		return c.ContextTokens
+	}
+
+	best := ""
+	for prefix := range modelWindows {
+		if strings.HasPrefix(c.Model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best != "" {
+		return modelWindows[best]
+	}
+
+	switch c.Type {
+	case "", TypeQ:
+		return 32000
+	case TypeOpenAI:
+		return 128000
+	default:
+		return DefaultContextWindow
+	}
+}
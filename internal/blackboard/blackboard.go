@@ -0,0 +1,196 @@
+package blackboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"plaxo-orchestra/internal/markdown"
+)
+
+type Kind string
+
+// Tipos de anotação, publicados pelos agentes com "@<tipo> chave: valor" numa linha da resposta.
+const (
+	Decision Kind = "decision"
+	Type     Kind = "type"
+	Question Kind = "question"
+	Fact     Kind = "fact"
+	// Answer responde a uma Question já publicada com a mesma chave
+	Answer Kind = "answer"
+)
+
+// A chave não pode começar com chave de abertura: "@type {{id: string}}" em JSDoc não é diretiva.
+var directive = regexp.MustCompile(`^[ \t>*-]*@(decision|type|question|fact|answer)[ \t]+([^{\s:][^:]*?)[ \t]*:[ \t]*(.+?)[ \t]*$`)
+
+type Entry struct {
+	Kind       Kind   `json:"kind"`
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	Author     string `json:"author"`
+	Answer     string `json:"answer,omitempty"`
+	AnsweredBy string `json:"answered_by,omitempty"`
+	// Conflicts são os valores diferentes que outros agentes publicaram antes, até o supervisor decidir
+	Conflicts []string  `json:"conflicts,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (e *Entry) String() string {
+	line := fmt.Sprintf("[%s] %s: %s (%s)", e.Kind, e.Key, e.Value, e.Author)
+	if e.Kind == Question {
+		if e.Answer == "" {
+			return line + " → ?"
+		}
+		line += fmt.Sprintf(" → %s (%s)", e.Answer, e.AnsweredBy)
+	}
+	if len(e.Conflicts) > 0 {
+		line += " ⚠️ diverge de: " + strings.Join(e.Conflicts, "; ")
+	}
+	return line
+}
+
+// Board é o quadro compartilhado de uma execução: a última publicação de cada tipo+chave vale.
+type Board struct {
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+func New() *Board {
+	return &Board{}
+}
+
+// Post publica ou substitui uma anotação; Answer preenche a pergunta correspondente.
+func (b *Board) Post(author string, kind Kind, key, value string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if kind == Answer {
+		if question := b.find(Question, key); question != nil {
+			question.Answer, question.AnsweredBy, question.UpdatedAt = value, author, now
+			return
+		}
+		// Resposta sem pergunta registrada fica como fato
+		kind = Fact
+	}
+
+	if entry := b.find(kind, key); entry != nil {
+		// Decisões e tipos divergentes entre agentes ficam registrados para o supervisor resolver
+		if (kind == Decision || kind == Type) && entry.Author != author && entry.Value != value {
+			entry.Conflicts = append(entry.Conflicts, fmt.Sprintf("%s (%s)", entry.Value, entry.Author))
+		}
+		entry.Value, entry.Author, entry.UpdatedAt = value, author, now
+		entry.Answer, entry.AnsweredBy = "", ""
+		return
+	}
+	b.entries = append(b.entries, &Entry{Kind: kind, Key: key, Value: value, Author: author, UpdatedAt: now})
+}
+
+// Settle fixa o valor final de uma decisão ou tipo e encerra os conflitos registrados nela.
+func (b *Board) Settle(author string, kind Kind, key, value string) {
+	b.Post(author, kind, key, value)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if entry := b.find(kind, key); entry != nil {
+		entry.Conflicts = nil
+	}
+}
+
+// Conflicts devolve as anotações com valores divergentes ainda não resolvidos.
+func (b *Board) Conflicts() []Entry {
+	var conflicts []Entry
+	for _, entry := range b.Entries() {
+		if len(entry.Conflicts) > 0 {
+			conflicts = append(conflicts, entry)
+		}
+	}
+	return conflicts
+}
+
+// Record publica as diretivas encontradas na resposta de um agente e devolve quantas eram.
+func (b *Board) Record(author, output string) int {
+	matches := directives(output)
+	for _, match := range matches {
+		b.Post(author, Kind(match[1]), match[2], match[3])
+	}
+	return len(matches)
+}
+
+// directives acha as linhas de diretiva fora dos blocos de código cercados por ``` ou ~~~.
+func directives(output string) [][]string {
+	var matches [][]string
+	for _, line := range markdown.OutsideFences(output) {
+		if match := directive.FindStringSubmatch(line); match != nil {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+func (b *Board) find(kind Kind, key string) *Entry {
+	for _, entry := range b.entries {
+		if entry.Kind == kind && strings.EqualFold(entry.Key, key) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Entries devolve uma cópia das anotações, na ordem em que foram publicadas.
+func (b *Board) Entries() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := make([]Entry, len(b.entries))
+	for i, entry := range b.entries {
+		entries[i] = *entry
+	}
+	return entries
+}
+
+// Summary lista as anotações agrupadas por tipo, uma por linha, para entrar nos prompts.
+func (b *Board) Summary() string {
+	entries := b.Entries()
+	var lines []string
+	for _, kind := range []Kind{Decision, Type, Question, Fact} {
+		for i := range entries {
+			if entries[i].Kind == kind {
+				lines = append(lines, entries[i].String())
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *Board) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.Entries())
+}
+
+func (b *Board) UnmarshalJSON(data []byte) error {
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = entries
+	return nil
+}
+
+type contextKey struct{}
+
+// With anexa o quadro ao contexto das etapas de uma execução.
+func With(ctx context.Context, board *Board) context.Context {
+	return context.WithValue(ctx, contextKey{}, board)
+}
+
+// From devolve o quadro da execução em andamento, ou nil fora de uma execução.
+func From(ctx context.Context) *Board {
+	board, _ := ctx.Value(contextKey{}).(*Board)
+	return board
+}
@@ -0,0 +1,89 @@
+package blackboard
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"linha simples", "@decision db: postgres", []string{"decision|db|postgres"}},
+		{"marcadores de lista e citação", "- @fact moeda: BRL\n> @type UserID: uuid\n * @question limite: qual?", []string{"fact|moeda|BRL", "type|UserID|uuid", "question|limite|qual?"}},
+		{"no meio da frase não vale", "Decidi usar @decision db: postgres", nil},
+		{"bloco de código ignorado", "```ts\n/**\n * @type {{id: string}}\n * @decision db: mysql\n */\n```\n@decision db: postgres", []string{"decision|db|postgres"}},
+		{"cerca com til", "~~~\n@fact x: 1\n~~~\n@fact y: 2", []string{"fact|y|2"}},
+		{"chave com chaves não vale", "@type {id: string}: User", nil},
+		{"sem valor", "@fact chave:", nil},
+		{"CRLF", "@fact moeda: BRL\r\n@fact fuso: UTC\r\n", []string{"fact|moeda|BRL", "fact|fuso|UTC"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			board := New()
+			if count := board.Record("auth", test.output); count != len(test.want) {
+				t.Errorf("%d diretivas, esperado %d", count, len(test.want))
+			}
+			var got []string
+			for _, entry := range board.Entries() {
+				got = append(got, string(entry.Kind)+"|"+entry.Key+"|"+entry.Value)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("anotações = %q, esperado %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPost(t *testing.T) {
+	board := New()
+	board.Post("auth", Question, "moeda", "qual moeda?")
+	board.Post("products", Answer, "Moeda", "BRL")
+	board.Post("auth", Answer, "fuso", "UTC")
+	board.Post("auth", Decision, "db", "postgres")
+	board.Post("products", Decision, "db", "mysql")
+	board.Post("products", Decision, "db", "mysql")
+
+	entries := board.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("anotações = %v", entries)
+	}
+	if entries[0].Answer != "BRL" || entries[0].AnsweredBy != "products" {
+		t.Errorf("pergunta não respondida: %+v", entries[0])
+	}
+	if entries[1].Kind != Fact {
+		t.Errorf("resposta sem pergunta deveria virar fato: %+v", entries[1])
+	}
+	if conflicts := board.Conflicts(); len(conflicts) != 1 || strings.Join(conflicts[0].Conflicts, ";") != "postgres (auth)" {
+		t.Fatalf("conflitos = %+v", conflicts)
+	}
+
+	board.Settle("orchestra_agents", Decision, "db", "postgres")
+	if conflicts := board.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("Settle deveria encerrar os conflitos: %+v", conflicts)
+	}
+	if want := "[decision] db: postgres (orchestra_agents)"; !strings.Contains(board.Summary(), want) {
+		t.Errorf("resumo sem %q:\n%s", want, board.Summary())
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	board := New()
+	board.Record("auth", "@decision db: postgres\n@question moeda: qual?")
+
+	data, err := json.Marshal(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := New()
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Summary() != board.Summary() {
+		t.Errorf("restaurado:\n%s\nesperado:\n%s", restored.Summary(), board.Summary())
+	}
+}
@@ -0,0 +1,179 @@
+package budget
+
+import (
+	"fmt"
+	"plaxo-orchestra/internal/backend"
+	"sort"
+	"strings"
+)
+
+// minUsefulTokens é o menor espaço que vale a pena dar a uma seção opcional.
+const minUsefulTokens = 24
+
+// Section é um bloco candidato a entrar no prompt. Seções Required nunca são removidas,
+// apenas reduzidas; as demais entram por ordem de Priority (maior primeiro).
+type Section struct {
+	Name     string
+	Content  string
+	Priority int
+	Required bool
+}
+
+type Change struct {
+	Section  string
+	Action   string // "removida", "resumida" ou "truncada"
+	Original int
+	Kept     int
+}
+
+type Report struct {
+	Budget  int
+	Used    int
+	Changes []Change
+}
+
+func (r *Report) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+func (r *Report) String() string {
+	var parts []string
+	for _, c := range r.Changes {
+		if c.Action == "removida" {
+			parts = append(parts, fmt.Sprintf("%s %s (%d tokens)", c.Section, c.Action, c.Original))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %s (%d→%d tokens)", c.Section, c.Action, c.Original, c.Kept))
+		}
+	}
+	return fmt.Sprintf("%d/%d tokens; %s", r.Used, r.Budget, strings.Join(parts, ", "))
+}
+
+// Builder monta o contexto de um prompt respeitando um orçamento de tokens.
+type Builder struct {
+	budget   int
+	sections []Section
+}
+
+func NewBuilder(budget int) *Builder {
+	return &Builder{budget: budget}
+}
+
+func (b *Builder) Add(section Section) *Builder {
+	b.sections = append(b.sections, section)
+	return b
+}
+
+// Build devolve o conteúdo ajustado de cada seção (ausente se removida) e o relatório do que mudou.
+func (b *Builder) Build() (map[string]string, *Report) {
+	fitted := make(map[string]string)
+	report := &Report{Budget: b.budget}
+
+	order := make([]Section, len(b.sections))
+	copy(order, b.sections)
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].Required != order[j].Required {
+			return order[i].Required
+		}
+		return order[i].Priority > order[j].Priority
+	})
+
+	remaining := b.budget
+	for i, section := range order {
+		tokens := backend.EstimateTokens(section.Content)
+
+		// Seções obrigatórias ainda por vir reservam uma fatia mínima do orçamento
+		allowance := remaining - b.reserved(order[i+1:])
+		if section.Required {
+			allowance = max(allowance, remaining/(1+b.countRequired(order[i+1:])))
+		}
+
+		switch {
+		case tokens <= allowance:
+			fitted[section.Name] = section.Content
+			remaining -= tokens
+		case !section.Required && allowance < minUsefulTokens:
+			report.Changes = append(report.Changes, Change{Section: section.Name, Action: "removida", Original: tokens})
+		default:
+			content, action := shrink(section.Content, allowance)
+			kept := backend.EstimateTokens(content)
+			fitted[section.Name] = content
+			remaining -= kept
+			report.Changes = append(report.Changes, Change{Section: section.Name, Action: action, Original: tokens, Kept: kept})
+		}
+	}
+
+	report.Used = b.budget - remaining
+	return fitted, report
+}
+
+func (b *Builder) reserved(rest []Section) int {
+	total := 0
+	for _, s := range rest {
+		if s.Required {
+			total += min(backend.EstimateTokens(s.Content), minUsefulTokens)
+		}
+	}
+	return total
+}
+
+func (b *Builder) countRequired(rest []Section) int {
+	count := 0
+	for _, s := range rest {
+		if s.Required {
+			count++
+		}
+	}
+	return count
+}
+
+// shrink tenta primeiro um resumo extrativo; se ainda não couber, trunca mantendo início e fim.
+func shrink(content string, maxTokens int) (string, string) {
+	if maxTokens <= 0 {
+		return "", "truncada"
+	}
+
+	if summary := summarize(content); summary != content && backend.EstimateTokens(summary) <= maxTokens {
+		return summary, "resumida"
+	}
+	return truncate(content, maxTokens), "truncada"
+}
+
+// summarize mantém títulos, marcadores e a primeira linha de cada parágrafo.
+func summarize(content string) string {
+	var kept []string
+	newParagraph := true
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			newParagraph = true
+			continue
+		}
+
+		structural := strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "===") ||
+			strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasSuffix(trimmed, ":")
+		if newParagraph || structural {
+			kept = append(kept, line)
+		}
+		newParagraph = false
+	}
+	return strings.Join(kept, "\n")
+}
+
+func truncate(content string, maxTokens int) string {
+	runes := []rune(content)
+	limit := maxTokens * 4
+	if len(runes) <= limit {
+		return content
+	}
+
+	marker := fmt.Sprintf("\n[... %d tokens omitidos ...]\n", backend.EstimateTokens(string(runes[limit:])))
+	limit -= len([]rune(marker))
+	if limit <= 0 {
+		return strings.TrimSpace(marker)
+	}
+
+	head := limit * 7 / 10
+	tail := limit - head
+	return string(runes[:head]) + marker + string(runes[len(runes)-tail:])
+}
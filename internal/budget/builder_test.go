@@ -0,0 +1,138 @@
+package budget
+
+import (
+	"strings"
+	"testing"
+
+	"plaxo-orchestra/internal/backend"
+)
+
+func TestBuild(t *testing.T) {
+	// Cada parágrafo tem uma linha curta seguida de texto longo, que o resumo descarta
+	paragraphs := "# Título\n\nresumo um\n" + strings.Repeat("detalhe ", 40) + "\n\nresumo dois\n" + strings.Repeat("detalhe ", 40)
+	long := strings.Repeat("abcd", 100)
+
+	tests := []struct {
+		name     string
+		budget   int
+		sections []Section
+		// want mapeia cada seção à ação esperada: "" se mantida inteira
+		want map[string]string
+	}{
+		{
+			name:   "tudo cabe",
+			budget: 100,
+			sections: []Section{
+				{Name: "a", Content: "curto"},
+				{Name: "b", Content: "também curto", Required: true},
+			},
+			want: map[string]string{"a": "", "b": ""},
+		},
+		{
+			name:   "maior prioridade entra primeiro",
+			budget: 60,
+			sections: []Section{
+				{Name: "baixa", Content: strings.Repeat("x", 200), Priority: 1},
+				{Name: "alta", Content: strings.Repeat("y", 200), Priority: 5},
+			},
+			want: map[string]string{"alta": "", "baixa": "removida"},
+		},
+		{
+			name:   "obrigatória é reduzida, nunca removida",
+			budget: 30,
+			sections: []Section{
+				{Name: "opcional", Content: long, Priority: 10},
+				{Name: "tarefa", Content: long, Required: true},
+			},
+			want: map[string]string{"tarefa": "truncada", "opcional": "removida"},
+		},
+		{
+			name:   "resume antes de truncar",
+			budget: 40,
+			sections: []Section{
+				{Name: "docs", Content: paragraphs, Required: true},
+			},
+			want: map[string]string{"docs": "resumida"},
+		},
+		{
+			name:   "trunca se o resumo não couber",
+			budget: 40,
+			sections: []Section{
+				{Name: "bruto", Content: long, Required: true},
+			},
+			want: map[string]string{"bruto": "truncada"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := NewBuilder(test.budget)
+			for _, section := range test.sections {
+				builder.Add(section)
+			}
+			fitted, report := builder.Build()
+
+			actions := make(map[string]string)
+			for _, change := range report.Changes {
+				actions[change.Section] = change.Action
+			}
+
+			for _, section := range test.sections {
+				want := test.want[section.Name]
+				if actions[section.Name] != want {
+					t.Errorf("%s: ação = %q, esperado %q", section.Name, actions[section.Name], want)
+				}
+
+				content, ok := fitted[section.Name]
+				switch want {
+				case "":
+					if content != section.Content {
+						t.Errorf("%s: conteúdo alterado", section.Name)
+					}
+				case "removida":
+					if ok {
+						t.Errorf("%s: seção removida continua no resultado", section.Name)
+					}
+				default:
+					if content == "" || backend.EstimateTokens(content) > test.budget {
+						t.Errorf("%s: conteúdo reduzido inválido (%d tokens)", section.Name, backend.EstimateTokens(content))
+					}
+				}
+			}
+
+			if report.Used > report.Budget {
+				t.Errorf("usado %d além do orçamento %d", report.Used, report.Budget)
+			}
+			if report.Changed() != (len(report.Changes) > 0) {
+				t.Errorf("Changed inconsistente com Changes")
+			}
+		})
+	}
+}
+
+func TestBuildSummaryAndTruncation(t *testing.T) {
+	paragraphs := "# Título\n\nresumo um\n" + strings.Repeat("detalhe ", 40) + "\n\nresumo dois\n" + strings.Repeat("detalhe ", 40)
+
+	fitted, _ := NewBuilder(40).Add(Section{Name: "docs", Content: paragraphs, Required: true}).Build()
+	if fitted["docs"] != "# Título\nresumo um\nresumo dois" {
+		t.Errorf("resumo = %q", fitted["docs"])
+	}
+
+	fitted, _ = NewBuilder(40).Add(Section{Name: "bruto", Content: "início" + strings.Repeat("-", 400) + "fim", Required: true}).Build()
+	content := fitted["bruto"]
+	if !strings.HasPrefix(content, "início") || !strings.HasSuffix(content, "fim") || !strings.Contains(content, "tokens omitidos") {
+		t.Errorf("truncamento = %q", content)
+	}
+}
+
+func TestReportString(t *testing.T) {
+	report := &Report{Budget: 100, Used: 90, Changes: []Change{
+		{Section: "memória", Action: "removida", Original: 50},
+		{Section: "tarefa", Action: "truncada", Original: 200, Kept: 80},
+	}}
+
+	want := "90/100 tokens; memória removida (50 tokens), tarefa truncada (200→80 tokens)"
+	if got := report.String(); got != want {
+		t.Errorf("String() = %q, esperado %q", got, want)
+	}
+}
@@ -0,0 +1,214 @@
+package consult
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"plaxo-orchestra/internal/markdown"
+)
+
+// Um agente consulta outro domínio com uma linha "@ask <domínio>: <pergunta>" na resposta.
+var directive = regexp.MustCompile(`^[ \t>*-]*@ask[ \t]+([^:\n]+?)[ \t]*:[ \t]*(.+?)[ \t]*$`)
+
+type Question struct {
+	To   string
+	Text string
+}
+
+// Parse devolve as consultas feitas numa resposta, na ordem em que aparecem; exemplos em blocos de código não contam.
+func Parse(output string) []Question {
+	var questions []Question
+	for _, line := range markdown.OutsideFences(output) {
+		if match := directive.FindStringSubmatch(line); match != nil {
+			questions = append(questions, Question{To: match[1], Text: match[2]})
+		}
+	}
+	return questions
+}
+
+// Exchange é uma consulta respondida (ou recusada, com Error) entre dois agentes.
+type Exchange struct {
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Question string    `json:"question"`
+	Answer   string    `json:"answer,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Depth    int       `json:"depth"`
+	At       time.Time `json:"at"`
+}
+
+func (e *Exchange) Answered() bool {
+	return e.Error == ""
+}
+
+// Trace guarda as consultas de uma execução, gravadas junto com ela.
+type Trace struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+func NewTrace() *Trace {
+	return &Trace{}
+}
+
+func (t *Trace) Add(exchange Exchange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exchanges = append(t.exchanges, exchange)
+}
+
+func (t *Trace) Exchanges() []Exchange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Exchange(nil), t.exchanges...)
+}
+
+func (t *Trace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Exchanges())
+}
+
+func (t *Trace) UnmarshalJSON(data []byte) error {
+	var exchanges []Exchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exchanges = exchanges
+	return nil
+}
+
+// AskFunc executa a pergunta de from no agente do domínio to.
+type AskFunc func(ctx context.Context, from, to, question string) (string, error)
+
+// Router encaminha as consultas entre os agentes de uma execução, com limite de profundidade e de quantidade.
+type Router struct {
+	domains  []string
+	maxDepth int
+	maxCount int
+	ask      AskFunc
+	trace    *Trace
+
+	mu    sync.Mutex
+	count int
+}
+
+func NewRouter(domains []string, maxDepth, maxCount int, trace *Trace, ask AskFunc) *Router {
+	return &Router{domains: domains, maxDepth: maxDepth, maxCount: maxCount, ask: ask, trace: trace}
+}
+
+// Peers devolve os domínios que from ainda pode consultar; vazio quando os limites já foram atingidos.
+func (r *Router) Peers(ctx context.Context, from string) []string {
+	r.mu.Lock()
+	exhausted := r.count >= r.maxCount
+	r.mu.Unlock()
+	if exhausted || Depth(ctx) >= r.maxDepth {
+		return nil
+	}
+
+	var peers []string
+	for _, domain := range r.domains {
+		if domain != from {
+			peers = append(peers, domain)
+		}
+	}
+	return peers
+}
+
+// Ask responde as consultas de from; as que passam dos limites voltam com Error em vez de resposta.
+func (r *Router) Ask(ctx context.Context, from string, questions []Question) []Exchange {
+	depth := Depth(ctx)
+
+	var exchanges []Exchange
+	for _, question := range questions {
+		exchange := Exchange{From: from, To: question.To, Question: question.Text, Depth: depth + 1, At: time.Now()}
+		switch {
+		case depth >= r.maxDepth:
+			exchange.Error = fmt.Sprintf("profundidade máxima de consulta (%d) atingida", r.maxDepth)
+		case question.To == from:
+			exchange.Error = "um agente não consulta o próprio domínio"
+		case !r.known(question.To):
+			exchange.Error = fmt.Sprintf("domínio desconhecido; disponíveis: %s", strings.Join(r.domains, ", "))
+		case !r.reserve():
+			exchange.Error = fmt.Sprintf("limite de %d consultas da execução atingido", r.maxCount)
+		default:
+			fmt.Printf("💬 %s → %s: %s\n", from, question.To, question.Text)
+			answer, err := r.ask(withDepth(ctx, depth+1), from, question.To, question.Text)
+			if err != nil {
+				exchange.Error = err.Error()
+			} else {
+				exchange.Answer = answer
+				fmt.Printf("↩️  %s → %s: %s\n", question.To, from, firstLine(answer))
+			}
+		}
+
+		if exchange.Error != "" {
+			fmt.Printf("🚫 Consulta de %s a %s sem resposta: %s\n", from, question.To, exchange.Error)
+		}
+		if r.trace != nil {
+			r.trace.Add(exchange)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	return exchanges
+}
+
+func (r *Router) known(domain string) bool {
+	for _, known := range r.domains {
+		if known == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router) reserve() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count >= r.maxCount {
+		return false
+	}
+	r.count++
+	return true
+}
+
+func firstLine(s string) string {
+	line := strings.TrimSpace(s)
+	if i := strings.Index(line, "\n"); i != -1 {
+		line = line[:i] + " ..."
+	}
+	if runes := []rune(line); len(runes) > 100 {
+		line = string(runes[:97]) + "..."
+	}
+	return line
+}
+
+type routerKey struct{}
+
+type depthKey struct{}
+
+// With anexa o roteador ao contexto das etapas de uma execução.
+func With(ctx context.Context, router *Router) context.Context {
+	return context.WithValue(ctx, routerKey{}, router)
+}
+
+// From devolve o roteador da execução em andamento, ou nil fora de uma execução.
+func From(ctx context.Context) *Router {
+	router, _ := ctx.Value(routerKey{}).(*Router)
+	return router
+}
+
+// Depth é quantas consultas aninhadas levaram até este contexto.
+func Depth(ctx context.Context) int {
+	depth, _ := ctx.Value(depthKey{}).(int)
+	return depth
+}
+
+func withDepth(ctx context.Context, depth int) context.Context {
+	return context.WithValue(ctx, depthKey{}, depth)
+}
@@ -0,0 +1,119 @@
+package consult
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"uma pergunta", "@ask auth: qual o tipo do user ID?", []string{"auth|qual o tipo do user ID?"}},
+		{"em lista", "Antes:\n- @ask user/profile: tem avatar?\n- @ask auth: expira?", []string{"user/profile|tem avatar?", "auth|expira?"}},
+		{"no meio da frase não vale", "posso usar @ask auth: x", nil},
+		{"sem pergunta", "@ask auth:", nil},
+		{"em bloco de código não vale", "Exemplo:\n```\n@ask auth: expira?\n```\n~~~\n@ask user: x\n~~~\n@ask billing: moeda?", []string{"billing|moeda?"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, question := range Parse(test.output) {
+				got = append(got, question.To+"|"+question.Text)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("perguntas = %q, esperado %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRouterAsk(t *testing.T) {
+	tests := []struct {
+		name      string
+		depth     int
+		questions []Question
+		// errors tem, por consulta, um trecho do erro esperado ou "" para respondida
+		errors []string
+	}{
+		{"responde", 0, []Question{{To: "auth", Text: "x?"}}, []string{""}},
+		{"próprio domínio", 0, []Question{{To: "user", Text: "x?"}}, []string{"próprio domínio"}},
+		{"domínio desconhecido", 0, []Question{{To: "billing", Text: "x?"}}, []string{"domínio desconhecido"}},
+		{"profundidade máxima", 2, []Question{{To: "auth", Text: "x?"}}, []string{"profundidade máxima"}},
+		{"limite de consultas", 0, []Question{{To: "auth", Text: "1?"}, {To: "auth", Text: "2?"}, {To: "auth", Text: "3?"}}, []string{"", "", "limite de 2 consultas"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trace := NewTrace()
+			var depths []int
+			router := NewRouter([]string{"auth", "user"}, 2, 2, trace, func(ctx context.Context, from, to, question string) (string, error) {
+				depths = append(depths, Depth(ctx))
+				return to + " responde " + question, nil
+			})
+
+			ctx := context.Background()
+			for i := 0; i < test.depth; i++ {
+				ctx = withDepth(ctx, i+1)
+			}
+			exchanges := router.Ask(ctx, "user", test.questions)
+			for i, exchange := range exchanges {
+				if test.errors[i] == "" {
+					if !exchange.Answered() || exchange.Answer != "auth responde "+test.questions[i].Text {
+						t.Errorf("consulta %d: %+v", i, exchange)
+					}
+					continue
+				}
+				if exchange.Answered() || !strings.Contains(exchange.Error, test.errors[i]) {
+					t.Errorf("consulta %d: erro %q, esperado %q", i, exchange.Error, test.errors[i])
+				}
+			}
+			for _, depth := range depths {
+				if depth != test.depth+1 {
+					t.Errorf("profundidade repassada = %d, esperado %d", depth, test.depth+1)
+				}
+			}
+			if len(trace.Exchanges()) != len(test.questions) {
+				t.Errorf("trace com %d trocas", len(trace.Exchanges()))
+			}
+		})
+	}
+}
+
+func TestPeers(t *testing.T) {
+	router := NewRouter([]string{"auth", "user"}, 1, 1, nil, func(ctx context.Context, from, to, question string) (string, error) {
+		return "ok", nil
+	})
+	if peers := router.Peers(context.Background(), "user"); strings.Join(peers, ",") != "auth" {
+		t.Errorf("Peers = %v", peers)
+	}
+	if peers := router.Peers(withDepth(context.Background(), 1), "user"); peers != nil {
+		t.Errorf("na profundidade máxima Peers = %v", peers)
+	}
+	router.Ask(context.Background(), "user", []Question{{To: "auth", Text: "x?"}})
+	if peers := router.Peers(context.Background(), "user"); peers != nil {
+		t.Errorf("com o limite atingido Peers = %v", peers)
+	}
+}
+
+func TestTraceJSON(t *testing.T) {
+	trace := NewTrace()
+	trace.Add(Exchange{From: "user", To: "auth", Question: "x?", Answer: "y", Depth: 1})
+
+	data, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewTrace()
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if exchanges := restored.Exchanges(); len(exchanges) != 1 || exchanges[0].Answer != "y" {
+		t.Errorf("restaurado = %+v", exchanges)
+	}
+}
@@ -0,0 +1,251 @@
+package contracts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Item é uma operação, evento ou tipo de dado; em requires, From indica o domínio que deve fornecê-lo.
+type Item struct {
+	Name        string `yaml:"name" json:"name" desc:"nome da operação, evento ou tipo (ex: ValidateToken, OrderCreated, Product)"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty" desc:"assinatura, campos ou payload esperados"`
+	From        string `yaml:"from,omitempty" json:"from,omitempty" desc:"só em requires: domínio que deve fornecer; vazio se não souber"`
+}
+
+type Surface struct {
+	Operations []Item `yaml:"operations,omitempty" json:"operations" desc:"funções, endpoints ou métodos"`
+	Events     []Item `yaml:"events,omitempty" json:"events" desc:"eventos publicados ou consumidos"`
+	Types      []Item `yaml:"types,omitempty" json:"types" desc:"estruturas de dados trocadas entre domínios"`
+}
+
+// Contract é o que um domínio fornece e exige dos outros, gravado em .plaxo/contracts/<domínio>.yaml.
+type Contract struct {
+	Domain   string  `yaml:"domain" json:"-"`
+	Provides Surface `yaml:"provides" json:"provides" desc:"o que este domínio oferece aos outros"`
+	Requires Surface `yaml:"requires" json:"requires" desc:"o que este domínio precisa dos outros"`
+}
+
+// Kinds são as categorias de item, na ordem em que aparecem.
+var Kinds = []string{"operations", "events", "types"}
+
+func (s Surface) Items(kind string) []Item {
+	switch kind {
+	case "operations":
+		return s.Operations
+	case "events":
+		return s.Events
+	case "types":
+		return s.Types
+	}
+	return nil
+}
+
+func (s Surface) Empty() bool {
+	return len(s.Operations)+len(s.Events)+len(s.Types) == 0
+}
+
+// Provides indica se a superfície tem um item do tipo com esse nome (sem diferenciar maiúsculas).
+func (s Surface) Provides(kind, name string) bool {
+	for _, item := range s.Items(kind) {
+		if strings.EqualFold(strings.TrimSpace(item.Name), strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge junta a outra superfície: itens novos entram no fim e os de mesmo nome são atualizados.
+func (s *Surface) Merge(other Surface) {
+	s.Operations = mergeItems(s.Operations, other.Operations)
+	s.Events = mergeItems(s.Events, other.Events)
+	s.Types = mergeItems(s.Types, other.Types)
+}
+
+func mergeItems(items, others []Item) []Item {
+	for _, other := range others {
+		found := false
+		for i := range items {
+			if strings.EqualFold(strings.TrimSpace(items[i].Name), strings.TrimSpace(other.Name)) {
+				if other.Description == "" {
+					other.Description = items[i].Description
+				}
+				items[i] = other
+				found = true
+				break
+			}
+		}
+		if !found {
+			items = append(items, other)
+		}
+	}
+	return items
+}
+
+// Merge incorpora um contrato extraído de novo sem perder o que já estava registrado (inclusive edições manuais).
+func (c *Contract) Merge(other *Contract) {
+	c.Provides.Merge(other.Provides)
+	c.Requires.Merge(other.Requires)
+}
+
+func (c *Contract) YAML() string {
+	data, _ := yaml.Marshal(c)
+	return string(data)
+}
+
+func Dir(root string) string {
+	return filepath.Join(root, ".plaxo", "contracts")
+}
+
+// Path aceita bounded contexts: user/profile vira .plaxo/contracts/user/profile.yaml.
+func Path(root, domain string) string {
+	return filepath.Join(Dir(root), filepath.FromSlash(domain)+".yaml")
+}
+
+func Load(root, domain string) (*Contract, error) {
+	data, err := os.ReadFile(Path(root, domain))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(domain, string(data))
+}
+
+// Parse lê o YAML de um contrato; o domínio vem de quem chama, não do conteúdo.
+func Parse(domain, data string) (*Contract, error) {
+	var contract Contract
+	if err := yaml.Unmarshal([]byte(data), &contract); err != nil {
+		return nil, fmt.Errorf("contrato inválido de %s: %v", domain, err)
+	}
+	contract.Domain = domain
+	return &contract, nil
+}
+
+// LoadAll lê todos os contratos registrados, indexados por domínio.
+func LoadAll(root string) (map[string]*Contract, error) {
+	contracts := make(map[string]*Contract)
+	err := filepath.Walk(Dir(root), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".yaml") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(Dir(root), path)
+		if err != nil {
+			return err
+		}
+		domain := filepath.ToSlash(strings.TrimSuffix(rel, ".yaml"))
+		contract, err := Load(root, domain)
+		if err != nil {
+			return err
+		}
+		contracts[domain] = contract
+		return nil
+	})
+	return contracts, err
+}
+
+func Save(root string, contract *Contract) error {
+	path := Path(root, contract.Domain)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(contract.YAML()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Problem é um requisito de um domínio que nenhum contrato fornece.
+type Problem struct {
+	Domain string
+	Kind   string
+	Name   string
+	From   string
+	Reason string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s requer %s %q: %s", p.Domain, p.Kind, p.Name, p.Reason)
+}
+
+// Check cruza os contratos e devolve os requisitos sem fornecedor, ordenados por domínio.
+func Check(contracts map[string]*Contract) []Problem {
+	var problems []Problem
+	for _, domain := range sortedDomains(contracts) {
+		contract := contracts[domain]
+		for _, kind := range Kinds {
+			for _, item := range contract.Requires.Items(kind) {
+				problem := Problem{Domain: domain, Kind: kind, Name: item.Name, From: item.From}
+				switch providers := Providers(contracts, domain, kind, item); {
+				case len(providers) > 0:
+					continue
+				case item.From != "" && contracts[item.From] == nil:
+					problem.Reason = fmt.Sprintf("%s não tem contrato registrado", item.From)
+				case item.From != "":
+					problem.Reason = fmt.Sprintf("%s não fornece", item.From)
+				default:
+					problem.Reason = "nenhum domínio fornece"
+				}
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems
+}
+
+// Providers devolve os outros domínios que fornecem o item exigido por domain.
+func Providers(contracts map[string]*Contract, domain, kind string, item Item) []string {
+	var providers []string
+	for _, other := range sortedDomains(contracts) {
+		if other == domain || (item.From != "" && other != item.From) {
+			continue
+		}
+		if contracts[other].Provides.Provides(kind, item.Name) {
+			providers = append(providers, other)
+		}
+	}
+	return providers
+}
+
+// Dependencies devolve os domínios dos quais domain consome algum item, ordenados.
+func Dependencies(contracts map[string]*Contract, domain string) []string {
+	contract := contracts[domain]
+	if contract == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, kind := range Kinds {
+		for _, item := range contract.Requires.Items(kind) {
+			for _, provider := range Providers(contracts, domain, kind, item) {
+				seen[provider] = true
+			}
+		}
+	}
+
+	var domains []string
+	for other := range seen {
+		domains = append(domains, other)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+func sortedDomains(contracts map[string]*Contract) []string {
+	domains := make([]string, 0, len(contracts))
+	for domain := range contracts {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
@@ -0,0 +1,81 @@
+package contracts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	existing := &Contract{Domain: "auth", Provides: Surface{
+		Operations: []Item{{Name: "Login", Description: "editado à mão"}, {Name: "Logout"}},
+	}}
+	extracted := &Contract{Domain: "auth",
+		Provides: Surface{Operations: []Item{{Name: "login"}, {Name: "ValidateToken", Description: "token -> user"}}},
+		Requires: Surface{Types: []Item{{Name: "User", From: "user"}}},
+	}
+
+	existing.Merge(extracted)
+
+	var names []string
+	for _, item := range existing.Provides.Operations {
+		names = append(names, item.Name+"="+item.Description)
+	}
+	if got, want := strings.Join(names, ","), "login=editado à mão,Logout=,ValidateToken=token -> user"; got != want {
+		t.Errorf("operações = %s, esperado %s", got, want)
+	}
+	if !existing.Requires.Provides("types", "user") {
+		t.Error("requisito novo não entrou")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	registry := map[string]*Contract{
+		"auth": {Domain: "auth",
+			Provides: Surface{Operations: []Item{{Name: "ValidateToken"}}},
+			Requires: Surface{Types: []Item{{Name: "User", From: "user"}}},
+		},
+		"products": {Domain: "products", Requires: Surface{
+			Operations: []Item{{Name: "validatetoken"}, {Name: "Charge", From: "billing"}},
+			Events:     []Item{{Name: "OrderCreated"}},
+		}},
+	}
+
+	var got []string
+	for _, problem := range Check(registry) {
+		got = append(got, problem.String())
+	}
+	want := []string{
+		`auth requer types "User": user não tem contrato registrado`,
+		`products requer operations "Charge": billing não tem contrato registrado`,
+		`products requer events "OrderCreated": nenhum domínio fornece`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problemas:\n%s\nesperado:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if deps := Dependencies(registry, "products"); strings.Join(deps, ",") != "auth" {
+		t.Errorf("Dependencies = %v", deps)
+	}
+}
+
+func TestSaveLoadAll(t *testing.T) {
+	root := t.TempDir()
+	for _, contract := range []*Contract{
+		{Domain: "auth", Provides: Surface{Operations: []Item{{Name: "Login"}}}},
+		{Domain: "user/profile", Requires: Surface{Operations: []Item{{Name: "Login", From: "auth"}}}},
+	} {
+		if err := Save(root, contract); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry, err := LoadAll(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registry) != 2 || registry["user/profile"] == nil || len(Check(registry)) != 0 {
+		t.Errorf("registro = %v", registry)
+	}
+	if _, err := Parse("auth", "provides: [nao"); err == nil {
+		t.Error("YAML inválido deveria falhar")
+	}
+}
@@ -1,11 +1,13 @@
 package detector
 
 import (
-	"fmt"
+	"context"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/prompts"
 	"strings"
+	"time"
 )
 
 type ProjectType int
@@ -48,60 +50,13 @@ func DetectProject(workingDir string) *ProjectInfo {
 }
 
 func isMultiAgentProject(dir string) bool {
-	// Procura por estrutura de domínios com agentes
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return false
-	}
-	
-	for _, entry := range entries {
-		if entry.IsDir() {
-			agentPath := filepath.Join(dir, entry.Name(), "agents", "instructions.txt")
-			if _, err := os.Stat(agentPath); err == nil {
-				return true
-			}
-		}
-	}
-	
-	return false
+	// Procura por domínios com manifesto de agente (agents/agent.yaml ou o antigo instructions.txt)
+	return len(findDomains(dir)) > 0
 }
 
 func findDomains(dir string) []string {
-	var domains []string
-	
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return domains
-	}
-	
-	for _, entry := range entries {
-		if entry.IsDir() {
-			// Verifica se tem agentes no nível do domínio
-			agentPath := filepath.Join(dir, entry.Name(), "agents", "instructions.txt")
-			if _, err := os.Stat(agentPath); err == nil {
-				domains = append(domains, entry.Name())
-				continue
-			}
-			
-			// Verifica bounded contexts dentro do domínio
-			domainPath := filepath.Join(dir, entry.Name())
-			subEntries, err := os.ReadDir(domainPath)
-			if err != nil {
-				continue
-			}
-			
-			for _, subEntry := range subEntries {
-				if subEntry.IsDir() {
-					subAgentPath := filepath.Join(domainPath, subEntry.Name(), "agents", "instructions.txt")
-					if _, err := os.Stat(subAgentPath); err == nil {
-						domains = append(domains, entry.Name()+"/"+subEntry.Name())
-					}
-				}
-			}
-		}
-	}
-	
-	return domains
+	// Inclui bounded contexts (user/profile) e os agentes distribuídos pelo spread
+	return manifest.Domains(dir)
 }
 
 func needsNewProject(dir string) bool {
@@ -116,27 +71,17 @@ func needsNewProject(dir string) bool {
 }
 
 func IsComplexSoftwareRequest(input string) bool {
-	prompt := fmt.Sprintf(`
-Analise esta requisição e responda APENAS "SIM" ou "NAO":
-"%s"
-
-É um software complexo que precisa de múltiplos domínios/módulos coordenados?
-Considere complexo se envolve:
-- Múltiplas entidades de negócio
-- Diferentes responsabilidades
-- Integrações entre módulos
-- Arquitetura com vários componentes
+	prompt := prompts.Render("complexity_check", map[string]interface{}{"Input": input})
 
-Responda apenas: SIM ou NAO
-`, input)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
 
-	cmd := exec.Command("q", "chat", "--message", prompt)
-	output, err := cmd.Output()
+	output, err := backend.Default().Complete(ctx, backend.Request{AgentID: "complexity_detector", Prompt: prompt})
 	if err != nil {
-		// Fallback: se Q CLI falhar, usa heurística simples
+		// Fallback: se o backend falhar, usa heurística simples
 		return len(strings.Fields(input)) > 5
 	}
 
-	response := strings.ToUpper(strings.TrimSpace(string(output)))
-	return strings.Contains(response, "SIM")
+	response := strings.ToUpper(strings.TrimSpace(output.Content))
+	return strings.Contains(response, "SIM") || strings.Contains(response, "YES")
 }
@@ -1,15 +1,24 @@
 package intelligence
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"plaxo-orchestra/internal/blackboard"
 	"plaxo-orchestra/internal/pool"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/structured"
+	"plaxo-orchestra/internal/workflow"
 	"strings"
 )
 
 type Coordinator struct {
-	semantic  *SemanticAnalyzer
-	memory    map[string]WorkflowMemory
-	agentPool *pool.AgentPool
+	semantic       *SemanticAnalyzer
+	memory         map[string]WorkflowMemory
+	agentPool      *pool.AgentPool
+	workflowConfig workflow.Config
+	// planner, quando definido, responde o pedido de plano no lugar do "workflow_planner" do pool
+	planner func(ctx context.Context, prompt string) (string, error)
 }
 
 type WorkflowMemory struct {
@@ -19,6 +28,7 @@ type WorkflowMemory struct {
 }
 
 type WorkflowStep struct {
+	ID          string            `json:"id"`
 	Agent       string            `json:"agent"`
 	Action      string            `json:"action"`
 	Dependencies []string         `json:"dependencies"`
@@ -26,115 +36,129 @@ type WorkflowStep struct {
 	Status      string            `json:"status"`
 }
 
+// workflowPlan é o formato JSON pedido ao planejador.
+type workflowPlan struct {
+	Steps []plannedStep `json:"steps" minitems:"1"`
+}
+
+type plannedStep struct {
+	ID        string   `json:"id" desc:"identificador único da etapa"`
+	Agent     string   `json:"agent" desc:"um dos agentes disponíveis"`
+	Action    string   `json:"action" desc:"o que o agente deve fazer"`
+	DependsOn []string `json:"depends_on" desc:"IDs das etapas que executam antes"`
+	Output    string   `json:"output" desc:"informação que o agente deve gerar"`
+}
+
 func NewCoordinator() *Coordinator {
 	return &Coordinator{
 		semantic:  NewSemanticAnalyzer(),
 		memory:    make(map[string]WorkflowMemory),
-		agentPool: pool.NewAgentPool(),
+		agentPool:      pool.NewAgentPool(),
+		workflowConfig: workflow.DefaultConfig(),
 	}
 }
 
-func (c *Coordinator) PlanWorkflow(input string, availableAgents []string) (*WorkflowMemory, error) {
-	analysis, err := c.semantic.AnalyzeIntent(input)
-	if err != nil {
-		return nil, err
-	}
-
-	// Usa Amazon Q CLI para planejar workflow
-	prompt := fmt.Sprintf(`
-Crie um plano de execução para esta requisição:
-
-Requisição: "%s"
-Análise semântica: %+v
-Agentes disponíveis: %s
+// SetAgentPool faz o planejador usar o pool (e as sessões) de quem o criou.
+func (c *Coordinator) SetAgentPool(agentPool *pool.AgentPool) {
+	c.agentPool = agentPool
+}
 
-Retorne um plano estruturado indicando:
-1. Quais agentes devem ser executados
-2. Em que ordem (dependências)
-3. Que informações cada agente precisa
-4. Que outputs cada agente deve gerar
+// SetPlanner faz outro agente (ex: o supervisor do projeto) dividir o pedido entre os domínios.
+func (c *Coordinator) SetPlanner(planner func(ctx context.Context, prompt string) (string, error)) {
+	c.planner = planner
+}
 
-Formato:
-AGENTE: nome_do_agente
-AÇÃO: o que deve fazer
-DEPENDE: agentes que devem executar antes (ou "nenhum")
-SAÍDA: que informação deve gerar
+// SetWorkflowConfig define concorrência, timeout e retry usados em ExecuteWorkflow.
+func (c *Coordinator) SetWorkflowConfig(cfg workflow.Config) {
+	c.workflowConfig = cfg
+}
 
-Exemplo:
-AGENTE: user
-AÇÃO: criar estrutura de autenticação
-DEPENDE: nenhum
-SAÍDA: interfaces de autenticação
+// PlanWorkflow planeja as etapas do pedido entre os agentes; analysis é a análise semântica já feita por
+// quem chama, ou nil para analisar aqui.
+func (c *Coordinator) PlanWorkflow(input string, analysis *SemanticResult, availableAgents []string) (*WorkflowMemory, error) {
+	if analysis == nil {
+		var err error
+		if analysis, err = c.semantic.AnalyzeIntent(input); err != nil {
+			return nil, err
+		}
+	}
 
-AGENTE: catalog
-AÇÃO: implementar listagem de produtos
-DEPENDE: user
-SAÍDA: API de produtos com autenticação
-`, input, analysis, strings.Join(availableAgents, ", "))
+	// Usa Amazon Q CLI para planejar workflow
+	prompt := prompts.Render("workflow_plan", map[string]interface{}{
+		"Input":    input,
+		"Analysis": analysis,
+		"Agents":   availableAgents,
+	})
 
-	output, err := c.agentPool.Execute("workflow_planner", prompt)
+	workflow, err := c.planWorkflowSteps(prompt, availableAgents)
 	if err != nil {
+		structured.Fallback("workflow_plan", err)
 		return c.createSimpleWorkflow(input, availableAgents), nil
 	}
-
-	workflow := c.parseWorkflowPlan(string(output), availableAgents)
 	c.memory[input] = *workflow
 	
 	return workflow, nil
 }
 
-func (c *Coordinator) parseWorkflowPlan(plan string, availableAgents []string) *WorkflowMemory {
-	workflow := &WorkflowMemory{
-		Steps:     []WorkflowStep{},
-		Context:   plan,
-		Completed: []string{},
-	}
-
-	lines := strings.Split(plan, "\n")
-	var currentStep *WorkflowStep
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		
-		if strings.HasPrefix(line, "AGENTE:") {
-			if currentStep != nil {
-				workflow.Steps = append(workflow.Steps, *currentStep)
-			}
-			agent := strings.TrimSpace(strings.TrimPrefix(line, "AGENTE:"))
-			if c.isValidAgent(agent, availableAgents) {
-				currentStep = &WorkflowStep{
-					Agent:        agent,
-					Dependencies: []string{},
-					Outputs:      make(map[string]string),
-					Status:       "pending",
-				}
+// planWorkflowSteps pede o plano em JSON e confere se os agentes existem e se as etapas formam um DAG válido.
+func (c *Coordinator) planWorkflowSteps(prompt string, availableAgents []string) (*WorkflowMemory, error) {
+	var plan workflowPlan
+	check := func() []string {
+		var problems []string
+		dag := workflow.New("plan")
+		for i, step := range plan.Steps {
+			if !c.isValidAgent(step.Agent, availableAgents) {
+				problems = append(problems, fmt.Sprintf("$.steps[%d].agent: agente %q não está entre %s", i, step.Agent, strings.Join(availableAgents, ", ")))
 			}
-		} else if currentStep != nil {
-			if strings.HasPrefix(line, "AÇÃO:") {
-				currentStep.Action = strings.TrimSpace(strings.TrimPrefix(line, "AÇÃO:"))
-			} else if strings.HasPrefix(line, "DEPENDE:") {
-				deps := strings.TrimSpace(strings.TrimPrefix(line, "DEPENDE:"))
-				if deps != "nenhum" && deps != "" {
-					currentStep.Dependencies = strings.Split(deps, ",")
-					for i, dep := range currentStep.Dependencies {
-						currentStep.Dependencies[i] = strings.TrimSpace(dep)
-					}
-				}
-			} else if strings.HasPrefix(line, "SAÍDA:") {
-				output := strings.TrimSpace(strings.TrimPrefix(line, "SAÍDA:"))
-				currentStep.Outputs["main"] = output
+			dag.Add(&workflow.Step{ID: step.ID, Agent: step.Agent, DependsOn: step.DependsOn})
+		}
+		// IDs vazios ou repetidos, dependências desconhecidas e ciclos
+		var invalid *workflow.ValidationError
+		if err := dag.Validate(); errors.As(err, &invalid) {
+			for _, problem := range invalid.Problems {
+				problems = append(problems, "$.steps: "+problem)
 			}
 		}
+		return problems
 	}
 
-	if currentStep != nil {
-		workflow.Steps = append(workflow.Steps, *currentStep)
+	complete := func(ctx context.Context, prompt string) (string, error) {
+		if c.planner != nil {
+			return c.planner(ctx, prompt)
+		}
+		return c.agentPool.ExecuteContext(ctx, "workflow_planner", prompt)
+	}
+	request := structured.Request{Name: "workflow_plan", Prompt: prompt, Check: check}
+	if err := structured.Generate(context.Background(), complete, request, &plan); err != nil {
+		return nil, err
 	}
 
-	return workflow
+	workflow := &WorkflowMemory{
+		Steps:     []WorkflowStep{},
+		Context:   prompt,
+		Completed: []string{},
+	}
+	for _, step := range plan.Steps {
+		dependencies := step.DependsOn
+		if dependencies == nil {
+			dependencies = []string{}
+		}
+		workflow.Steps = append(workflow.Steps, WorkflowStep{
+			ID:           step.ID,
+			Agent:        step.Agent,
+			Action:       step.Action,
+			Dependencies: dependencies,
+			Outputs:      map[string]string{"main": step.Output},
+			Status:       "pending",
+		})
+	}
+	return workflow, nil
 }
 
 func (c *Coordinator) isValidAgent(agent string, availableAgents []string) bool {
+	if agent == "" {
+		return false
+	}
 	for _, available := range availableAgents {
 		if strings.Contains(available, agent) || strings.Contains(agent, available) {
 			return true
@@ -151,18 +175,13 @@ func (c *Coordinator) createSimpleWorkflow(input string, availableAgents []strin
 		Completed: []string{},
 	}
 
-	// Ordena agentes por relevância
-	scores := make(map[string]float64)
+	// Adiciona agentes com score > 0.3, na ordem recebida
 	for _, agent := range availableAgents {
-		scores[agent] = c.semantic.CalculateSimilarity(input, agent)
-	}
-
-	// Adiciona agentes com score > 0.3
-	for agent, score := range scores {
-		if score > 0.3 {
+		if c.semantic.CalculateSimilarity(input, agent) > 0.3 {
 			step := WorkflowStep{
+				ID:           agent,
 				Agent:        agent,
-				Action:       fmt.Sprintf("Implementar funcionalidades relacionadas a: %s", input),
+				Action:       strings.TrimSpace(prompts.Render("workflow_default_action", map[string]interface{}{"Input": input})),
 				Dependencies: []string{},
 				Outputs:      map[string]string{"main": "Implementação completa"},
 				Status:       "pending",
@@ -174,91 +193,78 @@ func (c *Coordinator) createSimpleWorkflow(input string, availableAgents []strin
 	return workflow
 }
 
-func (c *Coordinator) ExecuteWorkflow(workflow *WorkflowMemory, agentExecutor func(string, string) (string, error)) error {
-	fmt.Printf("🎯 Executando workflow com %d etapas\n", len(workflow.Steps))
-
-	for len(workflow.Completed) < len(workflow.Steps) {
-		executed := false
-		
-		for i, step := range workflow.Steps {
-			if step.Status == "completed" {
-				continue
-			}
-
-			// Verifica se dependências foram completadas
-			if c.dependenciesCompleted(step.Dependencies, workflow.Completed) {
-				fmt.Printf("▶️  Executando: %s\n", step.Agent)
-				
-				// Prepara contexto com outputs das dependências
-				context := c.buildContextForStep(step, workflow)
-				prompt := fmt.Sprintf("%s\n\nContexto das etapas anteriores:\n%s", step.Action, context)
-				
-				result, err := agentExecutor(step.Agent, prompt)
-				if err != nil {
-					fmt.Printf("❌ Erro em %s: %v\n", step.Agent, err)
-					workflow.Steps[i].Status = "failed"
-					continue
-				}
-
-				workflow.Steps[i].Status = "completed"
-				workflow.Steps[i].Outputs["result"] = result
-				workflow.Completed = append(workflow.Completed, step.Agent)
-				
-				fmt.Printf("✅ %s concluído\n", step.Agent)
-				executed = true
-			}
+// ExecuteWorkflow executa as etapas no motor de DAG; etapas já "completed" são reaproveitadas.
+func (c *Coordinator) ExecuteWorkflow(ctx context.Context, memory *WorkflowMemory, agentExecutor func(context.Context, string, string) (string, error)) error {
+	fmt.Printf("🎯 Executando workflow com %d etapas\n", len(memory.Steps))
+
+	wf := workflow.New("coordinator")
+	previous := make(map[string]*workflow.Result)
+	index := make(map[string]int)
+	for i, step := range memory.Steps {
+		wf.Add(&workflow.Step{ID: step.ID, Agent: step.Agent, Action: step.Action, DependsOn: step.Dependencies})
+		index[step.ID] = i
+		if step.Status == "completed" {
+			previous[step.ID] = &workflow.Result{StepID: step.ID, Agent: step.Agent, Status: workflow.StatusSucceeded, Output: step.Outputs["result"]}
 		}
+	}
 
-		if !executed {
-			// Deadlock ou erro - força execução dos pendentes
-			for i, step := range workflow.Steps {
-				if step.Status == "pending" {
-					fmt.Printf("⚠️  Forçando execução: %s\n", step.Agent)
-					result, _ := agentExecutor(step.Agent, step.Action)
-					workflow.Steps[i].Status = "completed"
-					workflow.Steps[i].Outputs["result"] = result
-					workflow.Completed = append(workflow.Completed, step.Agent)
-				}
+	// Falha em um agente não impede os ramos independentes
+	engine := workflow.NewEngine(c.workflowConfig)
+	engine.ContinueOnError = true
+	engine.OnStepStart = func(step *workflow.Step) {
+		fmt.Printf("▶️  Executando: %s\n", step.ID)
+		memory.Steps[index[step.ID]].Status = "running"
+	}
+	engine.OnStepDone = func(step *workflow.Step, result *workflow.Result) {
+		s := &memory.Steps[index[step.ID]]
+		switch result.Status {
+		case workflow.StatusSucceeded:
+			s.Status = "completed"
+			if s.Outputs == nil {
+				s.Outputs = make(map[string]string)
 			}
-			break
+			s.Outputs["result"] = result.Output
+			memory.Completed = append(memory.Completed, step.ID)
+			fmt.Printf("✅ %s concluído\n", step.ID)
+		case workflow.StatusFailed:
+			s.Status = "failed"
+			fmt.Printf("❌ Erro em %s: %s\n", step.ID, result.Error)
+		case workflow.StatusSkipped:
+			s.Status = "skipped"
+			fmt.Printf("⏭️  %s pulado: %s\n", step.ID, result.Error)
 		}
 	}
 
+	// Sem execução gravada, o quadro compartilhado vive só durante este workflow
+	if blackboard.From(ctx) == nil {
+		ctx = blackboard.With(ctx, blackboard.New())
+	}
+	_, err := engine.Run(ctx, wf, func(ctx context.Context, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+		prompt := prompts.Render("workflow_step", map[string]interface{}{
+			"Action":  step.Action,
+			"Context": c.buildContextForStep(step, inputs),
+		})
+		return agentExecutor(ctx, step.Agent, prompt)
+	}, previous)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("🎉 Workflow concluído!")
 	return nil
 }
 
-func (c *Coordinator) dependenciesCompleted(dependencies []string, completed []string) bool {
-	for _, dep := range dependencies {
-		found := false
-		for _, comp := range completed {
-			if strings.Contains(comp, dep) || strings.Contains(dep, comp) {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-	return true
-}
-
-func (c *Coordinator) buildContextForStep(step WorkflowStep, workflow *WorkflowMemory) string {
+// buildContextForStep junta as saídas das dependências diretas da etapa.
+func (c *Coordinator) buildContextForStep(step *workflow.Step, inputs map[string]*workflow.Result) string {
 	var context strings.Builder
-	
-	for _, dep := range step.Dependencies {
-		for _, completedStep := range workflow.Steps {
-			if completedStep.Status == "completed" && 
-			   (strings.Contains(completedStep.Agent, dep) || strings.Contains(dep, completedStep.Agent)) {
-				context.WriteString(fmt.Sprintf("\n=== Output de %s ===\n", completedStep.Agent))
-				if result, exists := completedStep.Outputs["result"]; exists {
-					context.WriteString(result)
-				}
-				context.WriteString("\n")
-			}
+
+	for _, dep := range step.DependsOn {
+		if result := inputs[dep]; result != nil && result.Succeeded() {
+			context.WriteString(fmt.Sprintf("\n=== Output de %s ===\n", dep))
+			context.WriteString(result.Output)
+			context.WriteString("\n")
 		}
 	}
-	
+
 	return context.String()
 }
@@ -0,0 +1,57 @@
+package intelligence
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPlanWorkflowSteps(t *testing.T) {
+	agents := []string{"auth", "user/profile"}
+	valid := `{"steps":[{"id":"login","agent":"auth","action":"a","depends_on":[],"output":"o"},{"id":"perfil","agent":"user","action":"b","depends_on":["login"],"output":"o"}]}`
+
+	tests := []struct {
+		name string
+		// plan é a primeira resposta; a resposta válida vem no reparo
+		plan    string
+		problem string
+	}{
+		{"plano válido", valid, ""},
+		{"ciclo", `{"steps":[{"id":"a","agent":"auth","action":"x","depends_on":["b"],"output":"o"},{"id":"b","agent":"auth","action":"y","depends_on":["a"],"output":"o"}]}`, "ciclo de dependências"},
+		{"ID vazio", `{"steps":[{"id":"","agent":"auth","action":"x","depends_on":[],"output":"o"}]}`, "sem ID"},
+		{"agente vazio", `{"steps":[{"id":"a","agent":"","action":"x","depends_on":[],"output":"o"}]}`, `agente ""`},
+		{"dependência desconhecida", `{"steps":[{"id":"a","agent":"auth","action":"x","depends_on":["z"],"output":"o"}]}`, "etapa desconhecida z"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var prompts []string
+			c := NewCoordinator()
+			c.SetPlanner(func(ctx context.Context, prompt string) (string, error) {
+				prompts = append(prompts, prompt)
+				if len(prompts) == 1 {
+					return test.plan, nil
+				}
+				return valid, nil
+			})
+
+			plan, err := c.planWorkflowSteps("planeje", agents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Steps) != 2 || plan.Steps[1].Dependencies[0] != "login" {
+				t.Errorf("plano = %+v", plan.Steps)
+			}
+
+			if test.problem == "" {
+				if len(prompts) != 1 {
+					t.Errorf("%d chamadas para um plano válido", len(prompts))
+				}
+				return
+			}
+			if len(prompts) != 2 || !strings.Contains(prompts[1], test.problem) {
+				t.Errorf("reparo deveria citar %q; chamadas: %d", test.problem, len(prompts))
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package intelligence
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/pool"
+	"plaxo-orchestra/internal/workflow"
+)
+
+// model é o modelo falso usado para gravar a cassette: responde com a primeira regra do agente
+// cujo trecho aparece no fim do prompt.
+type model map[string][][2]string
+
+func (m model) Name() string { return "model" }
+
+func (m model) Complete(ctx context.Context, req backend.Request) (*backend.Response, error) {
+	last := req.Prompt
+	if i := strings.LastIndex(last, "Nova mensagem:"); i != -1 {
+		last = last[i:]
+	}
+	for _, rule := range m[req.AgentID] {
+		if strings.Contains(last, rule[0]) {
+			return &backend.Response{Content: rule[1], Usage: backend.EstimateUsage(req.Prompt, rule[1])}, nil
+		}
+	}
+	return &backend.Response{Content: "ok", Usage: backend.EstimateUsage(req.Prompt, "ok")}, nil
+}
+
+func (m model) Stream(ctx context.Context, req backend.Request, onChunk func(string)) (*backend.Response, error) {
+	response, err := m.Complete(ctx, req)
+	if err == nil {
+		onChunk(response.Content)
+	}
+	return response, err
+}
+
+func (m model) Cancel() {}
+
+// useCassette responde com testdata/cassettes/<name>.json e exige que todas as interações sejam usadas.
+// Com PLAXO_RECORD=1, grava a cassette de novo a partir do modelo falso.
+func useCassette(t *testing.T, name string, fake model) {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("testdata", "cassettes", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b backend.Backend
+	if os.Getenv("PLAXO_RECORD") != "" {
+		os.Remove(path)
+		b = backend.NewRecordingBackend(fake, path)
+	} else {
+		cassette, err := backend.LoadCassette(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replay := backend.NewReplayBackend(cassette)
+		t.Cleanup(func() {
+			if remaining := replay.Remaining(); remaining > 0 && !t.Failed() {
+				t.Errorf("%d interações da cassette %s não foram usadas", remaining, name)
+			}
+		})
+		b = replay
+	}
+	backend.SetDefault(b)
+	t.Cleanup(func() { backend.SetDefault(nil) })
+}
+
+func TestReplayExecuteWorkflow(t *testing.T) {
+	useCassette(t, "execute_workflow", model{
+		"user": {{"e-mail no perfil", "Perfil com e-mail, usando o ID string do login."}},
+		"auth": {{"renovar a sessão", "Sessão renovada a cada login."}},
+	})
+
+	agentPool := pool.NewAgentPool()
+	defer agentPool.Close()
+	executor := func(ctx context.Context, agent, prompt string) (string, error) {
+		if agent == "billing" {
+			return "", fmt.Errorf("agente %s não encontrado", agent)
+		}
+		return agentPool.ExecuteContext(ctx, agent, prompt)
+	}
+
+	memory := &WorkflowMemory{Steps: []WorkflowStep{
+		{ID: "login", Agent: "auth", Action: "conferir a senha", Status: "completed", Outputs: map[string]string{"result": "Login devolve o ID string."}},
+		{ID: "perfil", Agent: "user", Action: "guardar o e-mail no perfil", Dependencies: []string{"login"}},
+		{ID: "sessao", Agent: "auth", Action: "renovar a sessão", Dependencies: []string{"login"}},
+		{ID: "cobranca", Agent: "billing", Action: "cobrar a assinatura"},
+	}}
+
+	// Sem novas tentativas, a falha de cobranca não espera o backoff
+	cfg := workflow.DefaultConfig()
+	cfg.Retries = 0
+	c := NewCoordinator()
+	c.SetWorkflowConfig(cfg)
+	if err := c.ExecuteWorkflow(context.Background(), memory, executor); err == nil {
+		t.Fatal("esperava a falha de cobranca")
+	}
+
+	want := map[string]string{"login": "completed", "perfil": "completed", "sessao": "completed", "cobranca": "failed"}
+	for _, step := range memory.Steps {
+		if step.Status != want[step.ID] {
+			t.Errorf("%s: status %s, esperado %s", step.ID, step.Status, want[step.ID])
+		}
+	}
+	if result := memory.Steps[1].Outputs["result"]; !strings.Contains(result, "Perfil com e-mail") {
+		t.Errorf("saída de perfil = %q", result)
+	}
+}
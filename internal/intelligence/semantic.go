@@ -1,9 +1,12 @@
 package intelligence
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"plaxo-orchestra/internal/pool"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/structured"
 	"strings"
 )
 
@@ -13,11 +16,11 @@ type SemanticAnalyzer struct {
 }
 
 type SemanticResult struct {
-	Intent     string            `json:"intent"`
+	Intent     string            `json:"intent" enum:"create,modify,query,debug,integrate"`
 	Entities   []string          `json:"entities"`
 	Domains    []string          `json:"domains"`
-	Complexity string            `json:"complexity"`
-	Keywords   map[string]float64 `json:"keywords"`
+	Complexity string            `json:"complexity" enum:"simple,medium,complex"`
+	Keywords   map[string]float64 `json:"keywords" desc:"peso de relevância de 0.0 a 1.0"`
 }
 
 func NewSemanticAnalyzer() *SemanticAnalyzer {
@@ -34,41 +37,16 @@ func (s *SemanticAnalyzer) AnalyzeIntent(input string) (*SemanticResult, error)
 	}
 
 	// Usa Amazon Q CLI para análise semântica
-	prompt := fmt.Sprintf(`
-Analise semanticamente esta requisição e retorne APENAS um JSON válido:
-
-Requisição: "%s"
-
-Formato esperado:
-{
-  "intent": "create|modify|query|debug|integrate",
-  "entities": ["entidade1", "entidade2"],
-  "domains": ["dominio_provavel1", "dominio_provavel2"],
-  "complexity": "simple|medium|complex",
-  "keywords": {"palavra1": 0.9, "palavra2": 0.7}
-}
-
-Regras:
-- intent: ação principal (create, modify, query, debug, integrate)
-- entities: substantivos importantes (user, product, order, etc)
-- domains: domínios técnicos prováveis (user, catalog, payment, etc)
-- complexity: simple (1 domínio), medium (2-3), complex (4+)
-- keywords: palavras-chave com peso de relevância (0.0-1.0)
-`, input)
-
-	output, err := s.agentPool.Execute("semantic_analyzer", prompt)
-	if err != nil {
-		return nil, fmt.Errorf("erro na análise semântica: %v", err)
-	}
-
-	// Extrai JSON da resposta
-	jsonStr := s.extractJSON(output)
-	if jsonStr == "" {
-		return s.fallbackAnalysis(input), nil
-	}
+	prompt := prompts.Render("semantic_analysis", map[string]interface{}{"Input": input})
 
 	var result SemanticResult
-	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+	err := structured.Generate(context.Background(), s.complete, structured.Request{Name: "semantic_analysis", Prompt: prompt}, &result)
+	if err != nil {
+		var invalid *structured.ValidationError
+		if !errors.As(err, &invalid) {
+			return nil, fmt.Errorf("erro na análise semântica: %v", err)
+		}
+		structured.Fallback("semantic_analysis", err)
 		return s.fallbackAnalysis(input), nil
 	}
 
@@ -77,19 +55,8 @@ Regras:
 	return &result, nil
 }
 
-func (s *SemanticAnalyzer) extractJSON(text string) string {
-	// Procura por JSON na resposta
-	start := strings.Index(text, "{")
-	if start == -1 {
-		return ""
-	}
-
-	end := strings.LastIndex(text, "}")
-	if end == -1 || end <= start {
-		return ""
-	}
-
-	return text[start : end+1]
+func (s *SemanticAnalyzer) complete(ctx context.Context, prompt string) (string, error) {
+	return s.agentPool.ExecuteContext(ctx, "semantic_analyzer", prompt)
 }
 
 func (s *SemanticAnalyzer) fallbackAnalysis(input string) *SemanticResult {
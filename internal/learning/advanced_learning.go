@@ -24,9 +24,9 @@ type TemporalPattern struct {
 }
 
 type AdvancedLearning struct {
-	feedback        []DecisionFeedback `json:"feedback"`
-	patterns        []TemporalPattern  `json:"patterns"`
-	agentPerformance map[string]*AgentMetrics `json:"agent_performance"`
+	feedback        []DecisionFeedback
+	patterns        []TemporalPattern
+	agentPerformance map[string]*AgentMetrics
 }
 
 type AgentMetrics struct {
@@ -0,0 +1,324 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"plaxo-orchestra/internal/sandbox"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Version é a versão atual do formato do manifesto.
+const Version = 1
+
+const (
+	// DirName é o diretório do agente dentro do domínio (user/agents, user/profile/agents).
+	DirName  = "agents"
+	FileName = "agent.yaml"
+	// LegacyFile é o formato antigo, só com as instruções em texto livre.
+	LegacyFile = "instructions.txt"
+	// OrchestratorDir guarda o agente de coordenação geral (o supervisor) criado pelo spread.
+	OrchestratorDir = "orchestra_agents"
+	// OrchestratorDomain é o domínio do supervisor
+	OrchestratorDomain = "orchestrator"
+)
+
+// Manifest define um agente: o que ele sabe fazer, onde atua e com quais recursos.
+type Manifest struct {
+	Version          int               `yaml:"version"`
+	Name             string            `yaml:"name"`
+	Domain           string            `yaml:"domain"`
+	Instructions     string            `yaml:"instructions"`
+	Responsibilities []string          `yaml:"responsibilities,omitempty"`
+	Commands         map[string]string `yaml:"commands,omitempty"`
+	// Paths são os diretórios de código do agente, relativos à raiz do projeto
+	Paths []string `yaml:"paths,omitempty"`
+	// Model sobrescreve o modelo do backend para este agente
+	Model string `yaml:"model,omitempty"`
+	// Tools são as ferramentas que o agente pode chamar com "@tool"; vazio desliga o uso de ferramentas
+	Tools []string `yaml:"tools,omitempty"`
+	// Permissions limita o que o agente lê e escreve; cada lista vazia herda Paths
+	Permissions Permissions `yaml:"permissions,omitempty"`
+	TechStack   []string    `yaml:"tech_stack,omitempty"`
+	Complexity  int         `yaml:"complexity,omitempty"`
+	FilesCount  int         `yaml:"files_count,omitempty"`
+
+	// Dir é o diretório de onde o manifesto foi lido ou para onde será gravado
+	Dir string `yaml:"-"`
+
+	// upgraded descreve o que foi convertido de formatos antigos na leitura
+	upgraded []string
+}
+
+// Permissions são caminhos relativos à raiz do projeto que o agente pode ler e escrever.
+type Permissions struct {
+	Read  []string `yaml:"read,omitempty"`
+	Write []string `yaml:"write,omitempty"`
+}
+
+// legacyContext é o bloco "context" do agent.yaml sem versão gerado pelo spread.
+type legacyContext struct {
+	Context struct {
+		Path string `yaml:"path"`
+	} `yaml:"context"`
+}
+
+// New cria o manifesto de um agente em <root>/<domínio>/agents, com os comandos e responsabilidades padrão.
+func New(root, domain, instructions string) *Manifest {
+	return &Manifest{
+		Version:          Version,
+		Name:             strings.ReplaceAll(domain, "/", "-") + "-agent",
+		Domain:           domain,
+		Instructions:     instructions,
+		Responsibilities: DefaultResponsibilities(domain),
+		Commands:         DefaultCommands(domain),
+		Paths:            []string{domain},
+		Tools:            DefaultTools(),
+		Dir:              filepath.Join(root, filepath.FromSlash(domain), DirName),
+	}
+}
+
+// DefaultTools são as ferramentas embutidas (internal/tools) liberadas nos manifestos novos e migrados.
+// run_tests executa código do projeto e só entra quando o manifesto a lista.
+func DefaultTools() []string {
+	return []string{"read_file", "list_dir", "grep", "git_diff"}
+}
+
+func DefaultResponsibilities(domain string) []string {
+	return []string{
+		"Análise de código do domínio " + domain,
+		"Refatoração e otimização",
+		"Testes e validação",
+		"Documentação técnica",
+	}
+}
+
+func DefaultCommands(domain string) map[string]string {
+	return map[string]string{
+		"analyze":  "Analisar código do domínio " + domain,
+		"refactor": "Refatorar código seguindo melhores práticas",
+		"test":     "Criar/executar testes para o domínio",
+		"document": "Gerar documentação técnica",
+	}
+}
+
+// DefaultInstructions descreve o agente a partir das responsabilidades, para manifestos antigos sem instruções.
+func DefaultInstructions(domain string, responsibilities []string) string {
+	instructions := fmt.Sprintf("Especialista no domínio %s.", domain)
+	if len(responsibilities) > 0 {
+		instructions += "\n\nResponsabilidades:\n- " + strings.Join(responsibilities, "\n- ")
+	}
+	return instructions
+}
+
+// Read lê o agente de dir, aceitando o agent.yaml atual, o agent.yaml sem versão do spread e o instructions.txt antigo.
+func Read(root, dir string) (*Manifest, error) {
+	m := &Manifest{}
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, m); err != nil {
+			return nil, fmt.Errorf("manifesto inválido em %s: %v", dir, err)
+		}
+		if m.Version > Version {
+			return nil, fmt.Errorf("manifesto em %s tem versão %d; esta versão do plaxo lê até a %d", dir, m.Version, Version)
+		}
+		if m.Version == 0 {
+			var legacy legacyContext
+			yaml.Unmarshal(data, &legacy)
+			if path := relative(root, legacy.Context.Path); path != "" && len(m.Paths) == 0 {
+				m.Paths = []string{path}
+			}
+			m.upgraded = append(m.upgraded, FileName+" sem versão")
+		}
+	case os.IsNotExist(err):
+		if _, err := os.Stat(filepath.Join(dir, LegacyFile)); err != nil {
+			return nil, fmt.Errorf("nenhum agente em %s", dir)
+		}
+	default:
+		return nil, err
+	}
+
+	if legacy, err := os.ReadFile(filepath.Join(dir, LegacyFile)); err == nil {
+		if m.Instructions == "" {
+			m.Instructions = string(legacy)
+		}
+		m.upgraded = append(m.upgraded, LegacyFile)
+	}
+
+	m.Dir = dir
+	m.fill(root)
+	if len(m.upgraded) > 0 && m.Tools == nil {
+		m.Tools = DefaultTools()
+	}
+	return m, nil
+}
+
+// fill completa os campos que os formatos antigos não tinham.
+func (m *Manifest) fill(root string) {
+	home := relative(root, filepath.Dir(m.Dir))
+	if m.Domain == "" {
+		m.Domain = home
+		if filepath.Base(m.Dir) == OrchestratorDir {
+			m.Domain = OrchestratorDomain
+		}
+	}
+	if m.Name == "" {
+		m.Name = strings.ReplaceAll(m.Domain, "/", "-") + "-agent"
+	}
+	if len(m.Paths) == 0 && home != "" {
+		m.Paths = []string{home}
+	}
+	if m.Responsibilities == nil {
+		m.Responsibilities = DefaultResponsibilities(m.Domain)
+	}
+	if m.Commands == nil {
+		m.Commands = DefaultCommands(m.Domain)
+	}
+	if m.Instructions == "" {
+		m.Instructions = DefaultInstructions(m.Domain, m.Responsibilities)
+	}
+	m.Version = Version
+}
+
+// Policy é a política de acesso do agente: as permissões declaradas ou, na falta delas, os Paths.
+func (m *Manifest) Policy(root string) sandbox.Policy {
+	policy := sandbox.Policy{Agent: m.Domain, Root: root, Read: m.Permissions.Read, Write: m.Permissions.Write}
+	if len(policy.Read) == 0 {
+		policy.Read = m.Paths
+	}
+	if len(policy.Write) == 0 {
+		policy.Write = m.Paths
+	}
+	return policy
+}
+
+// Upgraded indica o que foi convertido de formatos antigos; vazio se o manifesto já está na versão atual.
+func (m *Manifest) Upgraded() []string {
+	return m.upgraded
+}
+
+func (m *Manifest) YAML() string {
+	data, _ := yaml.Marshal(m)
+	return string(data)
+}
+
+// Save grava o manifesto em Dir/agent.yaml.
+func Save(m *Manifest) error {
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(m.Dir, FileName)
+	content := fmt.Sprintf("# Agente do domínio %s (manifesto plaxo, versão %d)\n", m.Domain, m.Version) + m.YAML()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Discover encontra os agentes de domínio do projeto (diretórios agents/ com manifesto), ordenados por domínio.
+func Discover(root string) ([]*Manifest, error) {
+	var manifests []*Manifest
+	err := walk(root, func(dir string) error {
+		if filepath.Base(dir) != DirName {
+			return nil
+		}
+		m, err := Read(root, dir)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, m)
+		return nil
+	})
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Domain < manifests[j].Domain
+	})
+	return manifests, err
+}
+
+// Domains lista os domínios com agente; manifestos inválidos são ignorados.
+func Domains(root string) []string {
+	var domains []string
+	walk(root, func(dir string) error {
+		if filepath.Base(dir) != DirName {
+			return nil
+		}
+		if m, err := Read(root, dir); err == nil {
+			domains = append(domains, m.Domain)
+		}
+		return nil
+	})
+	sort.Strings(domains)
+	return domains
+}
+
+// Find devolve o agente do domínio, procurando primeiro em <root>/<domínio>/agents.
+func Find(root, domain string) (*Manifest, error) {
+	if m, err := Read(root, filepath.Join(root, filepath.FromSlash(domain), DirName)); err == nil && m.Domain == domain {
+		return m, nil
+	}
+
+	manifests, err := Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range manifests {
+		if m.Domain == domain {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("agente do domínio %s não encontrado", domain)
+}
+
+// Supervisor lê o agente de coordenação geral em <root>/orchestra_agents.
+func Supervisor(root string) (*Manifest, error) {
+	return Read(root, filepath.Join(root, OrchestratorDir))
+}
+
+// walk chama visit para cada diretório de agente (agents/ ou orchestra_agents/) sob root.
+func walk(root string, visit func(dir string) error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+			return filepath.SkipDir
+		}
+		if name != DirName && name != OrchestratorDir {
+			return nil
+		}
+		if !exists(filepath.Join(path, FileName)) && !exists(filepath.Join(path, LegacyFile)) {
+			return nil
+		}
+		if err := visit(path); err != nil {
+			return err
+		}
+		return filepath.SkipDir
+	})
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// relative converte path para o formato com barras relativo a root; vazio se estiver fora dele.
+func relative(root, path string) string {
+	if path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,175 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		dir          string
+		domain       string
+		instructions string
+		paths        string
+		upgraded     string
+		tools        bool
+		err          string
+	}{
+		{
+			name:         "manifesto atual",
+			files:        map[string]string{"auth/agents/agent.yaml": "version: 1\ndomain: auth\ninstructions: cuida de login\npaths: [auth, shared]\n"},
+			dir:          "auth/agents",
+			domain:       "auth",
+			instructions: "cuida de login",
+			paths:        "auth,shared",
+		},
+		{
+			name:         "instructions.txt antigo",
+			files:        map[string]string{"user/profile/agents/instructions.txt": "cuida de perfil"},
+			dir:          "user/profile/agents",
+			domain:       "user/profile",
+			instructions: "cuida de perfil",
+			paths:        "user/profile",
+			upgraded:     "instructions.txt",
+			tools:        true,
+		},
+		{
+			name:     "agent.yaml sem versão do spread",
+			files:    map[string]string{"products/agents/agent.yaml": "name: products-agent\ncontext:\n  path: src/products\n"},
+			dir:      "products/agents",
+			domain:   "products",
+			paths:    "src/products",
+			upgraded: "agent.yaml sem versão",
+			tools:    true,
+		},
+		{
+			name:   "supervisor",
+			files:  map[string]string{"orchestra_agents/agent.yaml": "version: 1\ninstructions: coordena\n"},
+			dir:    "orchestra_agents",
+			domain: OrchestratorDomain,
+		},
+		{
+			name:  "versão mais nova que a suportada",
+			files: map[string]string{"auth/agents/agent.yaml": "version: 99\n"},
+			dir:   "auth/agents",
+			err:   "versão 99",
+		},
+		{
+			name: "diretório sem agente",
+			dir:  "auth/agents",
+			err:  "nenhum agente",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			for path, content := range test.files {
+				write(t, root, path, content)
+			}
+
+			m, err := Read(root, filepath.Join(root, filepath.FromSlash(test.dir)))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("erro = %v, esperado %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Domain != test.domain || m.Version != Version {
+				t.Errorf("domínio %q versão %d", m.Domain, m.Version)
+			}
+			if test.instructions != "" && m.Instructions != test.instructions {
+				t.Errorf("instruções = %q", m.Instructions)
+			}
+			if m.Instructions == "" {
+				t.Error("instruções vazias")
+			}
+			if strings.Join(m.Paths, ",") != test.paths {
+				t.Errorf("paths = %v, esperado %s", m.Paths, test.paths)
+			}
+			if strings.Join(m.Upgraded(), ",") != test.upgraded {
+				t.Errorf("convertido = %v, esperado %s", m.Upgraded(), test.upgraded)
+			}
+			if (len(m.Tools) > 0) != test.tools {
+				t.Errorf("ferramentas = %v", m.Tools)
+			}
+		})
+	}
+}
+
+func TestDiscoverAndFind(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, "auth/agents/agent.yaml", "version: 1\ndomain: auth\n")
+	write(t, root, "user/profile/agents/instructions.txt", "perfil")
+	write(t, root, "orchestra_agents/agent.yaml", "version: 1\n")
+	write(t, root, "node_modules/lib/agents/agent.yaml", "version: 1\ndomain: lib\n")
+	write(t, root, ".plaxo/agents/agent.yaml", "version: 1\ndomain: oculto\n")
+
+	if domains := strings.Join(Domains(root), ","); domains != "auth,user/profile" {
+		t.Errorf("Domains = %s", domains)
+	}
+	m, err := Find(root, "user/profile")
+	if err != nil || m.Instructions != "perfil" {
+		t.Fatalf("Find = %+v, %v", m, err)
+	}
+	if _, err := Find(root, "billing"); err == nil {
+		t.Error("domínio inexistente deveria falhar")
+	}
+	if m, err := Supervisor(root); err != nil || m.Domain != OrchestratorDomain {
+		t.Errorf("Supervisor = %+v, %v", m, err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, "auth/agents/instructions.txt", "cuida de login")
+	write(t, root, "user/agents/agent.yaml", "version: 1\ndomain: user\ninstructions: x\n")
+
+	planned, err := Migrate(root, true)
+	if err != nil || len(planned) != 1 || planned[0].Domain != "auth" {
+		t.Fatalf("dry run = %+v, %v", planned, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "auth", "agents", FileName)); !os.IsNotExist(err) {
+		t.Fatal("dry run não pode gravar")
+	}
+
+	if _, err := Migrate(root, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "auth", "agents", LegacyFile)); !os.IsNotExist(err) {
+		t.Error("instructions.txt deveria ser removido")
+	}
+	m, err := Find(root, "auth")
+	if err != nil || m.Instructions != "cuida de login" || len(m.Upgraded()) != 0 {
+		t.Errorf("migrado = %+v, %v", m, err)
+	}
+	if again, _ := Migrate(root, true); len(again) != 0 {
+		t.Errorf("segunda migração = %+v", again)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	m := &Manifest{Domain: "auth", Paths: []string{"auth"}, Permissions: Permissions{Read: []string{"auth", "shared"}}}
+	policy := m.Policy("/projeto")
+	if strings.Join(policy.Read, ",") != "auth,shared" || strings.Join(policy.Write, ",") != "auth" {
+		t.Errorf("política = %+v", policy)
+	}
+}
@@ -0,0 +1,42 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Migration é um agente convertido (ou a converter) para o manifesto atual.
+type Migration struct {
+	Dir      string
+	Domain   string
+	Upgraded []string
+}
+
+// Migrate converte os agentes do projeto em formatos antigos para agent.yaml na versão atual
+// e remove os instructions.txt incorporados; com dryRun só informa o que seria feito.
+func Migrate(root string, dryRun bool) ([]Migration, error) {
+	var migrations []Migration
+	err := walk(root, func(dir string) error {
+		m, err := Read(root, dir)
+		if err != nil {
+			return err
+		}
+		if len(m.Upgraded()) == 0 {
+			return nil
+		}
+		migrations = append(migrations, Migration{Dir: dir, Domain: m.Domain, Upgraded: m.Upgraded()})
+		if dryRun {
+			return nil
+		}
+
+		if err := Save(m); err != nil {
+			return err
+		}
+		legacy := filepath.Join(dir, LegacyFile)
+		if err := os.Remove(legacy); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+	return migrations, err
+}
@@ -0,0 +1,45 @@
+package markdown
+
+import "strings"
+
+// Fence devolve a cerca que abre um bloco de código na linha (``` ou ~~~, três ou mais), ou "".
+func Fence(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+		return ""
+	}
+
+	fence := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+	if fence[0] == '`' && strings.Contains(trimmed[len(fence):], "`") {
+		// Crases na informação do bloco indicam código inline, não uma cerca
+		return ""
+	}
+	return fence
+}
+
+// Closes indica se a linha fecha o bloco aberto por fence: só o mesmo caractere, pelo menos tantas vezes quanto na abertura.
+func Closes(fence, line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// OutsideFences devolve as linhas do texto que estão fora dos blocos de código cercados,
+// onde diretivas de agente (@tool, @ask, @decision...) valem; um bloco sem fechamento vai até o fim.
+func OutsideFences(text string) []string {
+	var lines []string
+	fence := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if fence != "" {
+			if Closes(fence, line) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = Fence(line); fence != "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,32 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutsideFences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"sem blocos", "a\nb", []string{"a", "b"}},
+		{"crases", "a\n```go\n@tool x\n```\nb", []string{"a", "b"}},
+		{"til", "a\n~~~\n@ask auth: x\n~~~\nb", []string{"a", "b"}},
+		{"cerca maior aninha a menor", "a\n````md\n```\n@tool x\n```\n````\nb", []string{"a", "b"}},
+		{"til não fecha crases", "a\n```\n~~~\n@tool x\n```\nb", []string{"a", "b"}},
+		{"fechamento com texto não fecha", "a\n```\n``` go\n@tool x\n```\nb", []string{"a", "b"}},
+		{"crases inline não abrem bloco", "a ```x``` b\n```x```\nc", []string{"a ```x``` b", "```x```", "c"}},
+		{"sem fechamento vai até o fim", "a\n```\n@tool x", []string{"a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := OutsideFences(test.text)
+			if strings.Join(got, "|") != strings.Join(test.want, "|") {
+				t.Errorf("linhas = %q, esperado %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"plaxo-orchestra/internal/retrieval"
+)
+
+// FileName fica no diretório do agente, ao lado do agent.yaml.
+const FileName = "memory.jsonl"
+
+// Limites da compactação: acima de CompactAfter tarefas, as mais antigas viram resumo e ficam as KeepRecent últimas.
+const (
+	CompactAfter = 40
+	KeepRecent   = 15
+)
+
+type Outcome string
+
+const (
+	Success Outcome = "success"
+	Failure Outcome = "error"
+	// Summary é o resumo periódico das tarefas compactadas
+	Summary Outcome = "summary"
+)
+
+// Entry é uma tarefa lembrada pelo agente, ou o resumo das tarefas antigas quando Outcome é Summary.
+type Entry struct {
+	Task    string    `json:"task"`
+	Summary string    `json:"summary"`
+	Outcome Outcome   `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+	Files   []string  `json:"files,omitempty"`
+	At      time.Time `json:"at"`
+	// Compacted é quantas tarefas o resumo cobre, incluindo as de resumos anteriores
+	Compacted int `json:"compacted,omitempty"`
+}
+
+func (e Entry) String() string {
+	if e.Outcome == Summary {
+		return fmt.Sprintf("Resumo de %d tarefas anteriores (até %s):\n%s", e.Compacted, e.At.Format("2006-01-02"), e.Summary)
+	}
+
+	icon := "✅"
+	if e.Outcome == Failure {
+		icon = "❌"
+	}
+	line := fmt.Sprintf("%s %s %s", e.At.Format("2006-01-02"), icon, e.Task)
+	switch {
+	case e.Outcome == Failure && e.Error != "":
+		line += " → erro: " + e.Error
+	case e.Summary != "":
+		line += " → " + e.Summary
+	}
+	if len(e.Files) > 0 {
+		line += " [arquivos: " + strings.Join(e.Files, ", ") + "]"
+	}
+	return line
+}
+
+// Limite de letras da tarefa lembrada
+const taskLimit = 300
+
+type (
+	inputKey  struct{}
+	actionKey struct{}
+)
+
+// WithInput anexa ao contexto o pedido do usuário que originou as tarefas de uma execução.
+func WithInput(ctx context.Context, input string) context.Context {
+	return context.WithValue(ctx, inputKey{}, input)
+}
+
+// WithAction anexa ao contexto a ação da etapa em execução (análise, implementação, validação...).
+func WithAction(ctx context.Context, action string) context.Context {
+	return context.WithValue(ctx, actionKey{}, action)
+}
+
+// Task é o que a memória guarda como tarefa: o pedido do usuário com a ação da etapa, quando estão no contexto,
+// ou o começo da tarefa recebida. O prompt montado pelo orquestrador não entra inteiro em cada lembrança.
+func Task(ctx context.Context, task string) string {
+	input, _ := ctx.Value(inputKey{}).(string)
+	if strings.TrimSpace(input) == "" {
+		return truncate(task, taskLimit)
+	}
+
+	// A ação vem inteira no fim; um pedido longo é que é cortado
+	action, _ := ctx.Value(actionKey{}).(string)
+	if action = truncate(action, taskLimit/4); action == "" {
+		return truncate(input, taskLimit)
+	}
+	suffix := fmt.Sprintf(" (%s)", action)
+	return truncate(input, taskLimit-len([]rune(suffix))) + suffix
+}
+
+// SummarizeFunc resume as tarefas antigas junto com o resumo anterior (vazio na primeira compactação).
+type SummarizeFunc func(ctx context.Context, previous string, entries []Entry) (string, error)
+
+// Store é a memória de um agente, gravada em JSONL: o resumo periódico (se houver) seguido das tarefas recentes.
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Open lê a memória do diretório do agente; linhas inválidas são ignoradas.
+func Open(dir string) (*Store, error) {
+	store := &Store{path: filepath.Join(dir, FileName)}
+
+	f, err := os.Open(store.path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			store.entries = append(store.entries, entry)
+		}
+	}
+	return store, scanner.Err()
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Add acrescenta uma tarefa ao fim do arquivo.
+func (s *Store) Add(entry Entry) error {
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// Entries devolve uma cópia de tudo o que está gravado, na ordem do arquivo.
+func (s *Store) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries...)
+}
+
+// Summary devolve o resumo periódico mais recente, ou nil antes da primeira compactação.
+func (s *Store) Summary() *Entry {
+	entries := s.Entries()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Outcome == Summary {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// Tasks devolve as tarefas ainda não compactadas, da mais antiga para a mais recente.
+func (s *Store) Tasks() []Entry {
+	var tasks []Entry
+	for _, entry := range s.Entries() {
+		if entry.Outcome != Summary {
+			tasks = append(tasks, entry)
+		}
+	}
+	return tasks
+}
+
+// Relevant escolhe até k tarefas para a nova tarefa: as mais parecidas (BM25 sobre tarefa, resumo e arquivos),
+// completando com as mais recentes. O resultado fica em ordem cronológica.
+func (s *Store) Relevant(task string, k int) []Entry {
+	tasks := s.Tasks()
+	if k <= 0 || len(tasks) == 0 {
+		return nil
+	}
+
+	docs := make([]map[string]int, len(tasks))
+	lengths := make([]int, len(tasks))
+	total := 0
+	for i, entry := range tasks {
+		terms := retrieval.Tokenize(entry.Task + " " + entry.Summary + " " + strings.Join(entry.Files, " "))
+		docs[i] = make(map[string]int)
+		for _, term := range terms {
+			docs[i][term]++
+		}
+		lengths[i] = len(terms)
+		total += len(terms)
+	}
+	avgLength := math.Max(1, float64(total)/float64(len(tasks)))
+
+	query := make(map[string]bool)
+	for _, term := range retrieval.Tokenize(task) {
+		query[term] = true
+	}
+
+	scores := make([]float64, len(tasks))
+	for term := range query {
+		df := 0
+		for _, doc := range docs {
+			if doc[term] > 0 {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(len(tasks))-float64(df)+0.5)/(float64(df)+0.5))
+		for i, doc := range docs {
+			if tf := float64(doc[term]); tf > 0 {
+				norm := 1.2 * (1 - 0.75 + 0.75*float64(lengths[i])/avgLength)
+				scores[i] += idf * tf * 2.2 / (tf + norm)
+			}
+		}
+	}
+
+	// Mais relevantes primeiro; no empate (e sem relevância), as mais recentes
+	order := make([]int, len(tasks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if scores[order[a]] != scores[order[b]] {
+			return scores[order[a]] > scores[order[b]]
+		}
+		return order[a] > order[b]
+	})
+	if len(order) > k {
+		order = order[:k]
+	}
+	sort.Ints(order)
+
+	relevant := make([]Entry, len(order))
+	for i, index := range order {
+		relevant[i] = tasks[index]
+	}
+	return relevant
+}
+
+// NeedsCompaction indica se há tarefas demais fora do resumo.
+func (s *Store) NeedsCompaction() bool {
+	return len(s.Tasks()) > CompactAfter
+}
+
+// Compact junta o resumo anterior e as tarefas antigas num novo resumo e regrava o arquivo
+// só com ele e as KeepRecent tarefas mais recentes.
+func (s *Store) Compact(ctx context.Context, summarize SummarizeFunc) error {
+	all := s.Entries()
+	var tasks []Entry
+	for _, entry := range all {
+		if entry.Outcome != Summary {
+			tasks = append(tasks, entry)
+		}
+	}
+	if len(tasks) <= KeepRecent {
+		return nil
+	}
+	old, recent := tasks[:len(tasks)-KeepRecent], tasks[len(tasks)-KeepRecent:]
+
+	summary := Entry{
+		Task:      "Resumo periódico",
+		Outcome:   Summary,
+		At:        old[len(old)-1].At,
+		Compacted: len(old),
+	}
+	previous := ""
+	for _, entry := range all {
+		if entry.Outcome == Summary {
+			previous = entry.Summary
+			summary.Compacted = len(old) + entry.Compacted
+		}
+	}
+
+	text, err := summarize(ctx, previous, old)
+	if err != nil {
+		return err
+	}
+	summary.Summary = strings.TrimSpace(text)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Tarefas gravadas enquanto o resumo era gerado continuam no fim
+	entries := append([]Entry{summary}, recent...)
+	entries = append(entries, s.entries[min(len(all), len(s.entries)):]...)
+	var b strings.Builder
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.entries = entries
+	return nil
+}
+
+// Digest é o resumo sem modelo: o resumo anterior seguido de uma linha por tarefa compactada,
+// limitado às últimas digestLimit letras.
+func Digest(previous string, entries []Entry) string {
+	var lines []string
+	if previous != "" {
+		lines = append(lines, previous)
+	}
+	for i := range entries {
+		lines = append(lines, "- "+truncate(entries[i].String(), 200))
+	}
+
+	digest := []rune(strings.Join(lines, "\n"))
+	if len(digest) > digestLimit {
+		digest = digest[len(digest)-digestLimit:]
+		if i := strings.IndexRune(string(digest), '\n'); i != -1 {
+			return string(digest)[i+1:]
+		}
+	}
+	return string(digest)
+}
+
+const digestLimit = 4000
+
+// Directivas do quadro compartilhado que valem ser lembradas: decisões, tipos, fatos e respostas.
+var directive = regexp.MustCompile(`^[ \t>*-]*@(decision|type|fact|answer)[ \t]+`)
+
+// Summarize resume a resposta de um agente em uma linha: as decisões publicadas e o começo do texto,
+// sem blocos de código nem diffs.
+func Summarize(output string) string {
+	var decisions, prose []string
+	inFence, inDiff := false, false
+
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inFence = !inFence
+			continue
+		case inFence:
+			continue
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			inDiff = true
+			continue
+		case inDiff && (line == "" || strings.ContainsRune(" +-@\\", rune(line[0]))):
+			continue
+		}
+		inDiff = false
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "FILE:") || strings.HasPrefix(trimmed, "ARQUIVO:") {
+			continue
+		}
+		if directive.MatchString(line) {
+			decisions = append(decisions, strings.TrimLeft(trimmed, ">*- \t@"))
+			continue
+		}
+		prose = append(prose, strings.TrimLeft(trimmed, "# "))
+	}
+
+	summary := truncate(strings.Join(prose, " "), summaryLimit)
+	if len(decisions) > 0 {
+		summary = strings.TrimSpace(truncate(strings.Join(decisions, "; "), summaryLimit) + " | " + summary)
+		summary = strings.TrimSuffix(summary, " |")
+	}
+	return summary
+}
+
+const summaryLimit = 400
+
+func truncate(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > limit {
+		return string(runes[:limit-3]) + "..."
+	}
+	return s
+}
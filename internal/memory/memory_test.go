@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenAndAdd(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(Entry{Task: "criar login", Outcome: Success, Summary: "feito"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Linha corrompida no meio do arquivo é ignorada
+	f, _ := os.OpenFile(store.Path(), os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("{quebrado\n")
+	f.Close()
+	store.Add(Entry{Task: "criar logout", Outcome: Failure, Error: "timeout"})
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := reopened.Entries()
+	if len(entries) != 2 || entries[1].Error != "timeout" || entries[0].At.IsZero() {
+		t.Errorf("entradas = %+v", entries)
+	}
+}
+
+func TestRelevant(t *testing.T) {
+	store, _ := Open(t.TempDir())
+	for _, task := range []string{"criar tela de login", "ajustar preço do produto", "listar pedidos", "corrigir token do login", "exportar relatório"} {
+		store.Add(Entry{Task: task, Outcome: Success})
+	}
+
+	tests := []struct {
+		task string
+		k    int
+		want string
+	}{
+		{"login expira", 2, "criar tela de login|corrigir token do login"},
+		{"preço", 1, "ajustar preço do produto"},
+		{"nada a ver", 2, "corrigir token do login|exportar relatório"},
+		{"login", 0, ""},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, entry := range store.Relevant(test.task, test.k) {
+			got = append(got, entry.Task)
+		}
+		if strings.Join(got, "|") != test.want {
+			t.Errorf("Relevant(%q, %d) = %v, esperado %s", test.task, test.k, got, test.want)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	store, _ := Open(t.TempDir())
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= CompactAfter; i++ {
+		store.Add(Entry{Task: fmt.Sprintf("tarefa %d", i), Outcome: Success, At: base.Add(time.Duration(i) * time.Hour)})
+	}
+	if !store.NeedsCompaction() {
+		t.Fatal("deveria precisar compactar")
+	}
+
+	var summarized int
+	err := store.Compact(context.Background(), func(ctx context.Context, previous string, entries []Entry) (string, error) {
+		summarized = len(entries)
+		return "  resumo  ", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := CompactAfter + 1 - KeepRecent
+	summary := store.Summary()
+	if summarized != old || summary == nil || summary.Summary != "resumo" || summary.Compacted != old {
+		t.Fatalf("resumo = %+v (%d tarefas resumidas)", summary, summarized)
+	}
+	if tasks := store.Tasks(); len(tasks) != KeepRecent || tasks[0].Task != fmt.Sprintf("tarefa %d", old) {
+		t.Errorf("tarefas mantidas = %d", len(tasks))
+	}
+
+	reopened, _ := Open(strings.TrimSuffix(store.Path(), FileName))
+	if len(reopened.Entries()) != KeepRecent+1 {
+		t.Errorf("arquivo regravado com %d entradas", len(reopened.Entries()))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"texto simples", "# Feito\nCriei o endpoint.", "Feito Criei o endpoint."},
+		{"sem código nem diff", "Pronto:\n```go\nfunc x() {}\n```\n--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-a\n+b\nFim.", "Pronto: Fim."},
+		{"decisões primeiro", "Usei JWT.\n@decision auth: jwt\n@question x: y", "decision auth: jwt | Usei JWT. @question x: y"},
+		{"vazio", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Summarize(test.output); got != test.want {
+				t.Errorf("got %q, esperado %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTask(t *testing.T) {
+	long := strings.Repeat("palavra ", 100)
+
+	tests := []struct {
+		name   string
+		input  string
+		action string
+		task   string
+		want   string
+	}{
+		{"pedido do usuário no contexto", "adicionar login", "", "Domain: auth\nInstructions: ...\nTask: adicionar login", "adicionar login"},
+		{"pedido com a ação da etapa", "adicionar login", "implementation", "Task: implementar", "adicionar login (implementation)"},
+		{"pedido longo mantém a ação", long, "analysis", "x", strings.TrimSpace(long)[:taskLimit-len(" (analysis)")-3] + "... (analysis)"},
+		{"sem pedido usa a tarefa", "", "analysis", "revisar   token\n", "revisar token"},
+		{"tarefa longa é cortada", "", "", long, strings.TrimSpace(long)[:taskLimit-3] + "..."},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.input != "" {
+				ctx = WithInput(ctx, test.input)
+			}
+			if test.action != "" {
+				ctx = WithAction(ctx, test.action)
+			}
+			if got := Task(ctx, test.task); got != test.want {
+				t.Errorf("got %q, esperado %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDigest(t *testing.T) {
+	entries := []Entry{{Task: "a", Outcome: Success, Summary: "feito"}, {Task: "b", Outcome: Failure, Error: "falhou"}}
+	digest := Digest("anterior", entries)
+	if !strings.HasPrefix(digest, "anterior\n- ") || !strings.Contains(digest, "b → erro: falhou") {
+		t.Errorf("digest = %q", digest)
+	}
+	if long := Digest(strings.Repeat("x\n", digestLimit), entries); len([]rune(long)) > digestLimit {
+		t.Errorf("digest passou do limite: %d", len([]rune(long)))
+	}
+}
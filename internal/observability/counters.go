@@ -0,0 +1,89 @@
+package observability
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// MetricsDir é onde os contadores persistidos do projeto ficam.
+func MetricsDir(workingDir string) string {
+	return filepath.Join(workingDir, ".plaxo", "metrics")
+}
+
+// Counters guarda contadores por nome (agente, chamada...) e, depois de Persist, os grava
+// em .plaxo/metrics/<file> a cada alteração. merge soma dois valores de C.
+type Counters[C any] struct {
+	file   string
+	merge  func(a, b C) C
+	values map[string]C
+	path   string
+	mutex  sync.Mutex
+}
+
+func NewCounters[C any](file string, merge func(a, b C) C) *Counters[C] {
+	return &Counters[C]{file: file, merge: merge, values: make(map[string]C)}
+}
+
+// Path devolve onde os contadores do projeto são salvos.
+func (c *Counters[C]) Path(workingDir string) string {
+	return filepath.Join(MetricsDir(workingDir), c.file)
+}
+
+// Persist passa a acumular os contadores no arquivo do projeto, somando o que já estava salvo.
+func (c *Counters[C]) Persist(workingDir string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.path = c.Path(workingDir)
+	for name, saved := range c.Load(workingDir) {
+		c.values[name] = c.merge(c.values[name], saved)
+	}
+}
+
+// Load lê os contadores salvos do projeto.
+func (c *Counters[C]) Load(workingDir string) map[string]C {
+	loaded := make(map[string]C)
+	data, err := os.ReadFile(c.Path(workingDir))
+	if err != nil {
+		return loaded
+	}
+	json.Unmarshal(data, &loaded)
+	return loaded
+}
+
+// Snapshot devolve uma cópia dos contadores atuais.
+func (c *Counters[C]) Snapshot() map[string]C {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	copied := make(map[string]C, len(c.values))
+	for name, value := range c.values {
+		copied[name] = value
+	}
+	return copied
+}
+
+// Record aplica update aos contadores de name e grava o arquivo, se persistido.
+func (c *Counters[C]) Record(name string, update func(*C)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	value := c.values[name]
+	update(&value)
+	c.values[name] = value
+
+	if c.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return
+	}
+	if data, err := json.MarshalIndent(c.values, "", "  "); err == nil {
+		tmp := c.path + ".tmp"
+		if os.WriteFile(tmp, data, 0644) == nil {
+			os.Rename(tmp, c.path)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package observability
+
+import "testing"
+
+type hits struct {
+	Hits int `json:"hits"`
+}
+
+func addHits(a, b hits) hits {
+	return hits{Hits: a.Hits + b.Hits}
+}
+
+func TestCountersPersist(t *testing.T) {
+	root := t.TempDir()
+
+	// Sem Persist os contadores ficam só em memória
+	counters := NewCounters("hits.json", addHits)
+	counters.Record("a", func(h *hits) { h.Hits++ })
+	if saved := counters.Load(root); len(saved) != 0 {
+		t.Fatalf("salvo sem Persist: %v", saved)
+	}
+
+	counters.Persist(root)
+	counters.Record("a", func(h *hits) { h.Hits++ })
+
+	// Uma nova execução soma o que já estava salvo
+	next := NewCounters("hits.json", addHits)
+	next.Record("b", func(h *hits) { h.Hits++ })
+	next.Persist(root)
+	next.Record("a", func(h *hits) { h.Hits++ })
+
+	want := map[string]int{"a": 3, "b": 1}
+	for name, n := range want {
+		if got := next.Snapshot()[name].Hits; got != n {
+			t.Errorf("%s em memória = %d, esperado %d", name, got, n)
+		}
+		if got := next.Load(root)[name].Hits; got != n {
+			t.Errorf("%s salvo = %d, esperado %d", name, got, n)
+		}
+	}
+}
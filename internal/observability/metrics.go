@@ -22,10 +22,10 @@ type Span struct {
 }
 
 type Metrics struct {
-	spans       []Span            `json:"spans"`
-	counters    map[string]int64  `json:"counters"`
-	gauges      map[string]float64 `json:"gauges"`
-	histograms  map[string][]float64 `json:"histograms"`
+	spans       []Span
+	counters    map[string]int64
+	gauges      map[string]float64
+	histograms  map[string][]float64
 	mutex       sync.RWMutex
 }
 
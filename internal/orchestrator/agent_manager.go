@@ -1,51 +1,61 @@
 package orchestrator
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/blackboard"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/patch"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/usage"
 	"strings"
+	"sync"
+	"time"
 	"gopkg.in/yaml.v2"
 )
 
-type AgentConfig struct {
-	Name             string            `yaml:"name"`
-	Domain           string            `yaml:"domain"`
-	Complexity       int               `yaml:"complexity"`
-	FilesCount       int               `yaml:"files_count"`
-	TechStack        []string          `yaml:"tech_stack"`
-	Responsibilities []string          `yaml:"responsibilities"`
-	Context          AgentContext      `yaml:"context"`
-	Commands         map[string]string `yaml:"commands"`
-}
-
-type AgentContext struct {
-	Path  string `yaml:"path"`
-	Files int    `yaml:"files"`
-}
-
 type OrchestraConfig struct {
 	AppName      string              `yaml:"app_name"`
 	Complexity   string              `yaml:"complexity"`
 	TechStack    []string            `yaml:"tech_stack"`
 	TotalDomains int                 `yaml:"total_domains"`
 	Agents       map[string][]string `yaml:"agents"`
-	Orchestration map[string]string  `yaml:"orchestration"`
+	Orchestration map[string]OrchestrationWorkflow `yaml:"orchestration"`
 }
 
 type AgentManager struct {
 	rootPath        string
 	orchestraConfig *OrchestraConfig
-	agents          map[string]*AgentConfig
+	agents          map[string]*manifest.Manifest
+	reviewer        patch.Reviewer
+	gate            approval.Gate
+	// Serializa saída e revisão quando etapas de orquestração rodam em paralelo
+	reviewMu        sync.Mutex
 }
 
 func NewAgentManager(rootPath string) *AgentManager {
 	return &AgentManager{
 		rootPath: rootPath,
-		agents:   make(map[string]*AgentConfig),
+		agents:   make(map[string]*manifest.Manifest),
+		reviewer: defaultReviewer(),
+		gate:     approval.Default(rootPath),
 	}
 }
 
+// SetReviewer troca quem aprova as alterações propostas pelos agentes.
+func (am *AgentManager) SetReviewer(reviewer patch.Reviewer) {
+	am.reviewer = reviewer
+}
+
+// SetGate troca quem decide as etapas de aprovação dos workflows.
+func (am *AgentManager) SetGate(gate approval.Gate) {
+	am.gate = gate
+}
+
 func (am *AgentManager) LoadConfiguration() error {
 	configPath := filepath.Join(am.rootPath, "orchestra.yaml")
 	
@@ -70,26 +80,30 @@ func (am *AgentManager) LoadConfiguration() error {
 func (am *AgentManager) loadAgentConfigs() error {
 	for domain, paths := range am.orchestraConfig.Agents {
 		for _, agentPath := range paths {
-			configFile := filepath.Join(agentPath, "agent.yaml")
-			
-			if _, err := os.Stat(configFile); os.IsNotExist(err) {
-				continue
+			if !filepath.IsAbs(agentPath) {
+				agentPath = filepath.Join(am.rootPath, agentPath)
 			}
 			
-			data, err := os.ReadFile(configFile)
+			config, err := manifest.Read(am.rootPath, agentPath)
 			if err != nil {
 				continue
 			}
 			
-			config := &AgentConfig{}
-			if err := yaml.Unmarshal(data, config); err != nil {
-				continue
-			}
-			
 			am.agents[domain] = config
 		}
 	}
 	
+	// Agentes criados pelo chat também ficam disponíveis, mesmo fora do orchestra.yaml
+	manifests, err := manifest.Discover(am.rootPath)
+	if err != nil {
+		return fmt.Errorf("erro lendo agentes: %v", err)
+	}
+	for _, config := range manifests {
+		if am.agents[config.Domain] == nil {
+			am.agents[config.Domain] = config
+		}
+	}
+	
 	return nil
 }
 
@@ -109,8 +123,17 @@ func (am *AgentManager) ListAgents() {
 	
 	for domain, config := range am.agents {
 		fmt.Printf("🤖 %s (%s)\n", config.Name, domain)
-		fmt.Printf("   📁 Caminho: %s\n", config.Context.Path)
-		fmt.Printf("   📄 Arquivos: %d\n", config.Context.Files)
+		fmt.Printf("   📁 Caminho: %s\n", strings.Join(config.Paths, ", "))
+		fmt.Printf("   📄 Arquivos: %d\n", config.FilesCount)
+		if config.Model != "" {
+			fmt.Printf("   🧠 Modelo: %s\n", config.Model)
+		}
+		if len(config.Tools) > 0 {
+			fmt.Printf("   🔧 Ferramentas: %s\n", strings.Join(config.Tools, ", "))
+		}
+		if policy := config.Policy(am.rootPath); len(policy.Write) > 0 {
+			fmt.Printf("   ✍️  Escrita: %s\n", strings.Join(policy.Write, ", "))
+		}
 		fmt.Printf("   🎯 Responsabilidades: %d\n", len(config.Responsibilities))
 		
 		fmt.Printf("   💻 Comandos disponíveis:\n")
@@ -122,62 +145,81 @@ func (am *AgentManager) ListAgents() {
 }
 
 func (am *AgentManager) ExecuteAgentCommand(domain, command, input string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	
+	_, err := am.runAgentCommand(usage.WithStep(ctx, domain+"."+command), domain, command, input, true)
+	return err
+}
+
+// runAgentCommand executa o comando e revisa as alterações propostas; sem stream a resposta só é exibida no fim.
+func (am *AgentManager) runAgentCommand(ctx context.Context, domain, command, input string, stream bool) (string, error) {
 	agent, exists := am.agents[domain]
 	if !exists {
-		return fmt.Errorf("agente '%s' não encontrado", domain)
+		return "", fmt.Errorf("agente '%s' não encontrado", domain)
 	}
 	
 	cmdDesc, exists := agent.Commands[command]
 	if !exists {
-		return fmt.Errorf("comando '%s' não disponível para agente '%s'", command, domain)
+		return "", fmt.Errorf("comando '%s' não disponível para agente '%s'", command, domain)
 	}
 	
-	fmt.Printf("🤖 Executando: %s.%s\n", domain, command)
-	fmt.Printf("📋 Descrição: %s\n", cmdDesc)
-	fmt.Printf("🎯 Contexto: %s (%d arquivos)\n", agent.Context.Path, agent.Context.Files)
-	fmt.Println(strings.Repeat("─", 50))
-	
 	// Construir prompt contextualizado
-	contextualPrompt := am.buildContextualPrompt(agent, command, input)
+	ctx = backend.WithModel(ctx, agent.Model)
+	board := blackboard.From(ctx)
+	contextualPrompt := am.buildContextualPrompt(agent, command, input, board)
+	
+	var output string
+	var err error
+	pending := pendingWrite(ctx, domain)
+	switch {
+	case pending != nil:
+		// Retomada de uma escrita escalada: segue com a resposta aprovada, sem chamar o agente de novo
+		output, stream = pending.Output, false
+	case stream:
+		am.printCommandHeader(agent, domain, command, cmdDesc)
+		output, err = streamResult(ctx, agent.Name, contextualPrompt)
+		fmt.Println()
+	default:
+		output, err = completeResult(ctx, agent.Name, contextualPrompt)
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro executando %s.%s: %v", domain, command, err)
+	}
+	if board != nil && pending == nil {
+		board.Record(domain, output)
+	}
 	
-	// Aqui integraria com o enhanced orchestrator para executar
-	fmt.Printf("🧠 Prompt contextualizado:\n%s\n", contextualPrompt)
+	am.reviewMu.Lock()
+	defer am.reviewMu.Unlock()
 	
-	return nil
+	if !stream {
+		am.printCommandHeader(agent, domain, command, cmdDesc)
+		fmt.Println(output)
+	}
+	return output, reviewAndApply(ctx, am.rootPath, domain, output, am.reviewer)
 }
 
-func (am *AgentManager) buildContextualPrompt(agent *AgentConfig, command, input string) string {
-	prompt := fmt.Sprintf(`Você é um agente especializado no domínio '%s'.
-
-CONTEXTO DO AGENTE:
-- Nome: %s
-- Domínio: %s
-- Caminho: %s
-- Arquivos: %d
-- Tech Stack: %v
-- Complexidade: %d
-
-RESPONSABILIDADES:
-%s
-
-COMANDO SOLICITADO: %s
-DESCRIÇÃO: %s
-
-ENTRADA DO USUÁRIO: %s
-
-Por favor, execute a tarefa considerando:
-1. O contexto específico do domínio %s
-2. Os arquivos localizados em %s
-3. As tecnologias utilizadas: %v
-4. As responsabilidades do agente
+func (am *AgentManager) printCommandHeader(agent *manifest.Manifest, domain, command, cmdDesc string) {
+	fmt.Printf("🤖 Executando: %s.%s\n", domain, command)
+	fmt.Printf("📋 Descrição: %s\n", cmdDesc)
+	fmt.Printf("🎯 Contexto: %s (%d arquivos)\n", strings.Join(agent.Paths, ", "), agent.FilesCount)
+	fmt.Println(strings.Repeat("─", 50))
+}
 
-Forneça uma resposta detalhada e específica para este domínio.`,
-		agent.Domain,
-		agent.Name, agent.Domain, agent.Context.Path, agent.Context.Files, agent.TechStack, agent.Complexity,
-		strings.Join(agent.Responsibilities, "\n- "),
-		command, agent.Commands[command],
-		input,
-		agent.Domain, agent.Context.Path, agent.TechStack)
+func (am *AgentManager) buildContextualPrompt(agent *manifest.Manifest, command, input string, board *blackboard.Board) string {
+	data := map[string]interface{}{
+		"Agent":       agent,
+		"Command":     command,
+		"Description": agent.Commands[command],
+		"Input":       input,
+		"Shared":      board != nil,
+		"Blackboard":  "",
+	}
+	if board != nil {
+		data["Blackboard"] = board.Summary()
+	}
+	prompt := prompts.Render("agent_command", data)
 	
 	return prompt
 }
@@ -203,46 +245,3 @@ func (am *AgentManager) GetDomains() []string {
 	}
 	return domains
 }
-
-func (am *AgentManager) ExecuteOrchestrationCommand(command string) error {
-	if am.orchestraConfig == nil {
-		return fmt.Errorf("configuração não carregada")
-	}
-	
-	cmdDesc, exists := am.orchestraConfig.Orchestration[command]
-	if !exists {
-		return fmt.Errorf("comando de orquestração '%s' não encontrado", command)
-	}
-	
-	fmt.Printf("🎼 Executando orquestração: %s\n", command)
-	fmt.Printf("📋 Descrição: %s\n", cmdDesc)
-	fmt.Println(strings.Repeat("─", 50))
-	
-	// Executar comando em todos os agentes relevantes
-	switch command {
-	case "analyze_all":
-		return am.executeOnAllAgents("analyze", "Analisar código completo")
-	case "refactor_all":
-		return am.executeOnAllAgents("refactor", "Refatorar seguindo melhores práticas")
-	case "test_all":
-		return am.executeOnAllAgents("test", "Executar testes completos")
-	case "deploy_all":
-		return am.executeOnAllAgents("document", "Preparar documentação para deploy")
-	default:
-		return fmt.Errorf("comando de orquestração não implementado: %s", command)
-	}
-}
-
-func (am *AgentManager) executeOnAllAgents(command, description string) error {
-	fmt.Printf("🚀 Executando '%s' em todos os agentes...\n\n", command)
-	
-	for domain := range am.agents {
-		fmt.Printf("🤖 Processando domínio: %s\n", domain)
-		if err := am.ExecuteAgentCommand(domain, command, description); err != nil {
-			fmt.Printf("⚠️  Erro no domínio %s: %v\n", domain, err)
-		}
-		fmt.Println()
-	}
-	
-	return nil
-}
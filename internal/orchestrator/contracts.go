@@ -0,0 +1,157 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/contracts"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/structured"
+)
+
+// contractPrefix marca, na aprovação das análises, os itens com o YAML do contrato de cada domínio.
+const contractPrefix = "contrato:"
+
+// extractContract transforma a análise de um domínio no seu contrato em .plaxo/contracts,
+// somando ao contrato já registrado em vez de substituí-lo.
+func (o *Orchestrator) extractContract(ctx context.Context, input, domain, analysis string) error {
+	var others []string
+	for _, other := range sortedKeys(o.agents) {
+		if other != domain {
+			others = append(others, other)
+		}
+	}
+
+	contract := contracts.Contract{Domain: domain}
+	check := func() []string {
+		var problems []string
+		known := make(map[string]bool)
+		for _, other := range others {
+			known[other] = true
+		}
+		for _, kind := range contracts.Kinds {
+			for i, item := range contract.Requires.Items(kind) {
+				if item.From != "" && !known[item.From] {
+					problems = append(problems, fmt.Sprintf("$.requires.%s[%d].from: %q não está entre %s", kind, i, item.From, strings.Join(others, ", ")))
+				}
+			}
+		}
+		return problems
+	}
+
+	complete := func(ctx context.Context, prompt string) (string, error) {
+		return completeResult(ctx, "contract_extractor", prompt)
+	}
+	request := structured.Request{
+		Name: "contract_extraction",
+		Prompt: prompts.Render("contract_extraction", map[string]interface{}{
+			"Input":    input,
+			"Domain":   domain,
+			"Analysis": analysis,
+			"Domains":  others,
+		}),
+		Check: check,
+	}
+	if err := structured.Generate(ctx, complete, request, &contract); err != nil {
+		var invalid *structured.ValidationError
+		if errors.As(err, &invalid) {
+			structured.Fallback("contract_extraction", err)
+		}
+		return fmt.Errorf("erro extraindo contrato de %s: %v", domain, err)
+	}
+
+	merged, err := contracts.Load(o.workingDir, domain)
+	switch {
+	case err == nil:
+		merged.Merge(&contract)
+	case os.IsNotExist(err):
+		merged = &contract
+	default:
+		// Um contrato ilegível pode ter edições manuais; não sobrescreve
+		return fmt.Errorf("erro lendo contrato de %s: %v", domain, err)
+	}
+
+	if err := contracts.Save(o.workingDir, merged); err != nil {
+		return fmt.Errorf("erro gravando contrato de %s: %v", domain, err)
+	}
+	fmt.Printf("📜 Contrato de %s: %s\n", domain, contracts.Path(o.workingDir, domain))
+	return nil
+}
+
+// contractItems devolve o YAML do contrato de cada domínio como item editável da aprovação.
+func (o *Orchestrator) contractItems(domains []string) []*approval.Item {
+	var items []*approval.Item
+	for _, domain := range domains {
+		if contract, err := contracts.Load(o.workingDir, domain); err == nil {
+			items = append(items, &approval.Item{Key: contractPrefix + domain, Content: contract.YAML()})
+		}
+	}
+	return items
+}
+
+// saveContractEdits grava os contratos editados na aprovação; rejeitados ou pulados ficam como estavam.
+func (o *Orchestrator) saveContractEdits(req *approval.Request) error {
+	for _, item := range req.Items {
+		domain, ok := strings.CutPrefix(item.Key, contractPrefix)
+		if !ok || item.Decision != approval.Approved {
+			continue
+		}
+
+		edited, err := contracts.Parse(domain, item.Content)
+		if err != nil {
+			return err
+		}
+		if current, err := contracts.Load(o.workingDir, domain); err == nil && current.YAML() == edited.YAML() {
+			continue
+		}
+		if err := contracts.Save(o.workingDir, edited); err != nil {
+			return fmt.Errorf("erro gravando contrato de %s: %v", domain, err)
+		}
+		fmt.Printf("📜 Contrato de %s atualizado na aprovação\n", domain)
+	}
+	return nil
+}
+
+// analysisKeys são os domínios com a decisão dada, sem os itens de contrato.
+func analysisKeys(req *approval.Request, decision approval.Decision) []string {
+	var keys []string
+	for _, key := range req.Keys(decision) {
+		if !strings.HasPrefix(key, contractPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// checkContracts mostra os requisitos dos domínios indicados que nenhum contrato fornece.
+func (o *Orchestrator) checkContracts(domains []string) []contracts.Problem {
+	registry, err := contracts.LoadAll(o.workingDir)
+	if err != nil {
+		fmt.Printf("⚠️  Erro lendo contratos: %v\n", err)
+		return nil
+	}
+
+	involved := make(map[string]bool)
+	for _, domain := range domains {
+		involved[domain] = true
+	}
+
+	var problems []contracts.Problem
+	for _, problem := range contracts.Check(registry) {
+		if involved[problem.Domain] {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		fmt.Println("⚠️  Contratos com requisitos sem fornecedor:")
+		for _, problem := range problems {
+			fmt.Printf("  • %s\n", problem)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/contracts"
+	"plaxo-orchestra/internal/workflow"
+)
+
+// editingGate aprova tudo depois de aplicar as edições por chave.
+type editingGate struct {
+	edits map[string]string
+}
+
+func (g editingGate) Decide(ctx context.Context, req *approval.Request) (*approval.Request, error) {
+	for _, item := range req.Items {
+		if content, ok := g.edits[item.Key]; ok {
+			item.Content = content
+		}
+	}
+	req.Set(approval.Approved)
+	return req, nil
+}
+
+func TestExtractContractMerges(t *testing.T) {
+	useBackend(t, &scripted{responses: []string{`{"provides":{"operations":[{"name":"ValidateToken"}],"events":[],"types":[]},"requires":{"operations":[],"events":[],"types":[]}}`}})
+	o := New(t.TempDir())
+	manual := &contracts.Contract{Domain: "auth", Provides: contracts.Surface{Operations: []contracts.Item{{Name: "Login", Description: "à mão"}}}}
+	if err := contracts.Save(o.workingDir, manual); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := o.extractContract(context.Background(), "pedido", "auth", "análise"); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := contracts.Load(o.workingDir, "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.Provides.Provides("operations", "Login") || !saved.Provides.Provides("operations", "ValidateToken") {
+		t.Errorf("contrato gravado:\n%s", saved.YAML())
+	}
+}
+
+func TestApproveAnalysesSavesContractEdits(t *testing.T) {
+	o := New(t.TempDir())
+	for _, domain := range []string{"auth", "products"} {
+		if err := contracts.Save(o.workingDir, &contracts.Contract{Domain: domain}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o.SetGate(editingGate{edits: map[string]string{
+		contractPrefix + "auth": "provides:\n  operations:\n  - name: ValidateToken\n",
+	}})
+
+	wf := workflow.New("coordination", &workflow.Step{ID: "analysis.auth", Agent: "auth"}, &workflow.Step{ID: "analysis.products", Agent: "products"})
+	run, err := workflow.NewRun(o.workingDir, workflow.RunKindCoordination, "pedido", wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := &workflow.Step{ID: approvalStep, DependsOn: []string{"analysis.auth", "analysis.products"}}
+	inputs := map[string]*workflow.Result{
+		"analysis.auth":     {StepID: "analysis.auth", Agent: "auth", Status: workflow.StatusSucceeded, Output: "análise auth"},
+		"analysis.products": {StepID: "analysis.products", Agent: "products", Status: workflow.StatusSucceeded, Output: "análise products"},
+	}
+
+	output, err := o.approveAnalyses(context.Background(), run, step, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := approval.Decode(output)
+	if keys := analysisKeys(req, approval.Approved); strings.Join(keys, ",") != "auth,products" {
+		t.Errorf("análises aprovadas = %v", keys)
+	}
+	saved, _ := contracts.Load(o.workingDir, "auth")
+	if !saved.Provides.Provides("operations", "ValidateToken") {
+		t.Errorf("edição do contrato não foi gravada:\n%s", saved.YAML())
+	}
+
+	o.SetGate(editingGate{edits: map[string]string{contractPrefix + "auth": "provides: [quebrado"}})
+	if _, err := o.approveAnalyses(context.Background(), run, step, inputs); err == nil {
+		t.Error("contrato editado inválido deveria falhar")
+	}
+}
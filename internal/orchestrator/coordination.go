@@ -0,0 +1,270 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/blackboard"
+	"plaxo-orchestra/internal/consult"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/memory"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/sandbox"
+	"plaxo-orchestra/internal/usage"
+	"plaxo-orchestra/internal/workflow"
+)
+
+const approvalStep = "approval"
+
+// coordinationWorkflow monta as fases: análise de cada domínio → aprovação humana → implementação → validação,
+// seguidas do resumo final quando o projeto tem supervisor.
+func coordinationWorkflow(domains []string, supervised bool) *workflow.Workflow {
+	wf := workflow.New("coordination")
+
+	var analyses, implementations []string
+	for _, domain := range domains {
+		id := "analysis." + domain
+		wf.Add(&workflow.Step{ID: id, Agent: domain, Action: "analysis", Context: map[string]interface{}{"phase": "analysis"}})
+		analyses = append(analyses, id)
+	}
+
+	wf.Add(&workflow.Step{ID: approvalStep, Agent: workflow.HumanAgent, Action: "approval", DependsOn: analyses, Human: true, Context: map[string]interface{}{"phase": "approval"}})
+
+	for _, domain := range domains {
+		id := "implementation." + domain
+		wf.Add(&workflow.Step{ID: id, Agent: domain, Action: "implementation", DependsOn: []string{approvalStep}, Context: map[string]interface{}{"phase": "implementation"}})
+		implementations = append(implementations, id)
+	}
+
+	validator := "integration_validator"
+	if supervised {
+		validator = manifest.OrchestratorDomain
+	}
+	wf.Add(&workflow.Step{ID: "validation", Agent: validator, Action: "validation", DependsOn: implementations, Context: map[string]interface{}{"phase": "validation"}})
+
+	if supervised {
+		wf.Add(&workflow.Step{ID: "summary", Agent: manifest.OrchestratorDomain, Action: "summary", DependsOn: append(implementations, "validation"), Context: map[string]interface{}{"phase": "summary"}})
+	}
+	return wf
+}
+
+func (o *Orchestrator) coordinateAgents(input string, domains []string) error {
+	fmt.Println("🔗 Coordenando múltiplos agentes...")
+
+	var loaded []string
+	for _, domain := range domains {
+		if _, exists := o.agents[domain]; exists {
+			loaded = append(loaded, domain)
+		}
+	}
+
+	if len(loaded) == 0 {
+		return fmt.Errorf("nenhum agente carregado para coordenar")
+	}
+
+	wf := coordinationWorkflow(loaded, o.loadSupervisor() != nil)
+	run, err := workflow.NewRun(o.workingDir, workflow.RunKindCoordination, input, wf)
+	if err != nil {
+		return fmt.Errorf("erro criando execução: %v", err)
+	}
+	return o.runCoordination(context.Background(), wf, run)
+}
+
+// ResumeCoordination retoma uma coordenação, por exemplo depois de `orchestra approve`.
+func (o *Orchestrator) ResumeCoordination(ctx context.Context, run *workflow.Run) error {
+	if run.Kind != workflow.RunKindCoordination {
+		return fmt.Errorf("execução %s é do tipo %s, não coordination", run.ID, run.Kind)
+	}
+
+	wf := run.Rebuild()
+	var domains []string
+	for _, step := range wf.Steps {
+		if phaseOf(step) == "analysis" {
+			domains = append(domains, step.Agent)
+		}
+	}
+	o.loadAgents(domains)
+
+	return o.runCoordination(ctx, wf, run)
+}
+
+func (o *Orchestrator) runCoordination(ctx context.Context, wf *workflow.Workflow, run *workflow.Run) error {
+	cfg := workflow.LoadConfig(o.workingDir)
+	engine := workflow.NewEngine(cfg)
+	// Cada rodada de correção valida e reimplementa de novo
+	if step := wf.Step("validation"); step != nil && engine.StepTimeout > 0 {
+		step.Timeout = engine.StepTimeout * time.Duration(2*cfg.ValidationRetries+1)
+	}
+	// Um domínio rejeitado não impede a implementação dos demais
+	engine.ContinueOnError = true
+	engine.OnStepStart = func(step *workflow.Step) {
+		if phaseOf(step) == "validation" {
+			fmt.Println("🔍 Validando integração...")
+		}
+	}
+	engine.OnStepDone = func(step *workflow.Step, result *workflow.Result) {
+		switch result.Status {
+		case workflow.StatusFailed:
+			fmt.Printf("❌ Erro em %s: %s\n", step.ID, result.Error)
+		case workflow.StatusWaiting:
+			fmt.Printf("⏸️  %s: %s\n", step.ID, result.Error)
+		}
+	}
+	run.Track(engine)
+	// Decisões publicadas na análise chegam à implementação e às correções dos outros domínios
+	ctx = blackboard.With(ctx, run.Board())
+	ctx = memory.WithInput(ctx, run.Input)
+	ctx = usage.WithRun(ctx, run.ID)
+	ctx = consult.With(ctx, o.router(run))
+	ctx = sandbox.With(ctx, escalation(cfg, o.gate, o.workingDir, run))
+
+	_, err := engine.Run(ctx, wf, func(ctx context.Context, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+		return o.coordinationStep(memory.WithAction(ctx, step.Action), run, step, inputs)
+	}, run.Previous())
+
+	if finishErr := run.Finish(err); finishErr != nil {
+		fmt.Printf("⚠️  Erro salvando execução %s: %v\n", run.ID, finishErr)
+	}
+	if err != nil && run.Status != workflow.StatusWaiting {
+		fmt.Printf("💾 Retome com: orchestra runs resume %s\n", run.ID)
+	}
+	return err
+}
+
+func (o *Orchestrator) coordinationStep(ctx context.Context, run *workflow.Run, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+	switch phaseOf(step) {
+	case "analysis":
+		agent, exists := o.agents[step.Agent]
+		if !exists {
+			return "", fmt.Errorf("agente %s não carregado", step.Agent)
+		}
+
+		analysisPrompt := prompts.Render("coordination_analysis", map[string]interface{}{
+			"Domain": step.Agent,
+			"Input":  run.Input,
+		})
+		result, err := agent.ExecuteContext(ctx, analysisPrompt)
+		if err != nil {
+			return "", fmt.Errorf("erro na análise do %s: %v", step.Agent, err)
+		}
+		fmt.Printf("📋 %s analisou suas responsabilidades\n", step.Agent)
+
+		// O contrato estruturado entra nos próximos prompts do agente; sem ele, segue só com a análise
+		if err := o.extractContract(ctx, run.Input, step.Agent, result); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		return result, nil
+
+	case "approval":
+		return o.approveAnalyses(ctx, run, step, inputs)
+
+	case "implementation":
+		return o.implement(ctx, run.Input, step, inputs)
+
+	case "validation":
+		return o.validateWithRetries(ctx, run.Input, inputs)
+
+	case "summary":
+		return o.summarize(ctx, run.Input, step, inputs)
+	}
+	return "", fmt.Errorf("fase desconhecida em %s", step.ID)
+}
+
+// approveAnalyses pausa antes da implementação para revisar, editar, aprovar, rejeitar ou pular cada análise.
+func (o *Orchestrator) approveAnalyses(ctx context.Context, run *workflow.Run, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+	req := &approval.Request{
+		Run:   run.ID,
+		Step:  step.ID,
+		Title: "Aprovação das análises antes da implementação",
+	}
+	var domains []string
+	for _, dep := range step.DependsOn {
+		if result := inputs[dep]; result != nil && result.Succeeded() {
+			req.Items = append(req.Items, &approval.Item{Key: result.Agent, Content: result.Output})
+			domains = append(domains, result.Agent)
+		}
+	}
+	if problems := o.checkContracts(domains); len(problems) > 0 {
+		req.Title += fmt.Sprintf(" (%d requisitos de contrato sem fornecedor)", len(problems))
+	}
+	// Os contratos extraídos também podem ser corrigidos antes de chegarem aos prompts da implementação
+	req.Items = append(req.Items, o.contractItems(domains)...)
+
+	decided, err := o.gate.Decide(ctx, req)
+	if err != nil {
+		return "", humanError(err)
+	}
+	if err := o.saveContractEdits(decided); err != nil {
+		return "", workflow.Permanent(err)
+	}
+
+	for _, decision := range []approval.Decision{approval.Approved, approval.Skipped, approval.Rejected} {
+		if keys := decided.Keys(decision); len(keys) > 0 {
+			fmt.Printf("🧑‍⚖️ %s: %s\n", decision.Label(), strings.Join(keys, ", "))
+		}
+	}
+	return decided.Encode(), nil
+}
+
+func (o *Orchestrator) implement(ctx context.Context, input string, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+	req, err := approval.Decode(inputs[approvalStep].Output)
+	if err != nil {
+		return "", err
+	}
+
+	item := req.Item(step.Agent)
+	switch {
+	case item == nil || item.Decision == approval.Skipped:
+		fmt.Printf("⏭️  %s fora da implementação\n", step.Agent)
+		return "", nil
+	case item.Decision == approval.Rejected:
+		if item.Note != "" {
+			return "", fmt.Errorf("análise de %s rejeitada: %s", step.Agent, item.Note)
+		}
+		return "", fmt.Errorf("análise de %s rejeitada", step.Agent)
+	}
+
+	agent, exists := o.agents[step.Agent]
+	if !exists {
+		return "", fmt.Errorf("agente %s não carregado", step.Agent)
+	}
+
+	// As análises aprovadas, já com as edições feitas na aprovação
+	analyses := make(map[string]string)
+	for _, domain := range analysisKeys(req, approval.Approved) {
+		analyses[domain] = req.Item(domain).Content
+	}
+
+	coordinationPrompt := prompts.Render("coordination_implementation", map[string]interface{}{
+		"Input":    input,
+		"Analysis": item.Content,
+		"Analyses": o.formatAnalysisResults(analyses, step.Agent),
+	})
+
+	result, err := executeOrResume(ctx, agent, coordinationPrompt)
+	if err != nil {
+		return "", fmt.Errorf("erro na implementação do %s: %v", step.Agent, err)
+	}
+
+	o.reviewMu.Lock()
+	defer o.reviewMu.Unlock()
+
+	fmt.Printf("✅ %s implementou sua parte\n", step.Agent)
+	fmt.Println(result)
+	fmt.Println("---")
+
+	if err := reviewAndApply(ctx, o.workingDir, step.Agent, result, o.reviewer); err != nil {
+		if halts(err) {
+			return "", err
+		}
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	return result, nil
+}
+
+func phaseOf(step *workflow.Step) string {
+	return fmt.Sprint(step.Context["phase"])
+}
@@ -2,12 +2,26 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/blackboard"
+	"plaxo-orchestra/internal/budget"
 	"plaxo-orchestra/internal/cache"
+	"plaxo-orchestra/internal/consult"
+	"plaxo-orchestra/internal/detector"
+	"plaxo-orchestra/internal/intelligence"
 	"plaxo-orchestra/internal/learning"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/memory"
 	"plaxo-orchestra/internal/observability"
-	"plaxo-orchestra/internal/pool"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/sandbox"
 	"plaxo-orchestra/internal/stream"
+	"plaxo-orchestra/internal/structured"
+	"plaxo-orchestra/internal/usage"
+	"plaxo-orchestra/internal/workflow"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -18,8 +32,9 @@ type EnhancedOrchestrator struct {
 	cache       *cache.DistributedCache
 	learning    *learning.AdvancedLearning
 	observer    *observability.Observer
-	processor   *pool.AsyncProcessor
 	circuitBreaker *CircuitBreaker
+	coordinator    *intelligence.Coordinator
+	workflowConfig workflow.Config
 }
 
 type CircuitBreaker struct {
@@ -39,23 +54,21 @@ const (
 	HalfOpen
 )
 
-type WorkflowStep struct {
-	Agent        string
-	Dependencies []string
-	Parallel     bool
-	Context      map[string]interface{}
-}
-
 func NewEnhancedOrchestrator(workingDir string) *EnhancedOrchestrator {
-	connectionPool := pool.NewConnectionPool(10, 60*time.Second)
+	workflowConfig := workflow.LoadConfig(workingDir)
+	coordinator := intelligence.NewCoordinator()
+	coordinator.SetWorkflowConfig(workflowConfig)
+	orchestrator := New(workingDir)
+	coordinator.SetAgentPool(orchestrator.agentPool)
 	
 	return &EnhancedOrchestrator{
-		Orchestrator:   New(workingDir),
+		Orchestrator:   orchestrator,
 		cache:          cache.NewDistributedCache(),
 		learning:       learning.NewAdvancedLearning(),
 		observer:       observability.NewObserver(),
-		processor:      pool.NewAsyncProcessor(connectionPool, 5),
 		circuitBreaker: NewCircuitBreaker(5, 1*time.Minute),
+		coordinator:    coordinator,
+		workflowConfig: workflowConfig,
 	}
 }
 
@@ -99,77 +112,191 @@ func (eo *EnhancedOrchestrator) ProcessWithIntelligence(ctx context.Context, inp
 	}
 	
 	// Plan intelligent workflow
-	workflow, err := eo.planIntelligentWorkflow(ctx, input)
+	wf, err := eo.planIntelligentWorkflow(ctx, input)
 	if err != nil {
 		eo.circuitBreaker.RecordFailure()
 		return err
 	}
 	
+	// Checkpoint em .plaxo/runs para retomar se a execução for interrompida
+	run, err := workflow.NewRun(eo.workingDir, workflow.RunKindChat, input, wf)
+	if err != nil {
+		return fmt.Errorf("erro criando execução: %v", err)
+	}
+	
+	return eo.runWorkflow(ctx, span.SpanID, cacheKey, run)
+}
+
+// ResumeRun retoma uma execução do chat, reaproveitando as etapas já concluídas.
+func (eo *EnhancedOrchestrator) ResumeRun(ctx context.Context, run *workflow.Run) error {
+	if run.Kind != workflow.RunKindChat {
+		return fmt.Errorf("execução %s é do tipo %s, não chat", run.ID, run.Kind)
+	}
+	
+	span := eo.observer.StartSpan("resume_run", map[string]string{
+		"run": run.ID,
+	})
+	defer func() {
+		eo.observer.FinishSpan(span, true, nil)
+	}()
+	
+	if !eo.circuitBreaker.CanExecute() {
+		return fmt.Errorf("circuit breaker is open, service temporarily unavailable")
+	}
+	
+	var domains []string
+	for _, step := range run.Steps {
+		if domain, ok := step.Context["domain"].(string); ok {
+			domains = append(domains, domain)
+		}
+	}
+	eo.loadAgents(domains)
+	
+	return eo.runWorkflow(ctx, span.SpanID, eo.cache.GenerateKey(run.Input), run)
+}
+
+func (eo *EnhancedOrchestrator) runWorkflow(ctx context.Context, spanID, cacheKey string, run *workflow.Run) error {
 	// Execute workflow with streaming
-	result, err := eo.executeWorkflowWithStreaming(ctx, workflow, input)
+	result, err := eo.executeWorkflowWithStreaming(ctx, run.Rebuild(), run.Input, run)
 	if err != nil {
-		eo.circuitBreaker.RecordFailure()
-		eo.learning.RecordFeedback(span.SpanID, input, "workflow", false, 1)
+		// Sem parecer da validação o resultado não ensina nada, nem como sucesso nem como falha
+		if !errors.Is(err, errUnverified) {
+			eo.circuitBreaker.RecordFailure()
+			eo.learning.RecordFeedback(spanID, run.Input, "workflow", false, 1)
+		}
+		fmt.Printf("💾 %d/%d etapas salvas. Retome com: orchestra runs resume %s\n", run.Completed(), len(run.Steps), run.ID)
 		return err
 	}
 	
 	// Cache successful result
 	eo.cache.Set(ctx, cacheKey, result, 10*time.Minute)
 	eo.circuitBreaker.RecordSuccess()
-	eo.learning.RecordFeedback(span.SpanID, input, "workflow", true, 5)
+	eo.learning.RecordFeedback(spanID, run.Input, "workflow", true, 5)
 	
 	return nil
 }
 
-func (eo *EnhancedOrchestrator) planIntelligentWorkflow(ctx context.Context, input string) ([]WorkflowStep, error) {
+// Plan só planeja o workflow do pedido, sem executar nada (orchestra plan).
+func (eo *EnhancedOrchestrator) Plan(ctx context.Context, input string) (*workflow.Workflow, error) {
+	return eo.planIntelligentWorkflow(ctx, input)
+}
+
+// planIntelligentWorkflow planeja com o coordenador sobre os domínios reais do projeto; sem domínios, usa o agente livre.
+func (eo *EnhancedOrchestrator) planIntelligentWorkflow(ctx context.Context, input string) (*workflow.Workflow, error) {
 	span := eo.observer.StartSpan("plan_workflow", map[string]string{
 		"input": input,
 	})
 	defer eo.observer.FinishSpan(span, true, nil)
 	
-	// Analyze dependencies and create execution plan
-	workflow := []WorkflowStep{}
-	
-	// Simple workflow planning (would be more sophisticated in production)
-	if eo.needsCoordination(input) {
-		// Multi-agent coordination workflow
-		workflow = append(workflow, WorkflowStep{
-			Agent:        "analyzer",
-			Dependencies: []string{},
-			Parallel:     false,
-			Context:      map[string]interface{}{"phase": "analysis"},
-		})
+	var domains []string
+	if projectInfo := detector.DetectProject(eo.workingDir); projectInfo.Type == detector.MultiAgent {
+		eo.loadAgents(projectInfo.Domains)
+		for _, domain := range projectInfo.Domains {
+			if _, exists := eo.agents[domain]; exists {
+				domains = append(domains, domain)
+			}
+		}
+	}
+	
+	wf := workflow.New("single",
+		&workflow.Step{ID: "execution", Agent: "single", Context: map[string]interface{}{"phase": "execution"}},
+	)
+	if len(domains) > 0 {
+		fmt.Printf("📁 Domínios: %s\n", strings.Join(domains, ", "))
 		
-		workflow = append(workflow, WorkflowStep{
-			Agent:        "coordinator",
-			Dependencies: []string{"analyzer"},
-			Parallel:     false,
-			Context:      map[string]interface{}{"phase": "coordination"},
-		})
+		// O supervisor do projeto, quando existe, divide o pedido entre os domínios com as próprias instruções e memória
+		supervisor := eo.loadSupervisor()
+		if supervisor != nil {
+			eo.coordinator.SetPlanner(supervisor.Complete)
+		}
 		
-		workflow = append(workflow, WorkflowStep{
-			Agent:        "validator",
-			Dependencies: []string{"coordinator"},
-			Parallel:     false,
-			Context:      map[string]interface{}{"phase": "validation"},
-		})
-	} else {
-		// Single agent workflow
-		workflow = append(workflow, WorkflowStep{
-			Agent:        "single",
-			Dependencies: []string{},
-			Parallel:     false,
-			Context:      map[string]interface{}{"phase": "execution"},
-		})
-	}
-	
-	fmt.Printf("📋 Workflow planejado com %d etapas\n", len(workflow))
-	return workflow, nil
+		plan, err := eo.coordinator.PlanWorkflow(input, nil, domains)
+		if err == nil && len(plan.Steps) > 0 {
+			var planned *workflow.Workflow
+			if planned, err = agentWorkflow(plan, domains, eo.needsCoordination(input), supervisor != nil); err == nil {
+				err = planned.Validate()
+			}
+			if err == nil {
+				wf = planned
+				if supervisor != nil {
+					supervisor.Remember(ctx, input, planDecision(wf))
+				}
+			}
+		}
+		if err != nil {
+			fmt.Printf("⚠️  Erro no planejamento, usando agente livre: %v\n", err)
+		}
+	}
+	
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	
+	return wf, nil
 }
 
-func (eo *EnhancedOrchestrator) executeWorkflowWithStreaming(ctx context.Context, workflow []WorkflowStep, input string) (string, error) {
+// agentWorkflow converte o plano do coordenador em etapas dos agentes de domínio, validadas no fim quando há integração.
+// Com supervisor, ele sempre revisa as saídas e escreve o resumo final.
+func agentWorkflow(plan *intelligence.WorkflowMemory, domains []string, integrate, supervised bool) (*workflow.Workflow, error) {
+	wf := workflow.New("coordinator")
+	
+	var ids []string
+	planned := make(map[string]bool)
+	for _, step := range plan.Steps {
+		domain, ok := resolveDomain(step.Agent, domains)
+		if !ok {
+			return nil, fmt.Errorf("etapa %s cita o agente %q, que não está entre %s", step.ID, step.Agent, strings.Join(domains, ", "))
+		}
+		wf.Add(&workflow.Step{ID: step.ID, Agent: domain, Action: step.Action, DependsOn: step.Dependencies, Context: map[string]interface{}{"phase": "execution", "domain": domain, "output": step.Outputs["main"]}})
+		ids = append(ids, step.ID)
+		planned[domain] = true
+	}
+	
+	validator := "integration_validator"
+	if supervised {
+		validator = manifest.OrchestratorDomain
+	}
+	if len(planned) > 1 || integrate || supervised {
+		id := "validation"
+		if wf.Step(id) != nil {
+			id = "integration_validation"
+		}
+		wf.Add(&workflow.Step{ID: id, Agent: validator, Action: "validation", DependsOn: ids, Context: map[string]interface{}{"phase": "validation"}})
+		ids = append(ids, id)
+	}
+	
+	if supervised {
+		id := "summary"
+		if wf.Step(id) != nil {
+			id = "final_summary"
+		}
+		wf.Add(&workflow.Step{ID: id, Agent: manifest.OrchestratorDomain, Action: "summary", DependsOn: ids, Context: map[string]interface{}{"phase": "summary"}})
+	}
+	return wf, nil
+}
+
+// resolveDomain acha o domínio citado pelo planejador, que aceita nomes parciais (ex: "user" para "user/profile");
+// false quando nenhum domínio corresponde.
+func resolveDomain(agent string, domains []string) (string, bool) {
+	if agent == "" {
+		return "", false
+	}
+	for _, domain := range domains {
+		if domain == agent {
+			return domain, true
+		}
+	}
+	for _, domain := range domains {
+		if strings.Contains(domain, agent) || strings.Contains(agent, domain) {
+			return domain, true
+		}
+	}
+	return "", false
+}
+
+func (eo *EnhancedOrchestrator) executeWorkflowWithStreaming(ctx context.Context, wf *workflow.Workflow, input string, run *workflow.Run) (string, error) {
 	span := eo.observer.StartSpan("execute_workflow_streaming", map[string]string{
-		"steps": fmt.Sprintf("%d", len(workflow)),
+		"steps": fmt.Sprintf("%d", len(wf.Steps)),
 	})
 	defer eo.observer.FinishSpan(span, true, nil)
 	
@@ -177,209 +304,246 @@ func (eo *EnhancedOrchestrator) executeWorkflowWithStreaming(ctx context.Context
 	streamer := stream.NewStreamHandler()
 	
 	// Show workflow overview
-	fmt.Printf("📋 Workflow planejado com %d etapas\n", len(workflow))
-	for i, step := range workflow {
-		fmt.Printf("  %d. %s\n", i+1, step.Agent)
+	fmt.Printf("📋 Workflow planejado com %d etapas\n", len(wf.Steps))
+	for i, step := range wf.Steps {
+		fmt.Printf("  %d. %s (%s)\n", i+1, step.ID, step.Agent)
 	}
 	fmt.Println()
 	
-	results := make(map[string]string)
-	executed := make(map[string]bool)
+	// Saídas em streaming não podem se intercalar: uma etapa por vez
+	engine := workflow.NewEngine(eo.workflowConfig)
+	engine.MaxConcurrency = 1
+	// Cada rodada de correção valida e reexecuta os domínios apontados
+	for _, step := range wf.Steps {
+		if phaseOf(step) == "validation" && engine.StepTimeout > 0 {
+			step.Timeout = engine.StepTimeout * time.Duration(2*eo.workflowConfig.ValidationRetries+1)
+		}
+	}
 	
-	stepCount := 0
-	totalSteps := len(workflow)
+	previous := run.Previous()
+	if len(previous) > 0 {
+		fmt.Printf("♻️  Retomando %s: %d etapas já concluídas\n", run.ID, len(previous))
+	}
 	
-	for len(executed) < len(workflow) {
-		// Find steps that can be executed (dependencies satisfied)
-		var readySteps []WorkflowStep
+	stepCount := len(previous)
+	totalSteps := len(wf.Steps)
+	engine.OnStepStart = func(step *workflow.Step) {
+		stepCount++
 		
-		for _, step := range workflow {
-			if executed[step.Agent] {
-				continue
-			}
-			
-			canExecute := true
-			for _, dep := range step.Dependencies {
-				if !executed[dep] {
-					canExecute = false
-					break
-				}
-			}
-			
-			if canExecute {
-				readySteps = append(readySteps, step)
-			}
+		// Show progress
+		stream.ShowProgressBar(stepCount, totalSteps, fmt.Sprintf("Executando %s", step.ID))
+		
+		fmt.Printf("\n🔄 Etapa %d/%d: %s\n", stepCount, totalSteps, step.ID)
+		fmt.Println(strings.Repeat("─", 50))
+	}
+	engine.OnStepDone = func(step *workflow.Step, result *workflow.Result) {
+		switch result.Status {
+		case workflow.StatusSucceeded:
+			fmt.Printf("\n✅ %s concluído\n\n", step.ID)
+		case workflow.StatusFailed:
+			fmt.Printf("\n❌ %s falhou após %d tentativas: %s\n\n", step.ID, result.Attempts, result.Error)
 		}
+	}
+	run.Track(engine)
+	
+	// Etapas publicam e leem decisões no quadro da execução e consultam outros domínios; ambos vão no checkpoint
+	ctx = blackboard.With(ctx, run.Board())
+	ctx = memory.WithInput(ctx, run.Input)
+	ctx = usage.WithRun(ctx, run.ID)
+	ctx = consult.With(ctx, eo.router(run))
+	ctx = sandbox.With(ctx, escalation(eo.workflowConfig, eo.gate, eo.workingDir, run))
+	results, err := engine.Run(ctx, wf, func(ctx context.Context, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+		ctx = memory.WithAction(ctx, step.Action)
 		
-		if len(readySteps) == 0 {
-			return "", fmt.Errorf("workflow deadlock detected")
+		// O parecer da validação decide se a execução conta como sucesso (cache e aprendizado)
+		switch phaseOf(step) {
+		case "validation":
+			return eo.judge(ctx, input, step, inputs)
+		case "summary":
+			return eo.summarize(ctx, input, step, inputs)
 		}
 		
-		// Execute ready steps with streaming feedback
-		for _, step := range readySteps {
-			stepCount++
-			
-			// Show progress
-			stream.ShowProgressBar(stepCount, totalSteps, fmt.Sprintf("Executando %s", step.Agent))
-			
-			fmt.Printf("\n🔄 Etapa %d/%d: %s\n", stepCount, totalSteps, step.Agent)
-			fmt.Println(strings.Repeat("─", 50))
-			
-			// Build context-aware prompt
-			prompt := eo.buildContextualPrompt(input, step, results)
-			
-			// Execute with streaming
-			result := streamer.ExecuteWithStream(ctx, prompt)
-			if result.Error != nil {
-				return "", result.Error
-			}
-			
-			results[step.Agent] = result.Content
-			executed[step.Agent] = true
-			
-			fmt.Printf("\n✅ %s concluído\n\n", step.Agent)
+		// Etapas planejadas sobre domínios rodam no agente do domínio (instruções, memória e código)
+		if domain, ok := step.Context["domain"].(string); ok {
+			return eo.executeAgentStep(ctx, domain, input, step, inputs)
 		}
+		
+		// Build context-aware prompt
+		prompt := eo.buildContextualPrompt(input, step, inputs, run.Board())
+		
+		// Execute with streaming
+		result := streamer.ExecuteWithStream(ctx, prompt)
+		if result.Error == nil {
+			run.Board().Record(step.Agent, result.Content)
+		}
+		return result.Content, result.Error
+	}, previous)
+	if finishErr := run.Finish(err); finishErr != nil {
+		fmt.Printf("⚠️  Erro salvando execução %s: %v\n", run.ID, finishErr)
 	}
-	
-	// Combine results
-	finalResult := ""
-	for agent, result := range results {
-		finalResult += fmt.Sprintf("=== %s ===\n%s\n\n", agent, result)
+	if err != nil {
+		return "", err
 	}
 	
 	fmt.Println("🎉 Workflow concluído com sucesso!")
-	return finalResult, nil
+	return combineResults(wf, results), nil
 }
 
-func (eo *EnhancedOrchestrator) executeWorkflow(ctx context.Context, workflow []WorkflowStep, input string) (string, error) {
-	span := eo.observer.StartSpan("execute_workflow", map[string]string{
-		"steps": fmt.Sprintf("%d", len(workflow)),
-	})
-	defer eo.observer.FinishSpan(span, true, nil)
-	
-	results := make(map[string]string)
-	executed := make(map[string]bool)
-	
-	for len(executed) < len(workflow) {
-		// Find steps that can be executed (dependencies satisfied)
-		var readySteps []WorkflowStep
-		
-		for _, step := range workflow {
-			if executed[step.Agent] {
+// judge valida as saídas das dependências da etapa, agrupadas por domínio, com as rodadas de correção de validation_retries.
+func (eo *EnhancedOrchestrator) judge(ctx context.Context, input string, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+	outputs := make(map[string]string)
+	for _, dep := range step.DependsOn {
+		if result := inputs[dep]; result != nil && result.Succeeded() {
+			if previous, exists := outputs[result.Agent]; exists {
+				outputs[result.Agent] = previous + "\n\n" + result.Output
 				continue
 			}
-			
-			canExecute := true
-			for _, dep := range step.Dependencies {
-				if !executed[dep] {
-					canExecute = false
-					break
-				}
-			}
-			
-			if canExecute {
-				readySteps = append(readySteps, step)
-			}
-		}
-		
-		if len(readySteps) == 0 {
-			return "", fmt.Errorf("workflow deadlock detected")
+			outputs[result.Agent] = result.Output
 		}
-		
-		// Execute ready steps (potentially in parallel)
-		if len(readySteps) > 1 {
-			eo.executeStepsInParallel(ctx, readySteps, input, results)
-		} else {
-			result, err := eo.executeStep(ctx, readySteps[0], input, results)
-			if err != nil {
-				return "", err
-			}
-			results[readySteps[0].Agent] = result
+	}
+	
+	verdict, err := eo.validateUntilPass(ctx, input, outputs)
+	if err != nil {
+		return "", err
+	}
+	return verdict.Summary, nil
+}
+
+// executeAgentStep executa a ação planejada no agente do domínio e revisa as alterações propostas.
+func (eo *EnhancedOrchestrator) executeAgentStep(ctx context.Context, domain, input string, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+	span := eo.observer.StartSpan("execute_step", map[string]string{
+		"step":  step.ID,
+		"agent": domain,
+		"phase": phaseOf(step),
+	})
+	
+	agent, exists := eo.agents[domain]
+	if !exists {
+		err := fmt.Errorf("agente %s não carregado", domain)
+		eo.observer.FinishSpan(span, false, err)
+		return "", err
+	}
+	
+	fmt.Printf("🎯 %s: %s\n", domain, step.Action)
+	result, err := executeOrResume(ctx, agent, eo.buildStepTask(input, step, inputs))
+	if err != nil {
+		eo.observer.FinishSpan(span, false, err)
+		return "", fmt.Errorf("erro em %s: %v", step.ID, err)
+	}
+	eo.observer.FinishSpan(span, true, nil)
+	
+	fmt.Println(result)
+	if err := reviewAndApply(ctx, eo.workingDir, domain, result, eo.reviewer); err != nil {
+		if halts(err) {
+			return "", err
 		}
-		
-		// Mark steps as executed
-		for _, step := range readySteps {
-			executed[step.Agent] = true
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	return result, nil
+}
+
+// buildStepTask monta a tarefa da etapa com as saídas das dependências diretas, dentro do orçamento de tokens.
+func (eo *EnhancedOrchestrator) buildStepTask(input string, step *workflow.Step, inputs map[string]*workflow.Result) string {
+	data := map[string]interface{}{
+		"Input":   input,
+		"Action":  step.Action,
+		"Context": "",
+	}
+	overhead := backend.EstimateTokens(prompts.Render("workflow_step", data))
+	
+	builder := budget.NewBuilder(eo.promptBudget() - overhead)
+	for _, dep := range step.DependsOn {
+		if result := inputs[dep]; result != nil && result.Succeeded() {
+			builder.Add(budget.Section{Name: dep, Content: result.Output, Priority: 60})
 		}
 	}
 	
-	// Combine results
-	finalResult := ""
-	for agent, result := range results {
-		finalResult += fmt.Sprintf("=== %s ===\n%s\n\n", agent, result)
+	fitted, report := builder.Build()
+	if report.Changed() {
+		fmt.Printf("✂️  Contexto de %s ajustado: %s\n", step.ID, report)
 	}
 	
-	return finalResult, nil
+	var context strings.Builder
+	for _, dep := range step.DependsOn {
+		if output, ok := fitted[dep]; ok {
+			context.WriteString(fmt.Sprintf("\n=== Output de %s ===\n%s\n", dep, output))
+		}
+	}
+	data["Context"] = context.String()
+	return prompts.Render("workflow_step", data)
 }
 
-func (eo *EnhancedOrchestrator) executeStepsInParallel(ctx context.Context, steps []WorkflowStep, input string, results map[string]string) {
-	var wg sync.WaitGroup
-	resultsChan := make(chan struct {
-		agent  string
-		result string
-		err    error
-	}, len(steps))
-	
-	for _, step := range steps {
-		wg.Add(1)
-		go func(s WorkflowStep) {
-			defer wg.Done()
-			
-			result, err := eo.executeStep(ctx, s, input, results)
-			resultsChan <- struct {
-				agent  string
-				result string
-				err    error
-			}{s.Agent, result, err}
-		}(step)
-	}
-	
-	wg.Wait()
-	close(resultsChan)
-	
-	// Collect results
-	for res := range resultsChan {
-		if res.err == nil {
-			results[res.agent] = res.result
+// combineResults devolve o resumo do supervisor, quando há um, ou junta as saídas na ordem de declaração das etapas.
+func combineResults(wf *workflow.Workflow, results map[string]*workflow.Result) string {
+	for _, step := range wf.Steps {
+		if result := results[step.ID]; phaseOf(step) == "summary" && result != nil && result.Succeeded() {
+			return result.Output
+		}
+	}
+	
+	finalResult := ""
+	for _, step := range wf.Steps {
+		if result := results[step.ID]; result != nil && result.Succeeded() {
+			finalResult += fmt.Sprintf("=== %s ===\n%s\n\n", step.ID, result.Output)
 		}
 	}
+	return finalResult
 }
 
-func (eo *EnhancedOrchestrator) executeStep(ctx context.Context, step WorkflowStep, input string, previousResults map[string]string) (string, error) {
-	span := eo.observer.StartSpan("execute_step", map[string]string{
-		"agent": step.Agent,
-		"phase": fmt.Sprintf("%v", step.Context["phase"]),
-	})
-	defer eo.observer.FinishSpan(span, true, nil)
+func (eo *EnhancedOrchestrator) buildContextualPrompt(input string, step *workflow.Step, previousResults map[string]*workflow.Result, board *blackboard.Board) string {
+	data := map[string]interface{}{
+		"Input":      "",
+		"Agent":      step.Agent,
+		"Phase":      fmt.Sprintf("%v", step.Context["phase"]),
+		"Previous":   nil,
+		"Shared":     board != nil,
+		"Blackboard": "",
+	}
+	overhead := backend.EstimateTokens(prompts.Render("workflow_contextual", data))
 	
-	// Build context-aware prompt
-	prompt := eo.buildContextualPrompt(input, step, previousResults)
+	// Resultados das dependências diretas valem mais que os demais passos anteriores
+	dependencies := make(map[string]bool)
+	for _, dep := range step.DependsOn {
+		dependencies[dep] = true
+	}
 	
-	// Execute using async processor
-	resultChan := eo.processor.Submit(ctx, prompt)
+	var ids []string
+	for id, result := range previousResults {
+		if result != nil && result.Succeeded() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
 	
-	select {
-	case result := <-resultChan:
-		if result.Error != nil {
-			return "", result.Error
+	builder := budget.NewBuilder(eo.promptBudget() - overhead)
+	builder.Add(budget.Section{Name: "input", Content: input, Priority: 100, Required: true})
+	if board != nil {
+		builder.Add(budget.Section{Name: "blackboard", Content: board.Summary(), Priority: 65})
+	}
+	for _, id := range ids {
+		priority := 30
+		if dependencies[id] {
+			priority = 60
 		}
-		return fmt.Sprintf("%v", result.Data), nil
-	case <-ctx.Done():
-		return "", ctx.Err()
+		builder.Add(budget.Section{Name: "step:" + id, Content: previousResults[id].Output, Priority: priority})
+	}
+	
+	fitted, report := builder.Build()
+	if report.Changed() {
+		fmt.Printf("✂️  Contexto de %s ajustado: %s\n", step.ID, report)
 	}
-}
-
-func (eo *EnhancedOrchestrator) buildContextualPrompt(input string, step WorkflowStep, previousResults map[string]string) string {
-	prompt := fmt.Sprintf("Input: %s\n\nAgent: %s\nPhase: %v\n", input, step.Agent, step.Context["phase"])
 	
-	if len(previousResults) > 0 {
-		prompt += "\nPrevious Results:\n"
-		for agent, result := range previousResults {
-			prompt += fmt.Sprintf("- %s: %s\n", agent, result)
+	var previous []map[string]string
+	for _, id := range ids {
+		if result, ok := fitted["step:"+id]; ok {
+			previous = append(previous, map[string]string{"Agent": id, "Result": result})
 		}
 	}
 	
-	return prompt
+	data["Input"] = fitted["input"]
+	data["Previous"] = previous
+	data["Blackboard"] = fitted["blackboard"]
+	return prompts.Render("workflow_contextual", data)
 }
 
 func (cb *CircuitBreaker) CanExecute() bool {
@@ -434,6 +598,15 @@ func (eo *EnhancedOrchestrator) GetAdvancedInsights() map[string]interface{} {
 		combined[fmt.Sprintf("metrics_%s", k)] = v
 	}
 	
+	// Add persisted token usage
+	combined["usage"] = usage.LoadSummary(eo.workingDir)
+	
+	// Add structured output reliability
+	combined["structured"] = structured.LoadStats(eo.workingDir)
+	
+	// Add sandbox violations
+	combined["sandbox"] = sandbox.LoadStats(eo.workingDir)
+	
 	// Add cache statistics
 	hits, misses := eo.cache.GetStats()
 	combined["cache_hit_rate"] = float64(hits) / float64(hits+misses)
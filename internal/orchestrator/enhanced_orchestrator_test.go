@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"plaxo-orchestra/internal/intelligence"
+)
+
+func TestResolveDomain(t *testing.T) {
+	domains := []string{"auth", "user/profile"}
+
+	tests := []struct {
+		agent  string
+		domain string
+		ok     bool
+	}{
+		{"auth", "auth", true},
+		{"user", "user/profile", true},
+		{"user/profile/settings", "user/profile", true},
+		{"billing", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		domain, ok := resolveDomain(test.agent, domains)
+		if domain != test.domain || ok != test.ok {
+			t.Errorf("resolveDomain(%q) = %q, %v; esperado %q, %v", test.agent, domain, ok, test.domain, test.ok)
+		}
+	}
+}
+
+func TestAgentWorkflow(t *testing.T) {
+	domains := []string{"auth", "user"}
+	plan := func(agents ...string) *intelligence.WorkflowMemory {
+		memory := &intelligence.WorkflowMemory{}
+		for _, agent := range agents {
+			memory.Steps = append(memory.Steps, intelligence.WorkflowStep{ID: agent + ".step", Agent: agent, Dependencies: []string{}})
+		}
+		return memory
+	}
+
+	tests := []struct {
+		name       string
+		plan       *intelligence.WorkflowMemory
+		supervised bool
+		steps      []string
+		err        bool
+	}{
+		{"um domínio", plan("auth"), false, []string{"auth.step"}, false},
+		{"dois domínios validam", plan("auth", "user"), false, []string{"auth.step", "user.step", "validation"}, false},
+		{"supervisor revisa e resume", plan("auth"), true, []string{"auth.step", "validation", "summary"}, false},
+		{"agente desconhecido", plan("auth", "billing"), false, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wf, err := agentWorkflow(test.plan, domains, false, test.supervised)
+			if (err != nil) != test.err {
+				t.Fatalf("erro = %v", err)
+			}
+			if test.err {
+				return
+			}
+			if len(wf.Steps) != len(test.steps) {
+				t.Fatalf("%d etapas, esperado %v", len(wf.Steps), test.steps)
+			}
+			for i, step := range wf.Steps {
+				if step.ID != test.steps[i] {
+					t.Errorf("etapa %d = %s, esperado %s", i, step.ID, test.steps[i])
+				}
+			}
+			if err := wf.Validate(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/blackboard"
+	"plaxo-orchestra/internal/memory"
+	"plaxo-orchestra/internal/sandbox"
+	"plaxo-orchestra/internal/usage"
+	"plaxo-orchestra/internal/workflow"
+)
+
+// AllAgents em agent faz a etapa rodar em cada domínio carregado.
+const AllAgents = "*"
+
+// OrchestrationWorkflow é uma entrada da seção orchestration do orchestra.yaml.
+type OrchestrationWorkflow struct {
+	Description string `yaml:"description"`
+	// "parallel" (padrão) respeita só depends_on; "sequential" encadeia as etapas na ordem declarada
+	Mode  string              `yaml:"mode,omitempty"`
+	Steps []OrchestrationStep `yaml:"steps"`
+
+	legacy bool
+}
+
+// OrchestrationStep chama um comando de um agente de domínio.
+type OrchestrationStep struct {
+	ID      string `yaml:"id"`
+	Agent   string `yaml:"agent"`
+	Command string `yaml:"command"`
+	// Template com .Input, .Domain e .Steps.<id> (saída das etapas anteriores)
+	Input     string   `yaml:"input,omitempty"`
+	DependsOn []string `yaml:"depends_on,omitempty"`
+	// Approval pausa para um humano revisar as saídas de depends_on antes das etapas seguintes
+	Approval bool `yaml:"approval,omitempty"`
+}
+
+// UnmarshalYAML aceita também o formato antigo, em que a entrada é só uma descrição.
+func (w *OrchestrationWorkflow) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var description string
+	if err := unmarshal(&description); err == nil {
+		*w = OrchestrationWorkflow{Description: description, legacy: true}
+		return nil
+	}
+
+	type plain OrchestrationWorkflow
+	return unmarshal((*plain)(w))
+}
+
+// builtinOrchestrations substitui as entradas no formato antigo.
+var builtinOrchestrations = map[string][]OrchestrationStep{
+	"analyze_all":  {{ID: "analyze", Agent: AllAgents, Command: "analyze", Input: "Analisar código completo"}},
+	"refactor_all": {{ID: "refactor", Agent: AllAgents, Command: "refactor", Input: "Refatorar seguindo melhores práticas"}},
+	"test_all":     {{ID: "test", Agent: AllAgents, Command: "test", Input: "Executar testes completos"}},
+	"deploy_all":   {{ID: "document", Agent: AllAgents, Command: "document", Input: "Preparar documentação para deploy"}},
+}
+
+// orchestrationInput são os dados disponíveis no template de input de uma etapa.
+type orchestrationInput struct {
+	Input  string
+	Domain string
+	Steps  map[string]string
+}
+
+// orchestrationStep é uma etapa já expandida para um domínio.
+type orchestrationStep struct {
+	group   string
+	domain  string
+	command string
+	input   *template.Template
+	gate    bool
+}
+
+// ListOrchestrations mostra os workflows de orquestração configurados.
+func (am *AgentManager) ListOrchestrations() {
+	if am.orchestraConfig == nil || len(am.orchestraConfig.Orchestration) == 0 {
+		fmt.Println("❌ Nenhuma orquestração configurada")
+		return
+	}
+
+	fmt.Println("🎼 Orquestrações:")
+	for _, name := range sortedKeys(am.orchestraConfig.Orchestration) {
+		entry := am.orchestraConfig.Orchestration[name]
+		steps := entry.Steps
+		if entry.legacy {
+			steps = builtinOrchestrations[name]
+		}
+
+		var parts []string
+		for _, step := range steps {
+			if step.Approval {
+				parts = append(parts, "⏸️  "+step.ID)
+				continue
+			}
+			parts = append(parts, step.Agent+"."+step.Command)
+		}
+		fmt.Printf("  %s: %s\n", name, entry.Description)
+		if len(parts) > 0 {
+			fmt.Printf("     %s\n", strings.Join(parts, ", "))
+		}
+	}
+}
+
+// buildOrchestration converte a entrada da configuração num workflow validado, expandindo etapas de todos os agentes.
+func (am *AgentManager) buildOrchestration(name string) (*workflow.Workflow, map[string]*orchestrationStep, error) {
+	entry, exists := am.orchestraConfig.Orchestration[name]
+	if !exists {
+		return nil, nil, fmt.Errorf("comando de orquestração '%s' não encontrado", name)
+	}
+
+	steps := entry.Steps
+	if entry.legacy {
+		builtin, ok := builtinOrchestrations[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("orquestração '%s' não define etapas (steps)", name)
+		}
+		steps = builtin
+	}
+	if len(steps) == 0 {
+		return nil, nil, fmt.Errorf("orquestração '%s' não define etapas (steps)", name)
+	}
+
+	domains := am.GetDomains()
+	sort.Strings(domains)
+
+	var problems []string
+	ids := make(map[string][]string)
+	expanded := make(map[string]*orchestrationStep)
+	wf := workflow.New(name)
+	previous := ""
+
+	for i, spec := range steps {
+		id := spec.ID
+		if spec.Approval {
+			if id == "" {
+				id = "approval"
+			}
+			deps := append([]string{}, spec.DependsOn...)
+			if entry.Mode == "sequential" && previous != "" {
+				deps = append(deps, previous)
+			}
+			previous = id
+			ids[id] = []string{id}
+			expanded[id] = &orchestrationStep{group: id, gate: true}
+			wf.Add(&workflow.Step{ID: id, Agent: workflow.HumanAgent, Action: "approval", DependsOn: deps, Human: true})
+			continue
+		}
+		if id == "" {
+			id = spec.Agent + "." + spec.Command
+			if spec.Agent == AllAgents {
+				id = spec.Command
+			}
+		}
+
+		text := spec.Input
+		if text == "" {
+			text = "{{.Input}}"
+		}
+		input, err := template.New(id).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: input inválido: %v", id, err))
+			continue
+		}
+
+		targets := []string{spec.Agent}
+		if spec.Agent == AllAgents {
+			targets = domains
+		}
+
+		deps := append([]string{}, spec.DependsOn...)
+		if entry.Mode == "sequential" && i > 0 && previous != "" {
+			deps = append(deps, previous)
+		}
+		previous = id
+
+		for _, domain := range targets {
+			if problem := am.checkCommand(domain, spec.Command); problem != "" {
+				// Em etapas de todos os agentes, quem não tem o comando fica de fora
+				if spec.Agent == AllAgents {
+					fmt.Printf("⚠️  %s: %s, domínio ignorado\n", id, problem)
+					continue
+				}
+				problems = append(problems, fmt.Sprintf("%s: %s", id, problem))
+				continue
+			}
+
+			stepID := id
+			if spec.Agent == AllAgents {
+				stepID = id + "." + domain
+			}
+			ids[id] = append(ids[id], stepID)
+			expanded[stepID] = &orchestrationStep{group: id, domain: domain, command: spec.Command, input: input}
+			wf.Add(&workflow.Step{ID: stepID, Agent: domain, Action: spec.Command, DependsOn: deps})
+		}
+		if spec.Agent == AllAgents && len(ids[id]) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: nenhum agente tem o comando '%s'", id, spec.Command))
+		}
+	}
+
+	// Dependências de uma etapa expandida apontam para todas as suas cópias
+	for _, step := range wf.Steps {
+		var deps []string
+		for _, dep := range step.DependsOn {
+			if copies, ok := ids[dep]; ok {
+				deps = append(deps, copies...)
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+		step.DependsOn = deps
+	}
+
+	if len(problems) > 0 {
+		return nil, nil, &workflow.ValidationError{Workflow: name, Problems: problems}
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return wf, expanded, nil
+}
+
+func (am *AgentManager) checkCommand(domain, command string) string {
+	agent, exists := am.agents[domain]
+	if !exists {
+		return fmt.Sprintf("agente '%s' não encontrado", domain)
+	}
+	if _, exists := agent.Commands[command]; !exists {
+		return fmt.Sprintf("comando '%s' não disponível para agente '%s'", command, domain)
+	}
+	return ""
+}
+
+// ExecuteOrchestrationCommand executa um workflow da seção orchestration; input fica disponível como .Input nas etapas.
+func (am *AgentManager) ExecuteOrchestrationCommand(name, input string) error {
+	if am.orchestraConfig == nil {
+		return fmt.Errorf("configuração não carregada")
+	}
+
+	wf, steps, err := am.buildOrchestration(name)
+	if err != nil {
+		return err
+	}
+
+	run, err := workflow.NewRun(am.rootPath, workflow.RunKindOrchestrate, input, wf)
+	if err != nil {
+		return fmt.Errorf("erro criando execução: %v", err)
+	}
+	return am.runOrchestration(context.Background(), wf, steps, run)
+}
+
+// ResumeOrchestration retoma uma orquestração interrompida; etapas concluídas não rodam de novo.
+func (am *AgentManager) ResumeOrchestration(ctx context.Context, run *workflow.Run) error {
+	if run.Kind != workflow.RunKindOrchestrate {
+		return fmt.Errorf("execução %s é do tipo %s, não orchestrate", run.ID, run.Kind)
+	}
+	if am.orchestraConfig == nil {
+		return fmt.Errorf("configuração não carregada")
+	}
+
+	// O workflow vem da configuração atual; saídas de etapas com o mesmo ID são reaproveitadas
+	wf, steps, err := am.buildOrchestration(run.Workflow)
+	if err != nil {
+		return err
+	}
+	return am.runOrchestration(ctx, wf, steps, run)
+}
+
+func (am *AgentManager) runOrchestration(ctx context.Context, wf *workflow.Workflow, steps map[string]*orchestrationStep, run *workflow.Run) error {
+	name := wf.Name
+	fmt.Printf("🎼 Executando orquestração: %s\n", name)
+	if description := am.orchestraConfig.Orchestration[name].Description; description != "" {
+		fmt.Printf("📋 Descrição: %s\n", description)
+	}
+	previous := run.Previous()
+	if len(previous) > 0 {
+		fmt.Printf("♻️  Retomando %s: %d etapas já concluídas\n", run.ID, len(previous))
+	}
+	fmt.Println(strings.Repeat("─", 50))
+
+	cfg := workflow.LoadConfig(am.rootPath)
+	engine := workflow.NewEngine(cfg)
+	engine.ContinueOnError = true
+	// Com uma etapa por vez a resposta pode ir direto para o terminal
+	stream := engine.MaxConcurrency == 1
+
+	engine.OnStepStart = func(step *workflow.Step) {
+		fmt.Printf("🚀 %s (%s.%s)\n", step.ID, step.Agent, step.Action)
+	}
+	engine.OnStepDone = func(step *workflow.Step, result *workflow.Result) {
+		switch result.Status {
+		case workflow.StatusSucceeded:
+			fmt.Printf("✅ %s concluída em %v\n", step.ID, result.Duration().Round(100*time.Millisecond))
+		case workflow.StatusFailed:
+			fmt.Printf("⚠️  Erro em %s: %s\n", step.ID, result.Error)
+		case workflow.StatusSkipped:
+			fmt.Printf("⏭️  %s pulada: %s\n", step.ID, result.Error)
+		}
+	}
+	run.Track(engine)
+	ctx = blackboard.With(ctx, run.Board())
+	ctx = memory.WithInput(ctx, run.Input)
+	ctx = usage.WithRun(ctx, run.ID)
+	ctx = sandbox.With(ctx, escalation(cfg, am.gate, am.rootPath, run))
+
+	execute := func(ctx context.Context, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+		ctx = memory.WithAction(ctx, step.Action)
+		spec := steps[step.ID]
+		if spec.gate {
+			return am.approveOutputs(ctx, run, step, inputs)
+		}
+
+		prompt, err := renderStepInput(spec, name, run.Input, inputs, steps)
+		if err != nil {
+			return "", err
+		}
+		return am.runAgentCommand(ctx, spec.domain, spec.command, prompt, stream)
+	}
+
+	_, err := engine.Run(ctx, wf, execute, previous)
+	if finishErr := run.Finish(err); finishErr != nil {
+		fmt.Printf("⚠️  Erro salvando execução %s: %v\n", run.ID, finishErr)
+	}
+	if err != nil {
+		fmt.Printf("💾 %d/%d etapas salvas. Retome com: orchestra runs resume %s\n", run.Completed(), len(wf.Steps), run.ID)
+	}
+	return err
+}
+
+// approveOutputs pede aprovação das saídas das dependências; qualquer rejeição interrompe as etapas seguintes.
+func (am *AgentManager) approveOutputs(ctx context.Context, run *workflow.Run, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+	req := &approval.Request{
+		Run:   run.ID,
+		Step:  step.ID,
+		Title: fmt.Sprintf("Aprovação em %s", run.Workflow),
+	}
+	for _, id := range step.DependsOn {
+		if result := inputs[id]; result != nil && result.Succeeded() {
+			req.Items = append(req.Items, &approval.Item{Key: id, Content: result.Output})
+		}
+	}
+
+	decided, err := am.gate.Decide(ctx, req)
+	if err != nil {
+		return "", humanError(err)
+	}
+	if rejected := decided.Keys(approval.Rejected); len(rejected) > 0 {
+		return "", workflow.Permanent(fmt.Errorf("rejeitado na aprovação: %s", strings.Join(rejected, ", ")))
+	}
+	return decided.Encode(), nil
+}
+
+// renderStepInput monta o input da etapa; etapas expandidas também aparecem juntas sob o ID original.
+func renderStepInput(spec *orchestrationStep, name, input string, inputs map[string]*workflow.Result, steps map[string]*orchestrationStep) (string, error) {
+	data := orchestrationInput{Input: input, Domain: spec.domain, Steps: make(map[string]string)}
+
+	outputs := make(map[string]string)
+	var gates []*approval.Request
+	for _, id := range sortedKeys(inputs) {
+		result := inputs[id]
+		if result == nil || !result.Succeeded() {
+			continue
+		}
+		if dep := steps[id]; dep != nil && dep.gate {
+			req, err := approval.Decode(result.Output)
+			if err != nil {
+				return "", err
+			}
+			gates = append(gates, req)
+			continue
+		}
+		outputs[id] = result.Output
+	}
+
+	// Aprovações valem sobre as saídas: conteúdo editado substitui, item pulado sai
+	for _, req := range gates {
+		for _, item := range req.Items {
+			if item.Decision == approval.Approved {
+				outputs[item.Key] = item.Content
+			} else {
+				delete(outputs, item.Key)
+			}
+		}
+	}
+
+	groups := make(map[string][]string)
+	for _, id := range sortedKeys(outputs) {
+		data.Steps[id] = outputs[id]
+		if dep := steps[id]; dep != nil && dep.group != id {
+			groups[dep.group] = append(groups[dep.group], fmt.Sprintf("## %s\n%s", dep.domain, outputs[id]))
+		}
+	}
+	for group, outputs := range groups {
+		data.Steps[group] = strings.Join(outputs, "\n\n")
+	}
+
+	var buf bytes.Buffer
+	if err := spec.input.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("erro montando input de %s em %s: %v", spec.group, name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
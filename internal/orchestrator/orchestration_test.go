@@ -0,0 +1,192 @@
+package orchestrator
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/workflow"
+)
+
+func TestOrchestrationUnmarshal(t *testing.T) {
+	data := `
+analyze_all: "Analisar todos os domínios"
+release:
+  description: Preparar release
+  mode: sequential
+  steps:
+    - id: tests
+      agent: "*"
+      command: test
+    - approval: true
+      id: review
+`
+	var config map[string]OrchestrationWorkflow
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := config["analyze_all"]
+	if !legacy.legacy || legacy.Description != "Analisar todos os domínios" || len(legacy.Steps) != 0 {
+		t.Errorf("formato antigo = %+v", legacy)
+	}
+	release := config["release"]
+	if release.legacy || release.Mode != "sequential" || len(release.Steps) != 2 || !release.Steps[1].Approval {
+		t.Errorf("formato com etapas = %+v", release)
+	}
+}
+
+func TestBuildOrchestration(t *testing.T) {
+	agents := map[string]*manifest.Manifest{
+		"auth": {Domain: "auth", Commands: map[string]string{"analyze": "x", "test": "x"}},
+		"user": {Domain: "user", Commands: map[string]string{"test": "x", "document": "x"}},
+	}
+
+	tests := []struct {
+		name  string
+		entry OrchestrationWorkflow
+		// want lista as etapas como "id<-dependências", na ordem do workflow
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "formato antigo pula quem não tem o comando",
+			entry: OrchestrationWorkflow{legacy: true},
+			want:  []string{"analyze.auth<-"},
+		},
+		{
+			name: "todos os agentes com o comando",
+			entry: OrchestrationWorkflow{Steps: []OrchestrationStep{
+				{Agent: AllAgents, Command: "test"},
+				{ID: "docs", Agent: "user", Command: "document", DependsOn: []string{"test"}},
+			}},
+			want: []string{"test.auth<-", "test.user<-", "docs<-test.auth,test.user"},
+		},
+		{
+			name: "nenhum agente com o comando",
+			entry: OrchestrationWorkflow{Steps: []OrchestrationStep{
+				{Agent: AllAgents, Command: "deploy"},
+			}},
+			wantErr: "nenhum agente tem o comando 'deploy'",
+		},
+		{
+			name: "agente explícito sem o comando",
+			entry: OrchestrationWorkflow{Steps: []OrchestrationStep{
+				{Agent: "user", Command: "analyze"},
+			}},
+			wantErr: "comando 'analyze' não disponível para agente 'user'",
+		},
+		{
+			name: "sequencial encadeia as etapas e a aprovação",
+			entry: OrchestrationWorkflow{Mode: "sequential", Steps: []OrchestrationStep{
+				{ID: "analise", Agent: "auth", Command: "analyze"},
+				{ID: "revisao", Approval: true},
+				{Agent: AllAgents, Command: "test"},
+			}},
+			want: []string{"analise<-", "revisao<-analise", "test.auth<-revisao", "test.user<-revisao"},
+		},
+		{
+			name: "input inválido",
+			entry: OrchestrationWorkflow{Steps: []OrchestrationStep{
+				{Agent: "auth", Command: "analyze", Input: "{{.Input"},
+			}},
+			wantErr: "input inválido",
+		},
+		{
+			name:    "sem etapas",
+			entry:   OrchestrationWorkflow{Description: "vazio"},
+			wantErr: "não define etapas",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			am := &AgentManager{
+				agents:          agents,
+				orchestraConfig: &OrchestraConfig{Orchestration: map[string]OrchestrationWorkflow{"analyze_all": test.entry}},
+			}
+
+			wf, steps, err := am.buildOrchestration("analyze_all")
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("erro = %v, esperado %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, step := range wf.Steps {
+				got = append(got, step.ID+"<-"+strings.Join(step.DependsOn, ","))
+				if steps[step.ID] == nil {
+					t.Errorf("etapa %s sem especificação", step.ID)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("etapas = %v, esperado %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderStepInput(t *testing.T) {
+	gate := &approval.Request{Items: []*approval.Item{
+		{Key: "analyze.auth", Content: "auth editado", Decision: approval.Approved},
+		{Key: "analyze.user", Content: "user", Decision: approval.Skipped},
+	}}
+	steps := map[string]*orchestrationStep{
+		"analyze.auth": {group: "analyze", domain: "auth"},
+		"analyze.user": {group: "analyze", domain: "user"},
+		"review":       {group: "review", gate: true},
+		"plan":         {group: "plan", domain: "auth"},
+	}
+	succeeded := func(output string) *workflow.Result {
+		return &workflow.Result{Status: workflow.StatusSucceeded, Output: output}
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		inputs   map[string]*workflow.Result
+		want     string
+	}{
+		{"só o input", "{{.Input}} em {{.Domain}}", nil, "pedido em user"},
+		{
+			"saídas agrupadas por etapa expandida",
+			"{{.Steps.analyze}}",
+			map[string]*workflow.Result{"analyze.auth": succeeded("auth ok"), "analyze.user": succeeded("user ok")},
+			"## auth\nauth ok\n\n## user\nuser ok",
+		},
+		{
+			"aprovação edita e pula saídas",
+			"{{.Steps.analyze}}|{{index .Steps \"analyze.user\"}}",
+			map[string]*workflow.Result{"analyze.auth": succeeded("auth ok"), "analyze.user": succeeded("user ok"), "review": succeeded(gate.Encode())},
+			"## auth\nauth editado|",
+		},
+		{
+			"etapa com falha fica de fora",
+			"[{{.Steps.plan}}]",
+			map[string]*workflow.Result{"plan": {Status: workflow.StatusFailed, Output: "parcial"}},
+			"[]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec := &orchestrationStep{group: "next", domain: "user", input: template.Must(template.New("next").Option("missingkey=zero").Parse(test.template))}
+			got, err := renderStepInput(spec, "release", "pedido", test.inputs, steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("input = %q, esperado %q", got, test.want)
+			}
+		})
+	}
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"plaxo-orchestra/internal/agent"
+	"plaxo-orchestra/internal/approval"
 	"plaxo-orchestra/internal/analyzer"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/consult"
 	"plaxo-orchestra/internal/detector"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/patch"
 	"plaxo-orchestra/internal/pool"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/workflow"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,6 +26,13 @@ type Orchestrator struct {
 	workingDir string
 	agents     map[string]*agent.Agent
 	agentPool  *pool.AgentPool
+	reviewer   patch.Reviewer
+	gate       approval.Gate
+	// Serializa saída e revisão das implementações que rodam em paralelo
+	reviewMu   sync.Mutex
+	// Agente de orchestra_agents, carregado na primeira vez em que é preciso
+	supervisor     *agent.Agent
+	supervisorOnce sync.Once
 }
 
 func New(workingDir string) *Orchestrator {
@@ -25,9 +40,31 @@ func New(workingDir string) *Orchestrator {
 		workingDir: workingDir,
 		agents:     make(map[string]*agent.Agent),
 		agentPool:  pool.NewAgentPool(),
+		reviewer:   defaultReviewer(),
+		gate:       approval.Default(workingDir),
 	}
 }
 
+// SetReviewer troca quem aprova as alterações propostas pelos agentes (padrão: pergunta no terminal).
+func (o *Orchestrator) SetReviewer(reviewer patch.Reviewer) {
+	o.reviewer = reviewer
+}
+
+// SetGate troca quem aprova as análises antes da implementação (padrão: terminal, ou arquivo pendente sem terminal).
+func (o *Orchestrator) SetGate(gate approval.Gate) {
+	o.gate = gate
+}
+
+// promptBudget é o orçamento de tokens dos prompts de workflow, no mesmo backend em que os agentes rodam.
+func (o *Orchestrator) promptBudget() int {
+	return backend.PromptBudget(o.agentPool.Backend())
+}
+
+// Close encerra as sessões persistentes dos agentes.
+func (o *Orchestrator) Close() {
+	o.agentPool.Close()
+}
+
 func (o *Orchestrator) Process(input string) error {
 	projectInfo := detector.DetectProject(o.workingDir)
 	
@@ -52,27 +89,58 @@ func (o *Orchestrator) handleSingleAgent(input string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 	
-	cmd := exec.CommandContext(ctx, "q", "chat", input)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	
-	return cmd.Run()
+	return streamToStdout(ctx, "free_agent", input)
+}
+
+// streamToStdout envia o prompt ao backend padrão exibindo a resposta em tempo real.
+func streamToStdout(ctx context.Context, agentID, prompt string) error {
+	_, err := streamResult(ctx, agentID, prompt)
+	return err
+}
+
+// streamResult exibe a resposta em tempo real e também a devolve completa.
+func streamResult(ctx context.Context, agentID, prompt string) (string, error) {
+	response, err := backend.Default().Stream(ctx, backend.Request{AgentID: agentID, Prompt: prompt}, func(chunk string) {
+		fmt.Print(chunk)
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// completeResult devolve a resposta sem exibi-la, para execuções em paralelo.
+func completeResult(ctx context.Context, agentID, prompt string) (string, error) {
+	response, err := backend.Default().Complete(ctx, backend.Request{AgentID: agentID, Prompt: prompt})
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
 }
 
 func (o *Orchestrator) handleMultiAgent(input string, domains []string) error {
 	fmt.Println("🎼 Modo multi-agente ativo")
 	fmt.Printf("📁 Domínios: %s\n", strings.Join(domains, ", "))
 	
-	// Carrega agentes dos domínios
-	for _, domain := range domains {
-		if _, exists := o.agents[domain]; !exists {
-			agent := agent.NewAgent(domain, o.workingDir, o.agentPool)
-			if err := agent.LoadInstructions(); err != nil {
-				fmt.Printf("⚠️  Erro carregando agente %s: %v\n", domain, err)
-				continue
+	o.loadAgents(domains)
+	
+	// Com supervisor, ele escolhe os domínios; sem resposta dele, seguem as palavras-chave
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	routed := o.route(ctx, input, domains)
+	cancel()
+	switch {
+	case len(routed) == 1:
+		if agent, exists := o.agents[routed[0]]; exists {
+			fmt.Printf("🎯 Delegando para: %s\n", routed[0])
+			result, err := agent.Execute(input)
+			if err != nil {
+				return err
 			}
-			o.agents[domain] = agent
+			fmt.Println(result)
+			return nil
 		}
+	case len(routed) > 1:
+		return o.coordinateAgents(input, routed)
 	}
 	
 	// Analisa se precisa coordenação entre agentes
@@ -97,6 +165,32 @@ func (o *Orchestrator) handleMultiAgent(input string, domains []string) error {
 	return nil
 }
 
+// loadAgents carrega os agentes dos domínios ainda não carregados.
+func (o *Orchestrator) loadAgents(domains []string) {
+	for _, domain := range domains {
+		if _, exists := o.agents[domain]; !exists {
+			agent := agent.NewAgent(domain, o.workingDir, o.agentPool)
+			if err := agent.LoadInstructions(); err != nil {
+				fmt.Printf("⚠️  Erro carregando agente %s: %v\n", domain, err)
+				continue
+			}
+			o.agents[domain] = agent
+		}
+	}
+}
+
+// router encaminha as consultas "@ask" entre os agentes carregados e registra as trocas na execução.
+func (o *Orchestrator) router(run *workflow.Run) *consult.Router {
+	cfg := workflow.LoadConfig(o.workingDir)
+	return consult.NewRouter(sortedKeys(o.agents), cfg.ConsultDepth, cfg.MaxConsultations, run.Trace(), func(ctx context.Context, from, to, question string) (string, error) {
+		agent, exists := o.agents[to]
+		if !exists {
+			return "", fmt.Errorf("agente %s não carregado", to)
+		}
+		return agent.Consult(ctx, from, question)
+	})
+}
+
 func (o *Orchestrator) needsCoordination(input string) bool {
 	keywords := []string{"integrar", "conectar", "comunicar", "sincronizar", "coordenar", "funcionar", "implementar sistema"}
 	input = strings.ToLower(input)
@@ -109,107 +203,31 @@ func (o *Orchestrator) needsCoordination(input string) bool {
 	return false
 }
 
-func (o *Orchestrator) coordinateAgents(input string, domains []string) error {
-	fmt.Println("🔗 Coordenando múltiplos agentes...")
-	
-	// Fase 1: Análise - cada agente analisa o que precisa fazer
-	analysisResults := make(map[string]string)
-	for _, domain := range domains {
-		if agent, exists := o.agents[domain]; exists {
-			analysisPrompt := fmt.Sprintf(`
-Analise esta requisição do ponto de vista do seu domínio (%s):
-"%s"
-
-Responda APENAS:
-1. O que você precisa implementar/modificar no seu domínio
-2. Que contratos/interfaces você precisa de outros domínios
-3. Que contratos/interfaces você pode fornecer para outros domínios
-`, domain, input)
-			
-			result, err := agent.Execute(analysisPrompt)
-			if err != nil {
-				fmt.Printf("❌ Erro na análise do %s: %v\n", domain, err)
-				continue
-			}
-			analysisResults[domain] = result
-			fmt.Printf("📋 %s analisou suas responsabilidades\n", domain)
-		}
-	}
-	
-	// Fase 2: Coordenação - compartilha análises entre agentes
-	fmt.Println("🤝 Compartilhando análises entre agentes...")
-	for _, domain := range domains {
-		if agent, exists := o.agents[domain]; exists {
-			coordinationPrompt := fmt.Sprintf(`
-Baseado nas análises de todos os domínios, implemente sua parte:
-
-Requisição original: "%s"
-
-Análises dos outros domínios:
-%s
-
-Agora IMPLEMENTE concretamente sua parte, considerando as interfaces necessárias.
-`, input, o.formatAnalysisResults(analysisResults, domain))
-			
-			result, err := agent.Execute(coordinationPrompt)
-			if err != nil {
-				fmt.Printf("❌ Erro na implementação do %s: %v\n", domain, err)
-				continue
-			}
-			fmt.Printf("✅ %s implementou sua parte\n", domain)
-			fmt.Println(result)
-			fmt.Println("---")
-		}
-	}
-	
-	// Fase 3: Validação - verifica se tudo está integrado
-	fmt.Println("🔍 Validando integração...")
-	return o.validateIntegration(input, domains)
-}
-
 func (o *Orchestrator) formatAnalysisResults(results map[string]string, excludeDomain string) string {
 	var formatted strings.Builder
-	for domain, analysis := range results {
+	for _, domain := range sortedKeys(results) {
 		if domain != excludeDomain {
-			formatted.WriteString(fmt.Sprintf("\n=== %s ===\n%s\n", domain, analysis))
+			formatted.WriteString(fmt.Sprintf("\n=== %s ===\n%s\n", domain, results[domain]))
 		}
 	}
 	return formatted.String()
 }
 
-func (o *Orchestrator) validateIntegration(input string, domains []string) error {
-	validationPrompt := fmt.Sprintf(`
-Valide se a implementação está completa e integrada:
-Requisição: "%s"
-Domínios implementados: %s
-
-Verifique:
-1. Todas as funcionalidades foram implementadas?
-2. As integrações entre domínios estão corretas?
-3. Há algum erro ou inconsistência?
-4. O sistema está funcional?
-`, input, strings.Join(domains, ", "))
-
-	cmd := exec.Command("q", "chat", validationPrompt)
-	output, err := cmd.Output()
-	if err != nil {
-		fmt.Printf("⚠️  Erro na validação: %v\n", err)
-		return nil
+// sortedKeys mantém os prompts determinísticos (necessário para replay de cassettes).
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-	
-	fmt.Println("🔍 Validação final:")
-	fmt.Println(string(output))
-	return nil
+	sort.Strings(keys)
+	return keys
 }
 
 func (o *Orchestrator) createNewProject(input string) error {
-	fmt.Println("🏗️  Gerando projeto com Amazon Q CLI...")
+	fmt.Printf("🏗️  Gerando projeto com %s...\n", backend.Default().Name())
 	
-	// Primeiro, deixa o Amazon Q CLI gerar a estrutura do projeto
-	cmd := exec.Command("q", "chat", input)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	// Primeiro, deixa o backend gerar a estrutura do projeto
+	if err := streamToStdout(context.Background(), "project_creator", input); err != nil {
 		return err
 	}
 	
@@ -318,38 +336,34 @@ func (o *Orchestrator) looksLikeBoundedContext(path string) bool {
 }
 
 func (o *Orchestrator) setupAgentInExistingStructure(domain, context, description string) error {
-	var agentPath string
-	
-	if context == "main" {
-		// Agente no nível do domínio
-		agentPath = filepath.Join(o.workingDir, domain, "agents")
-	} else {
-		// Agente no bounded context
-		agentPath = filepath.Join(o.workingDir, domain, context, "agents")
+	// Agente no nível do domínio ou no bounded context
+	agentDomain := domain
+	if context != "main" {
+		agentDomain = domain + "/" + context
 	}
 	
-	if err := os.MkdirAll(agentPath, 0755); err != nil {
-		return err
-	}
+	instructions := prompts.Render("agent_instructions", map[string]interface{}{
+		"Domain":      domain,
+		"Context":     context,
+		"Description": description,
+	})
 	
-	instructions := fmt.Sprintf("Especialista em %s/%s: %s\n\nResponsável por implementar e manter funcionalidades específicas deste bounded context.", domain, context, description)
-	instructionsFile := filepath.Join(agentPath, "instructions.txt")
-	
-	return os.WriteFile(instructionsFile, []byte(instructions), 0644)
+	return saveAgent(o.workingDir, manifest.New(o.workingDir, agentDomain, instructions))
 }
 
 func (o *Orchestrator) createBoundedContextStructure(domain, context, description string) error {
-	contextPath := filepath.Join(o.workingDir, domain, context)
-	agentPath := filepath.Join(contextPath, "agents")
+	instructions := fmt.Sprintf("Especialista em %s/%s: %s", domain, context, description)
 	
-	if err := os.MkdirAll(agentPath, 0755); err != nil {
+	return saveAgent(o.workingDir, manifest.New(o.workingDir, domain+"/"+context, instructions))
+}
+
+// saveAgent grava o manifesto de um agente novo só se o diretório dele estiver nas suas permissões de escrita;
+// domínios sugeridos pelo modelo como "../x" viram uma *sandbox.Violation em vez de arquivos fora do projeto.
+func saveAgent(root string, m *manifest.Manifest) error {
+	if _, err := m.Policy(root).CheckWrite(m.Dir); err != nil {
 		return err
 	}
-	
-	instructions := fmt.Sprintf("Especialista em %s/%s: %s", domain, context, description)
-	instructionsFile := filepath.Join(agentPath, "instructions.txt")
-	
-	return os.WriteFile(instructionsFile, []byte(instructions), 0644)
+	return manifest.Save(m)
 }
 
 func (o *Orchestrator) selectAgent(input string, domains []string) string {
@@ -0,0 +1,266 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plaxo-orchestra/internal/agent"
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/patch"
+	"plaxo-orchestra/internal/sandbox"
+	"plaxo-orchestra/internal/usage"
+	"plaxo-orchestra/internal/workflow"
+	"strings"
+)
+
+// reviewAndApply extrai as alterações propostas na resposta do agente, confere as permissões de escrita, pede revisão e aplica as aceitas.
+// Na retomada de uma escrita escalada, a proposta gravada com o pedido de aprovação é usada no lugar da extraída.
+func reviewAndApply(ctx context.Context, root, domain, output string, reviewer patch.Reviewer) error {
+	var proposal *patch.Proposal
+	var err error
+	if pending := pendingWrite(ctx, domain); pending != nil && pending.Output == output {
+		proposal = pending.Proposal
+	} else {
+		proposal, err = patch.Propose(root, domain, output)
+	}
+	var violation *sandbox.Violation
+	if errors.As(err, &violation) {
+		sandbox.Report(violation)
+		return violation
+	}
+	if err != nil {
+		return fmt.Errorf("alterações de %s ignoradas: %v", domain, err)
+	}
+	if proposal.Empty() {
+		return nil
+	}
+
+	fmt.Printf("\n📝 %s propôs alterações em %d arquivos (%d trechos):\n", domain, len(proposal.Files), proposal.HunkCount())
+	fmt.Println(patch.Summary(proposal))
+
+	if err := enforcePermissions(ctx, root, output, proposal); err != nil {
+		return err
+	}
+	if proposal.Empty() {
+		fmt.Printf("⏭️  Nenhuma alteração de %s dentro das permissões\n", domain)
+		return nil
+	}
+
+	accepted := patch.Review(proposal, reviewer)
+	if accepted.Empty() {
+		fmt.Printf("⏭️  Nenhuma alteração de %s aplicada\n", domain)
+		return nil
+	}
+
+	record, err := patch.Apply(root, accepted)
+	if err != nil {
+		return fmt.Errorf("erro aplicando alterações de %s: %v", domain, err)
+	}
+
+	fmt.Printf("✅ %d trechos aplicados em %d arquivos (desfazer: orchestra patches undo %s)\n", accepted.HunkCount(), len(record.Files), record.ID)
+	return nil
+}
+
+// enforcePermissions confere cada arquivo da proposta contra as permissões de escrita do agente.
+// Sem escalação na execução, a primeira violação barra a proposta; com ela, só os arquivos liberados continuam.
+func enforcePermissions(ctx context.Context, root, output string, proposal *patch.Proposal) error {
+	policy := writePolicy(root, proposal.Domain)
+
+	var violations []*sandbox.Violation
+	for _, file := range proposal.Files {
+		if _, err := policy.CheckWrite(file.Path); err != nil {
+			var violation *sandbox.Violation
+			if !errors.As(err, &violation) {
+				return err
+			}
+			fmt.Printf("🚫 %v\n", violation)
+			violations = append(violations, violation)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	escalation := sandbox.From(ctx)
+	if escalation == nil {
+		return violations[0]
+	}
+	pending, err := json.Marshal(escalatedWrite{Output: output, Proposal: proposal})
+	if err != nil {
+		return err
+	}
+	allowed, err := escalation.Escalate(ctx, violations, pending)
+	if err != nil {
+		return err
+	}
+
+	denied := make(map[string]bool)
+	for _, violation := range violations {
+		denied[violation.Path] = true
+	}
+	for _, violation := range allowed {
+		delete(denied, violation.Path)
+	}
+	var kept []*patch.FilePatch
+	for _, file := range proposal.Files {
+		if !denied[file.Path] {
+			kept = append(kept, file)
+		}
+	}
+	proposal.Files = kept
+	return nil
+}
+
+// writePolicy é a política do agente segundo o manifesto; sem manifesto, ele só escreve no próprio domínio.
+func writePolicy(root, domain string) sandbox.Policy {
+	if m, err := manifest.Find(root, domain); err == nil {
+		return m.Policy(root)
+	}
+	return sandbox.Policy{Agent: domain, Root: root, Read: []string{domain}, Write: []string{domain}}
+}
+
+// escalatedWrite é gravado com o pedido de escalada: a retomada aplica exatamente esta proposta,
+// sem pedir ao agente uma resposta nova que não foi a aprovada.
+type escalatedWrite struct {
+	Output   string          `json:"output"`
+	Proposal *patch.Proposal `json:"proposal"`
+}
+
+// pendingWrite devolve a escrita do agente que a etapa atual deixou escalada, ou nil.
+func pendingWrite(ctx context.Context, agent string) *escalatedWrite {
+	escalation := sandbox.From(ctx)
+	if escalation == nil {
+		return nil
+	}
+	data, ok := escalation.Pending(ctx, agent)
+	if !ok {
+		return nil
+	}
+	var pending escalatedWrite
+	if json.Unmarshal(data, &pending) != nil || pending.Proposal == nil {
+		return nil
+	}
+	return &pending
+}
+
+// executeOrResume executa a tarefa no agente, a menos que a etapa esteja retomando uma escrita escalada dele:
+// aí devolve a resposta gravada com o pedido, cuja proposta reviewAndApply aplica.
+func executeOrResume(ctx context.Context, a *agent.Agent, task string) (string, error) {
+	if pending := pendingWrite(ctx, a.Domain); pending != nil {
+		fmt.Printf("▶️  %s: retomando a escrita escalada\n", a.Domain)
+		return pending.Output, nil
+	}
+	return a.ExecuteContext(ctx, task)
+}
+
+// escalation leva as escritas fora das permissões à aprovação da execução quando o orchestra.yaml pede
+// "sandbox: escalate"; nil mantém a falha da etapa.
+func escalation(cfg workflow.Config, gate approval.Gate, root string, run *workflow.Run) sandbox.Escalation {
+	if cfg.Sandbox != workflow.SandboxEscalate {
+		return nil
+	}
+	return &runEscalation{gate: gate, root: root, run: run}
+}
+
+type runEscalation struct {
+	gate approval.Gate
+	root string
+	run  *workflow.Run
+}
+
+func (e *runEscalation) Escalate(ctx context.Context, violations []*sandbox.Violation, pending []byte) ([]*sandbox.Violation, error) {
+	agent := violations[0].Agent
+	req := &approval.Request{
+		Run:        e.run.ID,
+		Step:       sandboxStep(usage.StepFrom(ctx), agent, violations),
+		Title:      fmt.Sprintf("%s quer escrever fora das suas permissões", agent),
+		Attachment: pending,
+	}
+	for _, violation := range violations {
+		req.Items = append(req.Items, &approval.Item{Key: violation.Path, Content: violation.Error()})
+	}
+
+	decided, err := e.gate.Decide(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// Decidida, a escrita segue agora; uma nova escalada na mesma etapa é outra resposta do agente
+	if decided.Attachment != nil {
+		decided.Attachment = nil
+		if err := approval.Save(e.root, decided); err != nil {
+			return nil, err
+		}
+	}
+
+	var allowed []*sandbox.Violation
+	var rejected *sandbox.Violation
+	for _, violation := range violations {
+		item := decided.Item(violation.Path)
+		approved := item != nil && item.Decision == approval.Approved
+		sandbox.Escalated(violation, approved)
+		switch {
+		case approved:
+			allowed = append(allowed, violation)
+		case item != nil && item.Decision == approval.Rejected && rejected == nil:
+			rejected = violation
+		}
+	}
+	if rejected != nil {
+		return nil, rejected
+	}
+	return allowed, nil
+}
+
+func (e *runEscalation) Pending(ctx context.Context, agent string) ([]byte, bool) {
+	paths, _ := filepath.Glob(approval.Path(e.root, e.run.ID, sandboxPrefix(usage.StepFrom(ctx), agent)+"*"))
+	for _, path := range paths {
+		req, err := approval.Load(e.root, e.run.ID, strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err == nil && req.Attachment != nil {
+			return req.Attachment, true
+		}
+	}
+	return nil, false
+}
+
+// sandboxStep identifica o pedido de aprovação pela etapa, pelo agente e pelos arquivos,
+// para que a retomada encontre a decisão já tomada.
+func sandboxStep(step, agent string, violations []*sandbox.Violation) string {
+	var paths []string
+	for _, violation := range violations {
+		paths = append(paths, violation.Path)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(paths, "\n")))
+	return fmt.Sprintf("%s%x", sandboxPrefix(step, agent), sum[:4])
+}
+
+func sandboxPrefix(step, agent string) string {
+	if step == "" {
+		step = "step"
+	}
+	return strings.ReplaceAll(fmt.Sprintf("%s-sandbox-%s-", step, agent), "/", "-")
+}
+
+// halts indica se o erro da revisão deve interromper a etapa em vez de virar aviso:
+// violação das permissões ou escalação aguardando aprovação.
+func halts(err error) bool {
+	var violation *sandbox.Violation
+	return errors.As(err, &violation) || errors.Is(err, workflow.ErrWaiting)
+}
+
+// humanError mantém a pausa de uma aprovação pendente; qualquer outra falha da decisão humana
+// não é repetida pelo Engine, que perguntaria tudo de novo.
+func humanError(err error) error {
+	if errors.Is(err, workflow.ErrWaiting) {
+		return err
+	}
+	return workflow.Permanent(err)
+}
+
+func defaultReviewer() patch.Reviewer {
+	return patch.NewTerminalReviewer(os.Stdin, os.Stdout)
+}
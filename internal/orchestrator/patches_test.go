@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"plaxo-orchestra/internal/agent"
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/patch"
+	"plaxo-orchestra/internal/sandbox"
+	"plaxo-orchestra/internal/usage"
+	"plaxo-orchestra/internal/workflow"
+)
+
+func TestEscalatedWriteResumes(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"auth", "products"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := filepath.Join(root, "products", "list.go")
+	if err := os.WriteFile(list, []byte("package products\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := workflow.NewRun(root, workflow.RunKindCoordination, "pedido", workflow.New("pedido", &workflow.Step{ID: "implement-auth", Agent: "auth"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := workflow.Config{Sandbox: workflow.SandboxEscalate}
+	ctx := usage.WithStep(context.Background(), "implement-auth")
+	ctx = sandbox.With(ctx, escalation(cfg, approval.FileGate{Root: root}, root, run))
+	reviewer := patch.AutoReviewer{Accept: true}
+
+	output := "FILE: products/list.go\n```go\npackage products\n\nfunc List() {}\n```\n"
+	if err := reviewAndApply(ctx, root, "auth", output, reviewer); !errors.Is(err, workflow.ErrWaiting) {
+		t.Fatalf("esperava aguardar aprovação, veio %v", err)
+	}
+
+	// Um agente sem backend falharia se fosse chamado de novo
+	idle := &agent.Agent{Name: "auth", Domain: "auth"}
+	resumed, err := executeOrResume(ctx, idle, "tarefa")
+	if err != nil || resumed != output {
+		t.Fatalf("retomada = %q, %v", resumed, err)
+	}
+	if err := reviewAndApply(ctx, root, "auth", resumed, reviewer); !errors.Is(err, workflow.ErrWaiting) {
+		t.Fatalf("sem decisão a retomada deve continuar aguardando, veio %v", err)
+	}
+
+	paths, _ := filepath.Glob(approval.Path(root, run.ID, "implement-auth-sandbox-auth-*"))
+	if len(paths) != 1 {
+		t.Fatalf("pedidos de aprovação = %v", paths)
+	}
+	req, err := approval.Load(root, run.ID, strings.TrimSuffix(filepath.Base(paths[0]), ".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Set(approval.Approved)
+	if err := approval.Save(root, req); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err = executeOrResume(ctx, idle, "tarefa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reviewAndApply(ctx, root, "auth", resumed, reviewer); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(list); !strings.Contains(string(data), "func List()") {
+		t.Errorf("proposta aprovada não aplicada:\n%s", data)
+	}
+	if pendingWrite(ctx, "auth") != nil {
+		t.Error("escrita aplicada continua pendente")
+	}
+}
+
+func TestEscalationDisabled(t *testing.T) {
+	if escalation(workflow.Config{Sandbox: workflow.SandboxFail}, approval.FileGate{}, "", nil) != nil {
+		t.Error("sandbox: fail não deveria escalar")
+	}
+
+	root := t.TempDir()
+	output := "FILE: products/list.go\n```go\npackage products\n```\n"
+	var violation *sandbox.Violation
+	if err := reviewAndApply(context.Background(), root, "auth", output, patch.AutoReviewer{Accept: true}); !errors.As(err, &violation) {
+		t.Fatalf("esperava *sandbox.Violation, veio %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "products", "list.go")); !os.IsNotExist(err) {
+		t.Error("escrita barrada foi aplicada")
+	}
+}
@@ -0,0 +1,168 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/patch"
+	"plaxo-orchestra/internal/workflow"
+)
+
+// model é o modelo falso usado para gravar as cassettes: responde com a primeira regra do agente
+// cujo trecho aparece no fim do prompt.
+type model struct {
+	mutex sync.Mutex
+	rules []rule
+}
+
+type rule struct {
+	agent    string
+	contains string
+	response string
+}
+
+func (m *model) Name() string { return "model" }
+
+func (m *model) Complete(ctx context.Context, req backend.Request) (*backend.Response, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	last := req.Prompt
+	if i := strings.LastIndex(last, "Nova mensagem:"); i != -1 {
+		last = last[i:]
+	}
+	for _, r := range m.rules {
+		if r.agent == req.AgentID && strings.Contains(last, r.contains) {
+			return &backend.Response{Content: r.response, Usage: backend.EstimateUsage(req.Prompt, r.response)}, nil
+		}
+	}
+	return &backend.Response{Content: "ok", Usage: backend.EstimateUsage(req.Prompt, "ok")}, nil
+}
+
+func (m *model) Stream(ctx context.Context, req backend.Request, onChunk func(string)) (*backend.Response, error) {
+	response, err := m.Complete(ctx, req)
+	if err == nil {
+		onChunk(response.Content)
+	}
+	return response, err
+}
+
+func (m *model) Cancel() {}
+
+// useCassette responde com testdata/cassettes/<name>.json e exige que todas as interações sejam usadas.
+// Com PLAXO_RECORD=1, grava a cassette de novo a partir do modelo falso.
+func useCassette(t *testing.T, name string, fake *model) {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("testdata", "cassettes", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("PLAXO_RECORD") != "" {
+		os.Remove(path)
+		useBackend(t, backend.NewRecordingBackend(fake, path))
+		return
+	}
+
+	cassette, err := backend.LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := backend.NewReplayBackend(cassette)
+	useBackend(t, replay)
+	t.Cleanup(func() {
+		if remaining := replay.Remaining(); remaining > 0 && !t.Failed() {
+			t.Errorf("%d interações da cassette %s não foram usadas", remaining, name)
+		}
+	})
+}
+
+// fixture cria um projeto com os domínios auth e user, cada um com seu manifesto.
+func fixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"auth/agents/agent.yaml": "version: 1\ndomain: auth\ninstructions: cuida de login e sessões\n",
+		"auth/login.go":          "package auth\n\nfunc Login(user, password string) bool {\n\treturn false\n}\n",
+		"user/agents/agent.yaml": "version: 1\ndomain: user\ninstructions: cuida do cadastro e do perfil\n",
+		"user/profile.go":        "package user\n\ntype Profile struct {\n\tID string\n}\n",
+		"go.mod":                 "module loja\n\ngo 1.21\n",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+const (
+	authChange = "Login passa a conferir a senha.\nFILE: auth/login.go\n```go\npackage auth\n\nfunc Login(user, password string) bool {\n\treturn password != \"\"\n}\n```\n@decision user-id: string\n"
+	userChange = "Perfil ganha o e-mail.\nFILE: user/profile.go\n```go\npackage user\n\ntype Profile struct {\n\tID    string\n\tEmail string\n}\n```\n"
+)
+
+func TestReplayProcessWithIntelligence(t *testing.T) {
+	useCassette(t, "process_with_intelligence", &model{rules: []rule{
+		{"semantic_analyzer", "Requisição", `{"intent":"modify","entities":["login","perfil"],"domains":["auth","user"],"complexity":"medium","keywords":{"login":0.9,"perfil":0.7}}`},
+		{"workflow_planner", "Requisição", `{"steps":[{"id":"auth-login","agent":"auth","action":"conferir a senha no login","depends_on":[],"output":"login"},{"id":"user-email","agent":"user","action":"adicionar e-mail ao perfil","depends_on":["auth-login"],"output":"perfil"}]}`},
+		{"auth", "conferir a senha", authChange},
+		{"user", "adicionar e-mail", userChange},
+		{"integration_validator", "auth", `{"status":"pass","summary":"login e perfil integrados","issues":[]}`},
+	}})
+
+	root := fixture(t)
+	eo := NewEnhancedOrchestrator(root)
+	eo.SetReviewer(patch.AutoReviewer{Accept: true})
+	eo.SetGate(approval.FileGate{Root: root})
+	defer eo.Close()
+
+	if err := eo.ProcessWithIntelligence(context.Background(), "conferir a senha no login e guardar o e-mail no perfil"); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := workflow.ListRuns(root)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("execuções = %v, %v", runs, err)
+	}
+	if runs[0].Status != workflow.StatusSucceeded {
+		t.Errorf("execução %s", runs[0].Status)
+	}
+	assertFile(t, root, "auth/login.go", `password != ""`)
+	assertFile(t, root, "user/profile.go", "Email string")
+}
+
+func TestReplaySmartProcess(t *testing.T) {
+	useCassette(t, "smart_process", &model{rules: []rule{
+		{"semantic_analyzer", "Requisição", `{"intent":"integrate","entities":["login","perfil"],"domains":["auth","user"],"complexity":"complex","keywords":{"login":0.9}}`},
+		{"workflow_planner", "Requisição", `{"steps":[{"id":"auth-login","agent":"auth","action":"expor o ID do usuário no login","depends_on":[],"output":"ID"},{"id":"user-profile","agent":"user","action":"carregar o perfil pelo ID do login","depends_on":["auth-login"],"output":"perfil"}]}`},
+		{"auth", "expor o ID", "Login devolve o ID do usuário.\n@decision user-id: string"},
+		{"user", "carregar o perfil", "Perfil carregado pelo ID string vindo do auth."},
+	}})
+
+	root := fixture(t)
+	so := NewSmart(root)
+	defer so.agentPool.Close()
+
+	if err := so.Process("integrar o login com o perfil do usuário"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFile(t *testing.T, root, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), want) {
+		t.Errorf("%s sem %q:\n%s", path, want, data)
+	}
+}
@@ -1,14 +1,18 @@
 package orchestrator
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"plaxo-orchestra/internal/agent"
 	"plaxo-orchestra/internal/analyzer"
 	"plaxo-orchestra/internal/detector"
+	"plaxo-orchestra/internal/manifest"
 	"plaxo-orchestra/internal/intelligence"
 	"plaxo-orchestra/internal/pool"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/workflow"
 	"strings"
 )
 
@@ -22,11 +26,14 @@ type SmartOrchestrator struct {
 }
 
 func NewSmart(workingDir string) *SmartOrchestrator {
+	coordinator := intelligence.NewCoordinator()
+	coordinator.SetWorkflowConfig(workflow.LoadConfig(workingDir))
+	
 	return &SmartOrchestrator{
 		workingDir:  workingDir,
 		agents:      make(map[string]*agent.Agent),
 		semantic:    intelligence.NewSemanticAnalyzer(),
-		coordinator: intelligence.NewCoordinator(),
+		coordinator: coordinator,
 		learning:    intelligence.NewLearningSystem(workingDir),
 		agentPool:   pool.NewAgentPool(),
 	}
@@ -156,23 +163,23 @@ func (o *SmartOrchestrator) executeSmartWorkflow(input string, domains []string,
 	fmt.Println("🔗 Executando workflow inteligente...")
 
 	// Planeja workflow baseado na análise semântica
-	workflow, err := o.coordinator.PlanWorkflow(input, domains)
+	plan, err := o.coordinator.PlanWorkflow(input, analysis, domains)
 	if err != nil {
 		fmt.Printf("⚠️  Erro no planejamento, usando coordenação básica: %v\n", err)
 		return o.coordinateBasic(input, domains)
 	}
 
-	fmt.Printf("📋 Workflow planejado com %d etapas\n", len(workflow.Steps))
+	fmt.Printf("📋 Workflow planejado com %d etapas\n", len(plan.Steps))
 
 	// Executa workflow
-	agentExecutor := func(agentName, prompt string) (string, error) {
+	agentExecutor := func(ctx context.Context, agentName, prompt string) (string, error) {
 		if agent, exists := o.agents[agentName]; exists {
-			return agent.Execute(prompt)
+			return agent.ExecuteContext(ctx, prompt)
 		}
 		return "", fmt.Errorf("agente %s não encontrado", agentName)
 	}
 
-	return o.coordinator.ExecuteWorkflow(workflow, agentExecutor)
+	return o.coordinator.ExecuteWorkflow(context.Background(), plan, agentExecutor)
 }
 
 func (o *SmartOrchestrator) createSmartProject(input string, analysis *intelligence.SemanticResult) error {
@@ -292,45 +299,26 @@ func (o *SmartOrchestrator) generateSmartDescription(domain, context string, ana
 }
 
 func (o *SmartOrchestrator) setupSmartAgent(bc analyzer.BoundedContext, analysis *intelligence.SemanticResult) error {
-	var agentPath string
-	
-	if bc.Context == "main" {
-		agentPath = filepath.Join(o.workingDir, bc.Domain, "agents")
-	} else {
-		agentPath = filepath.Join(o.workingDir, bc.Domain, bc.Context, "agents")
-	}
-	
-	if err := os.MkdirAll(agentPath, 0755); err != nil {
-		return err
+	domain := bc.Domain
+	if bc.Context != "main" {
+		domain = bc.Domain + "/" + bc.Context
 	}
 
 	// Gera instruções inteligentes baseadas na análise
 	instructions := o.generateSmartInstructions(bc, analysis)
-	instructionsFile := filepath.Join(agentPath, "instructions.txt")
 	
-	return os.WriteFile(instructionsFile, []byte(instructions), 0644)
+	return saveAgent(o.workingDir, manifest.New(o.workingDir, domain, instructions))
 }
 
 func (o *SmartOrchestrator) generateSmartInstructions(bc analyzer.BoundedContext, analysis *intelligence.SemanticResult) string {
-	instructions := fmt.Sprintf(`%s
-
-RESPONSABILIDADES:
-- Implementar funcionalidades específicas de %s/%s
-- Manter consistência com outros bounded contexts
-- Seguir padrões de arquitetura limpa
-
-CONTEXTO DA REQUISIÇÃO:
-- Intent: %s
-- Entidades relevantes: %s
-- Complexidade: %s
-
-DIRETRIZES:
-- Sempre considere integrações com outros domínios
-- Implemente testes quando apropriado
-- Documente APIs e contratos
-- Siga princípios SOLID e DDD
-`, bc.Description, bc.Domain, bc.Context, 
-   analysis.Intent, strings.Join(analysis.Entities, ", "), analysis.Complexity)
+	instructions := prompts.Render("smart_instructions", map[string]interface{}{
+		"Description": bc.Description,
+		"Domain":      bc.Domain,
+		"Context":     bc.Context,
+		"Intent":      analysis.Intent,
+		"Entities":    analysis.Entities,
+		"Complexity":  analysis.Complexity,
+	})
 
 	return instructions
 }
@@ -374,11 +362,11 @@ func (o *SmartOrchestrator) executeAgent(agentPath, input string) (string, error
 	agentID := strings.ReplaceAll(agentPath, "/", "_")
 	
 	// Contexto específico do agente
-	contextPrompt := fmt.Sprintf(`Você é um agente especializado em: %s
-Diretório de trabalho: %s
-Contexto do agente: %s
-
-Requisição: %s`, agentPath, o.workingDir, agentPath, input)
+	contextPrompt := prompts.Render("agent_context", map[string]interface{}{
+		"AgentPath":  agentPath,
+		"WorkingDir": o.workingDir,
+		"Input":      input,
+	})
 	
 	return o.agentPool.Execute(agentID, contextPrompt)
 }
@@ -1,9 +1,10 @@
 package orchestrator
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"plaxo-orchestra/internal/prompts"
 )
 
 func (o *Orchestrator) InitFromSpec(specFile string) error {
@@ -16,20 +17,13 @@ func (o *Orchestrator) InitFromSpec(specFile string) error {
 		return err
 	}
 
-	prompt := fmt.Sprintf(`
-Baseado nesta especificação, crie um projeto completo:
-
-%s
-
-Gere toda a estrutura de pastas, código e documentação necessária.
-`, string(content))
+	prompt := prompts.Render("project_from_spec", map[string]interface{}{
+		"Spec": string(content),
+	})
 
 	fmt.Println("🏗️ Gerando projeto baseado na especificação...")
 	
-	cmd := exec.Command("q", "chat", prompt)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := streamToStdout(context.Background(), "project_creator", prompt); err != nil {
 		return err
 	}
 
@@ -0,0 +1,161 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"plaxo-orchestra/internal/agent"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/blackboard"
+	"plaxo-orchestra/internal/budget"
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/structured"
+	"plaxo-orchestra/internal/workflow"
+)
+
+// Routing é a escolha do supervisor sobre quais domínios atendem o pedido.
+type Routing struct {
+	Domains []string `json:"domains" minitems:"1" desc:"domínios que devem participar, entre os disponíveis"`
+	Reason  string   `json:"reason" desc:"por que esses domínios"`
+}
+
+// Settlement é a decisão do supervisor para um conflito entre domínios no quadro da execução.
+type Settlement struct {
+	Kind  string `json:"kind" enum:"decision,type" desc:"tipo da anotação em conflito"`
+	Key   string `json:"key" desc:"chave da anotação"`
+	Value string `json:"value" desc:"valor que passa a valer para todos os domínios"`
+}
+
+// loadSupervisor carrega o agente de orchestra_agents/ na primeira chamada; nil em projetos sem supervisor.
+// Ele tem instruções e memória próprias como os agentes de domínio; roteamento, plano, revisão e resumo
+// usam agent.Complete, e cada decisão final fica uma vez na memória dele.
+func (o *Orchestrator) loadSupervisor() *agent.Agent {
+	o.supervisorOnce.Do(func() {
+		if _, err := os.Stat(filepath.Join(o.workingDir, manifest.OrchestratorDir)); err != nil {
+			return
+		}
+		m, err := manifest.Supervisor(o.workingDir)
+		if err != nil {
+			fmt.Printf("⚠️  Erro carregando supervisor: %v\n", err)
+			return
+		}
+		supervisor := agent.NewAgent(m.Domain, o.workingDir, o.agentPool)
+		supervisor.SetManifest(m)
+		o.supervisor = supervisor
+		fmt.Printf("🎼 Supervisor: %s\n", m.Name)
+	})
+	return o.supervisor
+}
+
+// route pede ao supervisor os domínios que atendem o pedido; nil sem supervisor ou sem resposta válida.
+func (o *Orchestrator) route(ctx context.Context, input string, domains []string) []string {
+	supervisor := o.loadSupervisor()
+	if supervisor == nil {
+		return nil
+	}
+
+	var routing Routing
+	check := func() []string {
+		known := make(map[string]bool)
+		for _, domain := range domains {
+			known[domain] = true
+		}
+		var problems []string
+		for i, domain := range routing.Domains {
+			if !known[domain] {
+				problems = append(problems, fmt.Sprintf("$.domains[%d]: %q não está entre %s", i, domain, strings.Join(domains, ", ")))
+			}
+		}
+		return problems
+	}
+
+	prompt := prompts.Render("supervisor_routing", map[string]interface{}{
+		"Input":   input,
+		"Domains": domains,
+	})
+	request := structured.Request{Name: "supervisor_routing", Prompt: prompt, Check: check}
+	if err := structured.Generate(ctx, supervisor.Complete, request, &routing); err != nil {
+		structured.Fallback("supervisor_routing", err)
+		return nil
+	}
+	decision := fmt.Sprintf("Encaminhado para %s: %s", strings.Join(routing.Domains, ", "), routing.Reason)
+	supervisor.Remember(ctx, input, decision)
+	fmt.Printf("🎼 Supervisor: %s\n", decision)
+	return routing.Domains
+}
+
+// summarize pede ao supervisor o resumo final a partir das saídas das dependências da etapa.
+func (o *Orchestrator) summarize(ctx context.Context, input string, step *workflow.Step, inputs map[string]*workflow.Result) (string, error) {
+	supervisor := o.loadSupervisor()
+	if supervisor == nil {
+		return "", fmt.Errorf("supervisor não encontrado em %s", manifest.OrchestratorDir)
+	}
+
+	data := map[string]interface{}{
+		"Input":     input,
+		"Outputs":   nil,
+		"Conflicts": []blackboard.Entry{},
+	}
+	if board := blackboard.From(ctx); board != nil {
+		data["Conflicts"] = board.Conflicts()
+	}
+	overhead := backend.EstimateTokens(prompts.Render("supervisor_summary", data))
+
+	// O parecer da revisão ("validation" ou "integration_validation") pesa mais que as saídas dos domínios
+	builder := budget.NewBuilder(o.promptBudget() - overhead)
+	for _, dep := range step.DependsOn {
+		if result := inputs[dep]; result != nil && result.Succeeded() {
+			priority := 50
+			if strings.HasSuffix(dep, "validation") {
+				priority = 80
+			}
+			builder.Add(budget.Section{Name: dep, Content: result.Output, Priority: priority})
+		}
+	}
+	fitted, report := builder.Build()
+	if report.Changed() {
+		fmt.Printf("✂️  Contexto do resumo ajustado: %s\n", report)
+	}
+
+	var outputs []map[string]string
+	for _, dep := range step.DependsOn {
+		if output, ok := fitted[dep]; ok {
+			outputs = append(outputs, map[string]string{"Step": dep, "Agent": inputs[dep].Agent, "Output": output})
+		}
+	}
+	data["Outputs"] = outputs
+
+	result, err := supervisor.Complete(ctx, prompts.Render("supervisor_summary", data))
+	if err != nil {
+		return "", fmt.Errorf("erro no resumo do supervisor: %v", err)
+	}
+	supervisor.Remember(ctx, input, result)
+	fmt.Println("📝 Resumo do supervisor:")
+	fmt.Println(result)
+	return result, nil
+}
+
+// planDecision descreve, para a memória do supervisor, o plano que ele vai conduzir.
+func planDecision(wf *workflow.Workflow) string {
+	var steps []string
+	for _, step := range wf.Steps {
+		steps = append(steps, fmt.Sprintf("%s (%s)", step.ID, step.Agent))
+	}
+	return "Plano: " + strings.Join(steps, " → ")
+}
+
+// settle publica no quadro as decisões do supervisor para os conflitos entre domínios.
+func settle(ctx context.Context, settlements []Settlement) {
+	board := blackboard.From(ctx)
+	if board == nil {
+		return
+	}
+	for _, settlement := range settlements {
+		board.Settle(manifest.OrchestratorDomain, blackboard.Kind(settlement.Kind), settlement.Key, settlement.Value)
+		fmt.Printf("⚖️  Supervisor decidiu %s %s: %s\n", settlement.Kind, settlement.Key, settlement.Value)
+	}
+}
@@ -0,0 +1,59 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"plaxo-orchestra/internal/manifest"
+	"plaxo-orchestra/internal/memory"
+	"plaxo-orchestra/internal/workflow"
+)
+
+func TestSupervisorDecisions(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, manifest.OrchestratorDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifest.FileName), []byte("version: 1\ninstructions: coordena o projeto\ntools: [read_file]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := &scripted{responses: []string{
+		`{"domains":["billing"],"reason":"cobrança"}`,
+		`{"domains":["auth"],"reason":"login é do auth"}`,
+		"Login entregue pelo auth.",
+	}}
+	useBackend(t, script)
+	o := New(root)
+	ctx := memory.WithInput(context.Background(), "adicionar login")
+
+	if routed := o.route(ctx, "adicionar login", []string{"auth", "user"}); strings.Join(routed, ",") != "auth" {
+		t.Fatalf("domínios = %v", routed)
+	}
+	step := &workflow.Step{ID: "summary", DependsOn: []string{"auth"}}
+	inputs := map[string]*workflow.Result{"auth": {StepID: "auth", Agent: "auth", Status: workflow.StatusSucceeded, Output: "login feito"}}
+	if _, err := o.summarize(ctx, "adicionar login", step, inputs); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(script.prompts) != 3 {
+		t.Fatalf("%d chamadas, esperado 3", len(script.prompts))
+	}
+	for _, prompt := range script.prompts {
+		if !strings.Contains(prompt, "coordena o projeto") || strings.Contains(prompt, "Domain:") || strings.Contains(prompt, "@tool") {
+			t.Errorf("chamada do supervisor fora do formato direto:\n%s", prompt)
+		}
+	}
+
+	entries := o.loadSupervisor().Memory.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("%d lembranças, esperado uma por decisão: %+v", len(entries), entries)
+	}
+	if !strings.Contains(entries[0].Summary, "Encaminhado para auth") || entries[1].Task != "adicionar login" {
+		t.Errorf("lembranças = %+v", entries)
+	}
+}
@@ -0,0 +1,242 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"plaxo-orchestra/internal/approval"
+	"plaxo-orchestra/internal/backend"
+	"plaxo-orchestra/internal/blackboard"
+	"plaxo-orchestra/internal/budget"
+	"plaxo-orchestra/internal/memory"
+	"plaxo-orchestra/internal/prompts"
+	"plaxo-orchestra/internal/structured"
+	"plaxo-orchestra/internal/workflow"
+)
+
+// Verdict é o parecer estruturado da validação de integração.
+type Verdict struct {
+	Status  string  `json:"status" enum:"pass,fail" desc:"pass se a integração está completa e correta"`
+	Summary string  `json:"summary" desc:"resumo do parecer"`
+	Issues  []Issue `json:"issues" desc:"problemas encontrados; vazio quando pass"`
+	// Settlements resolvem os conflitos do quadro; vêm do supervisor, quando o projeto tem um
+	Settlements []Settlement `json:"settlements,omitempty" desc:"valor final de cada conflito listado entre domínios"`
+}
+
+// unverified é o status de um parecer que não pôde ser lido: nem aprova nem aponta domínios para corrigir.
+const unverified = "unknown"
+
+// errUnverified encerra a validação sem parecer; a execução não conta como sucesso nem como falha.
+var errUnverified = errors.New("integração sem parecer legível da validação")
+
+type Issue struct {
+	Domain      string `json:"domain" desc:"domínio que deve corrigir o problema"`
+	Description string `json:"description" desc:"o que está errado e como corrigir"`
+}
+
+func (v *Verdict) Passed() bool {
+	return v.Status == "pass"
+}
+
+// Domains devolve os domínios com problemas, ordenados.
+func (v *Verdict) Domains() []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, issue := range v.Issues {
+		if !seen[issue.Domain] {
+			seen[issue.Domain] = true
+			domains = append(domains, issue.Domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+func (v *Verdict) IssuesFor(domain string) []Issue {
+	var issues []Issue
+	for _, issue := range v.Issues {
+		if issue.Domain == domain {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func (v *Verdict) String() string {
+	if v.Passed() {
+		return "aprovada: " + v.Summary
+	}
+	if v.Status == unverified {
+		return "sem parecer: " + v.Summary
+	}
+	var parts []string
+	for _, issue := range v.Issues {
+		parts = append(parts, fmt.Sprintf("[%s] %s", issue.Domain, issue.Description))
+	}
+	return fmt.Sprintf("reprovada: %s (%s)", v.Summary, strings.Join(parts, "; "))
+}
+
+// validateIntegration pede um parecer sobre as implementações dos domínios ao supervisor do projeto ou, sem ele, ao validador.
+// O supervisor também decide os conflitos registrados no quadro da execução.
+func (o *Orchestrator) validateIntegration(ctx context.Context, input string, implementations map[string]string) (*Verdict, error) {
+	domains := sortedKeys(implementations)
+	supervisor := o.loadSupervisor()
+	data := map[string]interface{}{
+		"Input":           input,
+		"Domains":         domains,
+		"Implementations": nil,
+		"Conflicts":       []blackboard.Entry{},
+	}
+	if board := blackboard.From(ctx); board != nil && supervisor != nil {
+		data["Conflicts"] = board.Conflicts()
+	}
+	overhead := backend.EstimateTokens(prompts.Render("integration_validation", data))
+
+	builder := budget.NewBuilder(o.promptBudget() - overhead)
+	for _, domain := range domains {
+		builder.Add(budget.Section{Name: domain, Content: implementations[domain], Priority: 50})
+	}
+	fitted, report := builder.Build()
+	if report.Changed() {
+		fmt.Printf("✂️  Contexto da validação ajustado: %s\n", report)
+	}
+
+	var entries []map[string]string
+	for _, domain := range domains {
+		if output, ok := fitted[domain]; ok {
+			entries = append(entries, map[string]string{"Domain": domain, "Output": output})
+		}
+	}
+	data["Implementations"] = entries
+
+	var verdict Verdict
+	check := func() []string {
+		var problems []string
+		known := make(map[string]bool)
+		for _, domain := range domains {
+			known[domain] = true
+		}
+		for i, issue := range verdict.Issues {
+			if !known[issue.Domain] {
+				problems = append(problems, fmt.Sprintf("$.issues[%d].domain: %q não está entre %s", i, issue.Domain, strings.Join(domains, ", ")))
+			}
+		}
+		if !verdict.Passed() && len(verdict.Issues) == 0 {
+			problems = append(problems, "$.issues: status fail exige ao menos um problema")
+		}
+		return problems
+	}
+
+	complete := func(ctx context.Context, prompt string) (string, error) {
+		if supervisor != nil {
+			return supervisor.Complete(ctx, prompt)
+		}
+		return completeResult(ctx, "integration_validator", prompt)
+	}
+	request := structured.Request{Name: "integration_validation", Prompt: prompts.Render("integration_validation", data), Check: check}
+	if err := structured.Generate(ctx, complete, request, &verdict); err != nil {
+		var invalid *structured.ValidationError
+		if !errors.As(err, &invalid) {
+			return nil, fmt.Errorf("erro na validação: %v", err)
+		}
+		// Parecer ilegível não aprova a integração
+		structured.Fallback("integration_validation", err)
+		return &Verdict{Status: unverified, Summary: "parecer da validação indisponível"}, nil
+	}
+	settle(ctx, verdict.Settlements)
+	return &verdict, nil
+}
+
+// validateWithRetries valida as implementações aprovadas na coordenação.
+func (o *Orchestrator) validateWithRetries(ctx context.Context, input string, inputs map[string]*workflow.Result) (string, error) {
+	req, err := approval.Decode(inputs[approvalStep].Output)
+	if err != nil {
+		return "", err
+	}
+
+	implementations := make(map[string]string)
+	for _, domain := range analysisKeys(req, approval.Approved) {
+		if result := inputs["implementation."+domain]; result != nil && result.Succeeded() {
+			implementations[domain] = result.Output
+		}
+	}
+	if len(implementations) == 0 {
+		return "", nil
+	}
+
+	verdict, err := o.validateUntilPass(ctx, input, implementations)
+	if err != nil {
+		return "", err
+	}
+	data, _ := json.MarshalIndent(verdict, "", "  ")
+	return string(data), nil
+}
+
+// validateUntilPass valida e reexecuta os domínios apontados até aprovar ou esgotar validation_retries.
+func (o *Orchestrator) validateUntilPass(ctx context.Context, input string, implementations map[string]string) (*Verdict, error) {
+	limit := workflow.LoadConfig(o.workingDir).ValidationRetries
+	for round := 0; ; round++ {
+		verdict, err := o.validateIntegration(ctx, input, implementations)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Printf("🔍 Validação %s\n", verdict)
+		if verdict.Passed() {
+			return verdict, nil
+		}
+		if verdict.Status == unverified {
+			// Sem domínios apontados não há o que corrigir; retomar a execução valida de novo
+			return nil, workflow.Permanent(errUnverified)
+		}
+		if round >= limit {
+			return nil, workflow.Permanent(fmt.Errorf("integração %s após %d rodadas de correção", verdict, round))
+		}
+
+		fmt.Printf("🔁 Correção %d/%d: %s\n", round+1, limit, strings.Join(verdict.Domains(), ", "))
+		for _, domain := range verdict.Domains() {
+			output, err := o.fix(ctx, input, domain, implementations[domain], verdict.IssuesFor(domain))
+			if err != nil {
+				fmt.Printf("⚠️  %v\n", err)
+				continue
+			}
+			implementations[domain] = output
+		}
+	}
+}
+
+// fix reexecuta a implementação de um domínio com os problemas apontados pela validação.
+func (o *Orchestrator) fix(ctx context.Context, input, domain, previous string, issues []Issue) (string, error) {
+	agent, exists := o.agents[domain]
+	if !exists {
+		return "", fmt.Errorf("agente %s não carregado", domain)
+	}
+
+	fixPrompt := prompts.Render("coordination_fix", map[string]interface{}{
+		"Input":    input,
+		"Domain":   domain,
+		"Previous": previous,
+		"Issues":   issues,
+	})
+
+	result, err := executeOrResume(memory.WithAction(ctx, "correction"), agent, fixPrompt)
+	if err != nil {
+		return "", fmt.Errorf("erro na correção do %s: %v", domain, err)
+	}
+
+	fmt.Printf("🛠️  %s corrigiu sua parte\n", domain)
+	fmt.Println(result)
+	fmt.Println("---")
+
+	if err := reviewAndApply(ctx, o.workingDir, domain, result, o.reviewer); err != nil {
+		if halts(err) {
+			return "", err
+		}
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	return result, nil
+}
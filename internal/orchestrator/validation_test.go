@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"plaxo-orchestra/internal/backend"
+)
+
+// scripted responde às chamadas com as respostas na ordem dada e repete a última quando acabam.
+type scripted struct {
+	mutex     sync.Mutex
+	responses []string
+	prompts   []string
+}
+
+func (s *scripted) Name() string { return "scripted" }
+
+func (s *scripted) Complete(ctx context.Context, req backend.Request) (*backend.Response, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.prompts = append(s.prompts, req.Prompt)
+	response := s.responses[0]
+	if len(s.responses) > 1 {
+		s.responses = s.responses[1:]
+	}
+	return &backend.Response{Content: response, Usage: backend.EstimateUsage(req.Prompt, response)}, nil
+}
+
+func (s *scripted) Stream(ctx context.Context, req backend.Request, onChunk func(string)) (*backend.Response, error) {
+	response, err := s.Complete(ctx, req)
+	if err == nil {
+		onChunk(response.Content)
+	}
+	return response, err
+}
+
+func (s *scripted) Cancel() {}
+
+func useBackend(t *testing.T, b backend.Backend) {
+	t.Helper()
+	backend.SetDefault(b)
+	t.Cleanup(func() { backend.SetDefault(nil) })
+}
+
+func TestValidateUntilPass(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		passed    bool
+		err       error
+		calls     int
+	}{
+		{
+			name:      "aprovada",
+			responses: []string{`{"status":"pass","summary":"ok","issues":[]}`},
+			passed:    true,
+			calls:     1,
+		},
+		{
+			name:      "parecer ilegível não aprova",
+			responses: []string{"não consegui avaliar"},
+			err:       errUnverified,
+			calls:     3,
+		},
+		{
+			name:      "reprovada após as rodadas de correção",
+			responses: []string{`{"status":"fail","summary":"faltou","issues":[{"domain":"auth","description":"sem teste"}]}`},
+			calls:     3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			script := &scripted{responses: test.responses}
+			useBackend(t, script)
+
+			o := New(t.TempDir())
+			verdict, err := o.validateUntilPass(context.Background(), "pedido", map[string]string{"auth": "feito"})
+			if test.passed {
+				if err != nil || !verdict.Passed() {
+					t.Fatalf("parecer = %v, erro %v", verdict, err)
+				}
+			} else if err == nil {
+				t.Fatalf("esperava erro, parecer %v", verdict)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("erro = %v, esperado %v", err, test.err)
+			}
+			if len(script.prompts) != test.calls {
+				t.Errorf("%d chamadas, esperado %d", len(script.prompts), test.calls)
+			}
+		})
+	}
+}
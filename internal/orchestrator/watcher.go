@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"plaxo-orchestra/internal/prompts"
 	"strings"
 	"time"
 )
@@ -78,7 +79,7 @@ func (o *Orchestrator) handleFileChange(filePath string) {
 	}
 
 	if agent, exists := o.agents[domain]; exists {
-		prompt := fmt.Sprintf("Arquivo %s foi modificado. Revise se há problemas ou melhorias necessárias.", filePath)
+		prompt := prompts.Render("file_review", map[string]interface{}{"File": filePath})
 		
 		result, err := agent.Execute(prompt)
 		if err != nil {
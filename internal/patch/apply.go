@@ -0,0 +1,345 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileChange guarda o conteúdo antes e depois de um arquivo alterado, para desfazer.
+type FileChange struct {
+	Path    string `json:"path"`
+	Existed bool   `json:"existed"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// Record é o registro de undo de uma aplicação, salvo em .plaxo/patches/<id>.json.
+type Record struct {
+	ID        string       `json:"id"`
+	Domain    string       `json:"domain"`
+	CreatedAt time.Time    `json:"created_at"`
+	UndoneAt  *time.Time   `json:"undone_at,omitempty"`
+	Files     []FileChange `json:"files"`
+}
+
+// ConflictError indica trechos que não batem com o conteúdo atual do arquivo.
+type ConflictError struct {
+	Path  string
+	Hunks []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflito em %s: trechos %s não conferem com o arquivo atual", e.Path, strings.Join(e.Hunks, ", "))
+}
+
+func PatchesDir(root string) string {
+	return filepath.Join(root, ".plaxo", "patches")
+}
+
+// Apply aplica todos os arquivos da proposta ou nenhum, e salva o registro de undo.
+func Apply(root string, proposal *Proposal) (*Record, error) {
+	record := &Record{
+		ID:        newID(proposal.Domain),
+		Domain:    proposal.Domain,
+		CreatedAt: time.Now(),
+	}
+
+	for _, file := range proposal.Files {
+		change, err := prepare(root, file)
+		if err != nil {
+			return nil, err
+		}
+		record.Files = append(record.Files, change)
+	}
+
+	if err := write(root, record.Files, false); err != nil {
+		return nil, err
+	}
+	if err := record.save(root); err != nil {
+		return record, fmt.Errorf("alterações aplicadas, mas o registro de undo falhou: %v", err)
+	}
+	return record, nil
+}
+
+func prepare(root string, file *FilePatch) (FileChange, error) {
+	change := FileChange{Path: file.Path}
+
+	current, err := os.ReadFile(filepath.Join(root, file.Path))
+	switch {
+	case err == nil:
+		change.Existed = true
+		change.Before = string(current)
+	case !os.IsNotExist(err):
+		return change, err
+	}
+
+	after, err := applyHunks(file.Path, splitLines(change.Before), file.Hunks)
+	if err != nil {
+		return change, err
+	}
+	change.After = joinLines(after)
+	change.Deleted = file.Delete && len(after) == 0
+	return change, nil
+}
+
+// applyHunks localiza cada trecho a partir da linha indicada, aceitando deslocamentos (diffs de modelos erram a numeração).
+func applyHunks(path string, original []string, hunks []*Hunk) ([]string, error) {
+	sorted := make([]*Hunk, len(hunks))
+	copy(sorted, hunks)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].OldStart < sorted[j].OldStart })
+
+	var result []string
+	var conflicts []string
+	cursor := 0
+
+	for _, hunk := range sorted {
+		old := hunk.old()
+		expected := hunk.OldStart - 1
+		if hunk.OldLines == 0 {
+			expected = hunk.OldStart
+		}
+
+		position := locate(original, old, cursor, expected)
+		if position < 0 {
+			conflicts = append(conflicts, hunk.Header())
+			continue
+		}
+
+		result = append(result, original[cursor:position]...)
+		result = append(result, hunk.new()...)
+		cursor = position + len(old)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Path: path, Hunks: conflicts}
+	}
+	return append(result, original[cursor:]...), nil
+}
+
+// locate procura old em lines a partir de from, começando pela posição esperada e se afastando dela.
+func locate(lines, old []string, from, expected int) int {
+	expected = min(max(expected, from), len(lines))
+	if len(old) == 0 {
+		return expected
+	}
+
+	matches := func(at int) bool {
+		if at < from || at+len(old) > len(lines) {
+			return false
+		}
+		for i, line := range old {
+			if strings.TrimRight(lines[at+i], " \t") != strings.TrimRight(line, " \t") {
+				return false
+			}
+		}
+		return true
+	}
+
+	for offset := 0; offset <= len(lines); offset++ {
+		if matches(expected + offset) {
+			return expected + offset
+		}
+		if offset > 0 && matches(expected-offset) {
+			return expected - offset
+		}
+	}
+	return -1
+}
+
+// write grava os arquivos em temporários e só depois renomeia; se um rename falhar, restaura os anteriores.
+func write(root string, changes []FileChange, undo bool) error {
+	type staged struct {
+		change FileChange
+		target string
+		tmp    string
+		remove bool
+	}
+
+	var stages []staged
+	cleanup := func() {
+		for _, s := range stages {
+			if s.tmp != "" {
+				os.Remove(s.tmp)
+			}
+		}
+	}
+
+	for _, change := range changes {
+		content, remove := change.After, change.Deleted
+		if undo {
+			content, remove = change.Before, !change.Existed
+		}
+
+		s := staged{change: change, target: filepath.Join(root, change.Path), remove: remove}
+		if !remove {
+			if err := os.MkdirAll(filepath.Dir(s.target), 0755); err != nil {
+				cleanup()
+				return err
+			}
+			s.tmp = s.target + ".plaxo-tmp"
+			if err := os.WriteFile(s.tmp, []byte(content), filePerm(s.target)); err != nil {
+				cleanup()
+				return fmt.Errorf("erro gravando %s: %v", change.Path, err)
+			}
+		}
+		stages = append(stages, s)
+	}
+
+	for i, s := range stages {
+		var err error
+		if s.remove {
+			err = os.Remove(s.target)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.Rename(s.tmp, s.target)
+		}
+
+		if err != nil {
+			// Desfaz o que já foi trocado para manter a árvore consistente
+			var done []FileChange
+			for _, previous := range stages[:i] {
+				done = append(done, previous.change)
+			}
+			write(root, done, !undo)
+			cleanup()
+			return fmt.Errorf("erro aplicando %s: %v", s.change.Path, err)
+		}
+		stages[i].tmp = ""
+	}
+	return nil
+}
+
+func filePerm(path string) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return 0644
+}
+
+// Undo restaura os arquivos de um registro; id vazio desfaz a última aplicação ainda ativa.
+func Undo(root, id string) (*Record, error) {
+	var record *Record
+	if id == "" {
+		records, err := List(root)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(records) - 1; i >= 0; i-- {
+			if records[i].UndoneAt == nil {
+				record = records[i]
+				break
+			}
+		}
+		if record == nil {
+			return nil, fmt.Errorf("nenhuma alteração para desfazer")
+		}
+	} else {
+		if id == "." || strings.Contains(id, "..") || strings.ContainsAny(id, `/\`) {
+			return nil, fmt.Errorf("id de alteração inválido: %s", id)
+		}
+		loaded, err := load(filepath.Join(PatchesDir(root), id+".json"))
+		if err != nil {
+			return nil, err
+		}
+		record = loaded
+	}
+
+	if record.UndoneAt != nil {
+		return nil, fmt.Errorf("alteração %s já foi desfeita", record.ID)
+	}
+
+	// Só desfaz se ninguém mexeu nos arquivos depois
+	var modified []string
+	for _, change := range record.Files {
+		current, err := os.ReadFile(filepath.Join(root, change.Path))
+		switch {
+		case change.Deleted && os.IsNotExist(err):
+		case err != nil || string(current) != change.After:
+			modified = append(modified, change.Path)
+		}
+	}
+	if len(modified) > 0 {
+		return nil, fmt.Errorf("arquivos alterados depois da aplicação: %s", strings.Join(modified, ", "))
+	}
+
+	if err := write(root, record.Files, true); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record.UndoneAt = &now
+	return record, record.save(root)
+}
+
+// List devolve os registros de aplicação em ordem cronológica.
+func List(root string) ([]*Record, error) {
+	entries, err := os.ReadDir(PatchesDir(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*Record
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		record, err := load(filepath.Join(PatchesDir(root), entry.Name()))
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+	return records, nil
+}
+
+func load(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("registro de patch não encontrado: %v", err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("registro de patch inválido %s: %v", path, err)
+	}
+	return &record, nil
+}
+
+func (r *Record) save(root string) error {
+	if err := os.MkdirAll(PatchesDir(root), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(PatchesDir(root), r.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func newID(domain string) string {
+	name := strings.NewReplacer("/", "-", " ", "-").Replace(domain)
+	if name == "" {
+		name = "patch"
+	}
+	return time.Now().Format("20060102_150405.000") + "_" + name
+}
@@ -0,0 +1,151 @@
+package patch
+
+import "strings"
+
+// Linhas de contexto ao redor de cada trecho
+const contextLines = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	text string
+	old  int // índice da linha no arquivo antigo (ops equal/delete)
+	new  int // índice da linha no arquivo novo (ops equal/insert)
+}
+
+// Diff compara dois conteúdos e devolve os trechos de diff unificado entre eles.
+func Diff(before, after string) []*Hunk {
+	return hunks(editScript(splitLines(before), splitLines(after)))
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// editScript calcula a sequência de operações via LCS, após descartar prefixo e sufixo comuns.
+func editScript(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: opEqual, text: a[i], old: i, new: i})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	n, m := len(midA), len(midB)
+
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && midA[i] == midB[j]:
+			ops = append(ops, op{kind: opEqual, text: midA[i], old: prefix + i, new: prefix + j})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{kind: opInsert, text: midB[j], old: prefix + i, new: prefix + j})
+			j++
+		default:
+			ops = append(ops, op{kind: opDelete, text: midA[i], old: prefix + i, new: prefix + j})
+			i++
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, op{kind: opEqual, text: a[len(a)-suffix+k], old: len(a) - suffix + k, new: len(b) - suffix + k})
+	}
+	return ops
+}
+
+// hunks agrupa as mudanças próximas em trechos com contextLines de contexto.
+func hunks(ops []op) []*Hunk {
+	var result []*Hunk
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+
+		start := max(0, i-contextLines)
+		end := i
+		// Estende enquanto a próxima mudança estiver a até 2*contextLines linhas iguais
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				end = min(run, end+contextLines)
+				break
+			}
+			end = run
+		}
+
+		hunk := &Hunk{OldStart: ops[start].old + 1, NewStart: ops[start].new + 1}
+		for _, o := range ops[start:end] {
+			hunk.Lines = append(hunk.Lines, string(o.kind)+o.text)
+			if o.kind != opInsert {
+				hunk.OldLines++
+			}
+			if o.kind != opDelete {
+				hunk.NewLines++
+			}
+		}
+		// Convenção do diff unificado para trechos vazios
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+
+		result = append(result, hunk)
+		i = end
+	}
+	return result
+}
@@ -0,0 +1,291 @@
+package patch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"plaxo-orchestra/internal/markdown"
+	"plaxo-orchestra/internal/sandbox"
+)
+
+// Hunk é um trecho alterado de um arquivo. Lines usa os prefixos de diff unificado: ' ', '-' e '+'.
+type Hunk struct {
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"`
+}
+
+func (h *Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// old devolve as linhas que o trecho espera encontrar no arquivo.
+func (h *Hunk) old() []string {
+	var lines []string
+	for _, line := range h.Lines {
+		if line[0] != '+' {
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
+
+func (h *Hunk) new() []string {
+	var lines []string
+	for _, line := range h.Lines {
+		if line[0] != '-' {
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
+
+// FilePatch reúne os trechos propostos para um arquivo, relativo à raiz do projeto.
+type FilePatch struct {
+	Path   string  `json:"path"`
+	Hunks  []*Hunk `json:"hunks"`
+	Create bool    `json:"create,omitempty"`
+	Delete bool    `json:"delete,omitempty"`
+}
+
+// Proposal são as alterações que um agente propôs em sua resposta.
+type Proposal struct {
+	Domain string       `json:"domain"`
+	Files  []*FilePatch `json:"files"`
+}
+
+func (p *Proposal) Empty() bool {
+	return len(p.Files) == 0
+}
+
+func (p *Proposal) HunkCount() int {
+	count := 0
+	for _, file := range p.Files {
+		count += len(file.Hunks)
+	}
+	return count
+}
+
+var (
+	hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+	fileMarker = regexp.MustCompile("^(?:FILE|ARQUIVO):\\s*`?([^`\\s]+)`?\\s*$")
+)
+
+// Propose extrai diffs unificados e blocos de arquivo da resposta e os compara com a árvore em root.
+// Blocos de arquivo (linha "FILE: caminho" seguida de um bloco ```) viram trechos de diff contra o arquivo atual.
+func Propose(root, domain, text string) (*Proposal, error) {
+	proposal := &Proposal{Domain: domain}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if match := fileMarker.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			content, next, ok := fencedBlock(lines, i+1)
+			if !ok {
+				continue
+			}
+			file, err := fileFromContent(root, domain, match[1], content)
+			if err != nil {
+				return nil, err
+			}
+			if file != nil {
+				proposal.add(file)
+			}
+			i = next
+			continue
+		}
+
+		if strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			file, next, err := parseUnified(root, domain, lines, i)
+			if err != nil {
+				return nil, err
+			}
+			if file != nil {
+				proposal.add(file)
+			}
+			i = next - 1
+		}
+	}
+
+	return proposal, nil
+}
+
+// add junta trechos do mesmo arquivo vindos de blocos diferentes da resposta.
+func (p *Proposal) add(file *FilePatch) {
+	for _, existing := range p.Files {
+		if existing.Path == file.Path {
+			existing.Hunks = append(existing.Hunks, file.Hunks...)
+			existing.Delete = existing.Delete || file.Delete
+			return
+		}
+	}
+	p.Files = append(p.Files, file)
+}
+
+// fencedBlock lê o bloco cercado (``` ou ~~~) que começa em start, ignorando linhas em branco antes dele.
+// Como no CommonMark, só fecha o bloco uma cerca do mesmo caractere e pelo menos do mesmo tamanho da abertura,
+// então um arquivo com blocos aninhados (README, docs) vem inteiro quando a cerca externa é maior.
+func fencedBlock(lines []string, start int) (string, int, bool) {
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	if start >= len(lines) {
+		return "", start, false
+	}
+	fence := markdown.Fence(lines[start])
+	if fence == "" {
+		return "", start, false
+	}
+
+	for end := start + 1; end < len(lines); end++ {
+		if markdown.Closes(fence, lines[end]) {
+			return strings.Join(lines[start+1:end], "\n") + "\n", end, true
+		}
+	}
+	return "", start, false
+}
+
+func fileFromContent(root, domain, path, content string) (*FilePatch, error) {
+	rel, err := cleanPath(root, domain, path)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := os.ReadFile(filepath.Join(root, rel))
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	hunks := Diff(string(current), content)
+	if len(hunks) == 0 {
+		return nil, nil
+	}
+	return &FilePatch{Path: rel, Hunks: hunks, Create: !exists}, nil
+}
+
+func parseUnified(root, domain string, lines []string, start int) (*FilePatch, int, error) {
+	oldPath := diffPath(lines[start][4:])
+	newPath := diffPath(lines[start+1][4:])
+
+	file := &FilePatch{}
+	path := newPath
+	switch {
+	case oldPath == "/dev/null":
+		file.Create = true
+	case newPath == "/dev/null":
+		file.Delete = true
+		path = oldPath
+	}
+
+	rel, err := cleanPath(root, domain, path)
+	if err != nil {
+		return nil, start + 2, err
+	}
+	file.Path = rel
+
+	i := start + 2
+	for i < len(lines) {
+		match := hunkHeader.FindStringSubmatch(lines[i])
+		if match == nil {
+			break
+		}
+
+		hunk := &Hunk{
+			OldStart: atoi(match[1], 0),
+			OldLines: atoi(match[2], 1),
+			NewStart: atoi(match[3], 0),
+			NewLines: atoi(match[4], 1),
+		}
+		i++
+
+		// Lê linhas até completar as contagens do cabeçalho
+		oldSeen, newSeen := 0, 0
+		for i < len(lines) && (oldSeen < hunk.OldLines || newSeen < hunk.NewLines) {
+			line := lines[i]
+			if line == "" {
+				line = " "
+			}
+			switch line[0] {
+			case ' ':
+				oldSeen++
+				newSeen++
+			case '-':
+				oldSeen++
+			case '+':
+				newSeen++
+			case '\\':
+				i++
+				continue
+			default:
+				return nil, i, fmt.Errorf("diff de %s malformado na linha %q", rel, lines[i])
+			}
+			hunk.Lines = append(hunk.Lines, line)
+			i++
+		}
+		if oldSeen < hunk.OldLines || newSeen < hunk.NewLines {
+			// Resposta cortada no meio do trecho: aplicar só a parte recebida corromperia o arquivo
+			return nil, i, fmt.Errorf("diff de %s incompleto: %s termina com %d linhas antigas e %d novas", rel, hunk.Header(), oldSeen, newSeen)
+		}
+		file.Hunks = append(file.Hunks, hunk)
+
+		if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+			i++
+		}
+	}
+
+	if len(file.Hunks) == 0 {
+		return nil, i, nil
+	}
+	return file, i, nil
+}
+
+func diffPath(field string) string {
+	path := strings.TrimSpace(field)
+	if tab := strings.IndexByte(path, '\t'); tab != -1 {
+		path = path[:tab]
+	}
+	if path == "/dev/null" {
+		return path
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
+
+// cleanPath só aceita caminhos que fiquem dentro do projeto; os demais são uma *sandbox.Violation do domínio.
+func cleanPath(root, domain, path string) (string, error) {
+	original := path
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return "", sandbox.Outside(domain, sandbox.Write, original)
+		}
+		path = rel
+	}
+
+	clean := filepath.Clean(filepath.FromSlash(path))
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", sandbox.Outside(domain, sandbox.Write, original)
+	}
+	return clean, nil
+}
+
+func atoi(value string, fallback int) int {
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,285 @@
+package patch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"plaxo-orchestra/internal/sandbox"
+)
+
+const original = "package auth\n\nfunc Login() {\n\treturn\n}\n"
+
+func project(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "auth"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "auth", "login.go"), []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestPropose(t *testing.T) {
+	root := project(t)
+
+	tests := []struct {
+		name    string
+		text    string
+		files   []string
+		hunks   int
+		create  bool
+		wantErr string
+		outside bool
+	}{
+		{
+			name:  "diff unificado",
+			text:  "Segue:\n--- a/auth/login.go\n+++ b/auth/login.go\n@@ -3,3 +3,4 @@\n func Login() {\n+\tlog()\n \treturn\n }\n",
+			files: []string{"auth/login.go"},
+			hunks: 1,
+		},
+		{
+			name:   "bloco de arquivo novo",
+			text:   "FILE: auth/token.go\n```go\npackage auth\n\nfunc Token() {}\n```\n",
+			files:  []string{"auth/token.go"},
+			hunks:  1,
+			create: true,
+		},
+		{
+			name: "bloco igual ao arquivo atual",
+			text: "FILE: auth/login.go\n```go\n" + original + "```\n",
+		},
+		{
+			name:  "trechos do mesmo arquivo se juntam",
+			text:  "--- a/auth/login.go\n+++ b/auth/login.go\n@@ -1,1 +1,1 @@\n-package auth\n+package auth // v2\n\n--- a/auth/login.go\n+++ b/auth/login.go\n@@ -4,1 +4,1 @@\n-\treturn\n+\treturn // fim\n",
+			files: []string{"auth/login.go"},
+			hunks: 2,
+		},
+		{
+			name:    "resposta cortada no meio do trecho",
+			text:    "--- a/auth/login.go\n+++ b/auth/login.go\n@@ -3,3 +3,4 @@\n func Login() {\n+\tlog()\n",
+			wantErr: "incompleto",
+		},
+		{
+			name:    "linha fora do formato",
+			text:    "--- a/auth/login.go\n+++ b/auth/login.go\n@@ -3,2 +3,2 @@\n func Login() {\n?? estranho\n",
+			wantErr: "malformado",
+		},
+		{
+			name:    "caminho fora do projeto",
+			text:    "--- a/../etc/passwd\n+++ b/../etc/passwd\n@@ -1,1 +1,1 @@\n-root\n+hacked\n",
+			outside: true,
+		},
+		{
+			name: "texto sem alterações",
+			text: "Nenhuma alteração necessária.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proposal, err := Propose(root, "auth", test.text)
+			if test.outside {
+				var violation *sandbox.Violation
+				if !errors.As(err, &violation) || !violation.Outside {
+					t.Fatalf("esperava violação fora do projeto, veio %v", err)
+				}
+				return
+			}
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("erro = %v, esperado %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var files []string
+			for _, file := range proposal.Files {
+				files = append(files, file.Path)
+				if file.Create != test.create {
+					t.Errorf("%s: Create = %v", file.Path, file.Create)
+				}
+			}
+			if strings.Join(files, ",") != strings.Join(test.files, ",") {
+				t.Errorf("arquivos = %v, esperado %v", files, test.files)
+			}
+			if proposal.HunkCount() != test.hunks {
+				t.Errorf("trechos = %d, esperado %d", proposal.HunkCount(), test.hunks)
+			}
+		})
+	}
+}
+
+func TestProposeNestedFence(t *testing.T) {
+	readme := "# Auth\n\n```go\nauth.Login(user, password)\n```\n\nFim.\n"
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"cerca maior", "FILE: auth/README.md\n````markdown\n" + readme + "````\n"},
+		{"til", "FILE: auth/README.md\n~~~markdown\n" + readme + "~~~\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := project(t)
+			proposal, err := Propose(root, "auth", test.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Apply(root, Review(proposal, AutoReviewer{Accept: true})); err != nil {
+				t.Fatal(err)
+			}
+			written, _ := os.ReadFile(filepath.Join(root, "auth", "README.md"))
+			if string(written) != readme {
+				t.Errorf("README gravado:\n%s\nesperado:\n%s", written, readme)
+			}
+		})
+	}
+}
+
+func TestDiffRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{"inserção", "a\nb\nc\n", "a\nb\nx\nc\n"},
+		{"remoção", "a\nb\nc\n", "a\nc\n"},
+		{"arquivo novo", "", "package x\n"},
+		{"troca total", "a\n", "b\n"},
+		{"trechos distantes", strings.Repeat("l\n", 20) + "fim\n", "início\n" + strings.Repeat("l\n", 20) + "final\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := applyHunks("x", splitLines(test.before), Diff(test.before, test.after))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := joinLines(result); got != test.after {
+				t.Errorf("got %q, esperado %q", got, test.after)
+			}
+		})
+	}
+}
+
+func TestApplyAndUndo(t *testing.T) {
+	root := project(t)
+	path := filepath.Join(root, "auth", "login.go")
+
+	proposal, err := Propose(root, "auth", "--- a/auth/login.go\n+++ b/auth/login.go\n@@ -4,1 +4,1 @@\n-\treturn\n+\treturn // pronto\nFILE: auth/token.go\n```go\npackage auth\n```\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := Apply(root, Review(proposal, AutoReviewer{Accept: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, _ := os.ReadFile(path)
+	if !strings.Contains(string(changed), "return // pronto") {
+		t.Fatalf("alteração não aplicada:\n%s", changed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "auth", "token.go")); err != nil {
+		t.Fatal("arquivo novo não criado")
+	}
+
+	if _, err := Undo(root, record.ID); err != nil {
+		t.Fatal(err)
+	}
+	restored, _ := os.ReadFile(path)
+	if string(restored) != original {
+		t.Errorf("undo não restaurou o original:\n%s", restored)
+	}
+	if _, err := os.Stat(filepath.Join(root, "auth", "token.go")); !os.IsNotExist(err) {
+		t.Error("undo deveria remover o arquivo criado")
+	}
+	if _, err := Undo(root, record.ID); err == nil {
+		t.Error("desfazer duas vezes deveria falhar")
+	}
+	for _, id := range []string{"../" + record.ID, "x/../" + record.ID, `..\x`, ".."} {
+		if _, err := Undo(root, id); err == nil || !strings.Contains(err.Error(), "inválido") {
+			t.Errorf("Undo(%q) = %v, esperava id inválido", id, err)
+		}
+	}
+}
+
+func TestApplyConflict(t *testing.T) {
+	root := project(t)
+	proposal, err := Propose(root, "auth", "--- a/auth/login.go\n+++ b/auth/login.go\n@@ -4,1 +4,1 @@\n-\tpanic(1)\n+\treturn\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Apply(root, proposal)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.Path != filepath.Join("auth", "login.go") {
+		t.Fatalf("esperava conflito em auth/login.go, veio %v", err)
+	}
+	current, _ := os.ReadFile(filepath.Join(root, "auth", "login.go"))
+	if string(current) != original {
+		t.Error("conflito não pode alterar o arquivo")
+	}
+}
+
+func TestReview(t *testing.T) {
+	proposal := &Proposal{Domain: "auth", Files: []*FilePatch{
+		{Path: "a.go", Hunks: []*Hunk{{Lines: []string{"+a"}}, {Lines: []string{"+b"}}}},
+		{Path: "b.go", Delete: true, Hunks: []*Hunk{{Lines: []string{"-x"}}, {Lines: []string{"-y"}}}},
+	}}
+
+	tests := []struct {
+		name      string
+		decisions []Decision
+		hunks     map[string]int
+		deleted   bool
+	}{
+		{"aceita tudo", []Decision{AcceptRest}, map[string]int{"a.go": 2, "b.go": 2}, true},
+		{"rejeita tudo", []Decision{RejectRest}, map[string]int{}, false},
+		{"remoção parcial vira edição", []Decision{Accept, Reject, Accept, Reject}, map[string]int{"a.go": 1, "b.go": 1}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reviewer := &scripted{decisions: test.decisions}
+			accepted := Review(proposal, reviewer)
+
+			got := make(map[string]int)
+			deleted := false
+			for _, file := range accepted.Files {
+				got[file.Path] = len(file.Hunks)
+				deleted = deleted || file.Delete
+			}
+			if len(got) != len(test.hunks) {
+				t.Fatalf("arquivos = %v, esperado %v", got, test.hunks)
+			}
+			for path, count := range test.hunks {
+				if got[path] != count {
+					t.Errorf("%s: %d trechos, esperado %d", path, got[path], count)
+				}
+			}
+			if deleted != test.deleted {
+				t.Errorf("Delete = %v, esperado %v", deleted, test.deleted)
+			}
+		})
+	}
+}
+
+type scripted struct {
+	decisions []Decision
+	next      int
+}
+
+func (s *scripted) Review(domain string, file *FilePatch, index int, hunk *Hunk) Decision {
+	decision := s.decisions[s.next]
+	s.next++
+	return decision
+}
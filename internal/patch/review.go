@@ -0,0 +1,185 @@
+package patch
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type Decision int
+
+const (
+	Accept Decision = iota
+	Reject
+	// AcceptRest e RejectRest valem para este trecho e todos os seguintes da proposta
+	AcceptRest
+	RejectRest
+)
+
+// Reviewer decide, trecho a trecho, o que entra na árvore.
+type Reviewer interface {
+	Review(domain string, file *FilePatch, index int, hunk *Hunk) Decision
+}
+
+// Review devolve a proposta só com os trechos aceitos pelo revisor.
+func Review(proposal *Proposal, reviewer Reviewer) *Proposal {
+	accepted := &Proposal{Domain: proposal.Domain}
+	var rest *Decision
+
+	for _, file := range proposal.Files {
+		kept := &FilePatch{Path: file.Path, Create: file.Create, Delete: file.Delete}
+		for i, hunk := range file.Hunks {
+			var decision Decision
+			if rest != nil {
+				decision = *rest
+			} else {
+				decision = reviewer.Review(proposal.Domain, file, i, hunk)
+			}
+
+			switch decision {
+			case AcceptRest:
+				rest = &decision
+				kept.Hunks = append(kept.Hunks, hunk)
+			case RejectRest:
+				rest = &decision
+			case Accept:
+				kept.Hunks = append(kept.Hunks, hunk)
+			}
+		}
+
+		// Remoção parcial de arquivo vira edição comum
+		if kept.Delete && len(kept.Hunks) != len(file.Hunks) {
+			kept.Delete = false
+		}
+		if len(kept.Hunks) > 0 {
+			accepted.Files = append(accepted.Files, kept)
+		}
+	}
+	return accepted
+}
+
+// AutoReviewer aceita ou rejeita tudo sem perguntar (ex: execução não interativa).
+type AutoReviewer struct {
+	Accept bool
+}
+
+func (a AutoReviewer) Review(domain string, file *FilePatch, index int, hunk *Hunk) Decision {
+	if a.Accept {
+		return Accept
+	}
+	return Reject
+}
+
+// TerminalReviewer mostra cada trecho colorido e pergunta no terminal.
+type TerminalReviewer struct {
+	in  io.Reader
+	out io.Writer
+}
+
+func NewTerminalReviewer(in io.Reader, out io.Writer) *TerminalReviewer {
+	return &TerminalReviewer{in: in, out: out}
+}
+
+func (t *TerminalReviewer) Review(domain string, file *FilePatch, index int, hunk *Hunk) Decision {
+	status := ""
+	switch {
+	case file.Create:
+		status = " (novo)"
+	case file.Delete:
+		status = " (remoção)"
+	}
+	fmt.Fprintf(t.out, "\n📄 [%s] %s%s — trecho %d/%d\n", domain, file.Path, status, index+1, len(file.Hunks))
+	WriteHunk(t.out, hunk)
+
+	for {
+		fmt.Fprint(t.out, "❓ Aplicar este trecho? [s]im/[n]ão/[t]odos/[q] nenhum dos restantes: ")
+		answer, err := readLine(t.in)
+		if err != nil {
+			fmt.Fprintln(t.out)
+			return RejectRest
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "s", "sim", "y", "yes":
+			return Accept
+		case "n", "nao", "não", "no", "":
+			return Reject
+		case "t", "todos", "a", "all":
+			return AcceptRest
+		case "q", "quit":
+			return RejectRest
+		}
+	}
+}
+
+// readLine lê byte a byte para não consumir entrada além da linha (o stdin é compartilhado com o modo interativo).
+func readLine(in io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if len(line) > 0 {
+				return string(line), nil
+			}
+			return "", err
+		}
+	}
+}
+
+const (
+	colorReset = "\033[0m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+// WriteHunk escreve o trecho com cores de diff, exceto quando NO_COLOR está definido.
+func WriteHunk(w io.Writer, hunk *Hunk) {
+	plain := os.Getenv("NO_COLOR") != ""
+	paint := func(color, text string) string {
+		if plain {
+			return text
+		}
+		return color + text + colorReset
+	}
+
+	fmt.Fprintln(w, paint(colorCyan, hunk.Header()))
+	for _, line := range hunk.Lines {
+		switch line[0] {
+		case '+':
+			fmt.Fprintln(w, paint(colorGreen, line))
+		case '-':
+			fmt.Fprintln(w, paint(colorRed, line))
+		default:
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// Summary resume a proposta por arquivo para o cabeçalho do preview do domínio.
+func Summary(proposal *Proposal) string {
+	var lines []string
+	for _, file := range proposal.Files {
+		added, removed := 0, 0
+		for _, hunk := range file.Hunks {
+			for _, line := range hunk.Lines {
+				switch line[0] {
+				case '+':
+					added++
+				case '-':
+					removed++
+				}
+			}
+		}
+		lines = append(lines, fmt.Sprintf("  %s (+%d -%d, %d trechos)", file.Path, added, removed, len(file.Hunks)))
+	}
+	return strings.Join(lines, "\n")
+}
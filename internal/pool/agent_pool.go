@@ -3,7 +3,7 @@ package pool
 import (
 	"context"
 	"fmt"
-	"os/exec"
+	"plaxo-orchestra/internal/backend"
 	"sync"
 	"time"
 )
@@ -13,18 +13,30 @@ type AgentInstance struct {
 	LastUsed time.Time
 	InUse    bool
 	Context  string
+	Session  backend.Session
+	Restarts int
+
+	// ephemeral instances atendem chamadas concorrentes ao mesmo agente e são fechadas no Release
+	ephemeral bool
 }
 
 type AgentPool struct {
 	instances map[string]*AgentInstance
 	mutex     sync.RWMutex
 	maxIdle   time.Duration
+	backend   backend.Backend
+	// Modelo de cada agente, quando o manifesto sobrescreve o do backend
+	models    map[string]string
+	modelsMu  sync.RWMutex
 }
 
+const maxRestarts = 3
+
 func NewAgentPool() *AgentPool {
 	pool := &AgentPool{
 		instances: make(map[string]*AgentInstance),
 		maxIdle:   10 * time.Minute,
+		models:    make(map[string]string),
 	}
 	
 	// Cleanup routine
@@ -35,62 +47,150 @@ func NewAgentPool() *AgentPool {
 
 func (p *AgentPool) GetOrCreate(agentID string) (*AgentInstance, error) {
 	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	
-	if instance, exists := p.instances[agentID]; exists {
-		if !instance.InUse {
-			instance.InUse = true
-			instance.LastUsed = time.Now()
-			return instance, nil
-		}
+	if instance, exists := p.instances[agentID]; exists && !instance.InUse {
+		instance.InUse = true
+		instance.LastUsed = time.Now()
+		p.mutex.Unlock()
+		return instance, nil
 	}
+	p.mutex.Unlock()
 	
-	// Create new instance
+	// Abrir a sessão pode iniciar um processo: fora do lock, para não segurar os outros agentes
 	instance, err := p.createInstance(agentID)
 	if err != nil {
 		return nil, err
 	}
 	
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, exists := p.instances[agentID]; exists {
+		// Instância ocupada (ou publicada por outra chamada): atende com uma sessão temporária sem derrubar a persistente
+		instance.ephemeral = true
+		return instance, nil
+	}
 	p.instances[agentID] = instance
 	return instance, nil
 }
 
 func (p *AgentPool) createInstance(agentID string) (*AgentInstance, error) {
-	// Use direct execution instead of persistent process
+	session, err := backend.OpenSession(p.modelContext(context.Background(), agentID), p.Backend(), agentID)
+	if err != nil {
+		return nil, fmt.Errorf("erro abrindo sessão do agente %s: %v", agentID, err)
+	}
+	
 	instance := &AgentInstance{
 		ID:       agentID,
 		LastUsed: time.Now(),
 		InUse:    true,
+		Session:  session,
 	}
 	
 	return instance, nil
 }
 
 func (p *AgentPool) Execute(agentID, input string) (string, error) {
+	return p.ExecuteContext(context.Background(), agentID, input)
+}
+
+// ExecuteContext é como Execute, mas propaga ctx (cancelamento e atribuição de uso) até o backend.
+func (p *AgentPool) ExecuteContext(ctx context.Context, agentID, input string) (string, error) {
 	instance, err := p.GetOrCreate(agentID)
 	if err != nil {
 		return "", err
 	}
 	defer p.Release(instance)
 	
-	// Execute Q CLI directly with longer timeout for initialization
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	// Execute backend with longer timeout for initialization
+	ctx, cancel := context.WithTimeout(p.modelContext(ctx, agentID), 120*time.Second)
 	defer cancel()
 	
-	cmd := exec.CommandContext(ctx, "q", "chat", input)
+	if err := p.ensureHealthy(instance); err != nil {
+		return "", err
+	}
 	
-	output, err := cmd.Output()
+	response, err := instance.Session.Send(ctx, input)
+	if err != nil && ctx.Err() == nil && !instance.Session.Healthy() {
+		// Processo caiu durante a chamada: reinicia a sessão e tenta novamente
+		if restartErr := p.ensureHealthy(instance); restartErr != nil {
+			return "", restartErr
+		}
+		response, err = instance.Session.Send(ctx, input)
+	}
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("Q CLI timeout after 120 seconds")
+			return "", fmt.Errorf("%s timeout after 120 seconds", p.Backend().Name())
 		}
-		if exitError, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("Q CLI error (exit %d): %s", exitError.ExitCode(), string(exitError.Stderr))
+		return "", err
+	}
+	
+	// O limite de reinícios vale para falhas seguidas, não para a vida do processo
+	instance.Restarts = 0
+	return response.Content, nil
+}
+
+// Complete faz uma chamada avulsa com o modelo do agente, fora da sessão dele: nada entra nem sai da conversa.
+func (p *AgentPool) Complete(ctx context.Context, agentID, input string) (string, error) {
+	ctx, cancel := context.WithTimeout(p.modelContext(ctx, agentID), 120*time.Second)
+	defer cancel()
+	
+	response, err := p.Backend().Complete(ctx, backend.Request{AgentID: agentID, Prompt: input})
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s timeout after 120 seconds", p.Backend().Name())
 		}
-		return "", fmt.Errorf("Q CLI error: %v", err)
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// ensureHealthy reabre a sessão da instância se o processo do agente tiver morrido; desiste depois de maxRestarts
+// reinícios sem nenhuma chamada bem-sucedida entre eles.
+func (p *AgentPool) ensureHealthy(instance *AgentInstance) error {
+	if instance.Session != nil && instance.Session.Healthy() {
+		return nil
+	}
+	
+	if instance.Restarts >= maxRestarts {
+		return fmt.Errorf("sessão do agente %s reiniciada %d vezes, desistindo", instance.ID, instance.Restarts)
+	}
+	
+	if instance.Session != nil {
+		instance.Session.Close()
+	}
+	
+	session, err := backend.OpenSession(p.modelContext(context.Background(), instance.ID), p.Backend(), instance.ID)
+	if err != nil {
+		return fmt.Errorf("erro reiniciando sessão do agente %s: %v", instance.ID, err)
 	}
 	
-	return string(output), nil
+	instance.Session = session
+	instance.Restarts++
+	return nil
+}
+
+// Backend retorna o backend do pool, ou o backend padrão do processo.
+func (p *AgentPool) Backend() backend.Backend {
+	if p.backend != nil {
+		return p.backend
+	}
+	return backend.Default()
+}
+
+func (p *AgentPool) SetBackend(b backend.Backend) {
+	p.backend = b
+}
+
+// SetModel faz o agente usar model em vez do modelo configurado no backend.
+func (p *AgentPool) SetModel(agentID, model string) {
+	p.modelsMu.Lock()
+	defer p.modelsMu.Unlock()
+	p.models[agentID] = model
+}
+
+func (p *AgentPool) modelContext(ctx context.Context, agentID string) context.Context {
+	p.modelsMu.RLock()
+	defer p.modelsMu.RUnlock()
+	return backend.WithModel(ctx, p.models[agentID])
 }
 
 func (p *AgentPool) Release(instance *AgentInstance) {
@@ -99,6 +199,23 @@ func (p *AgentPool) Release(instance *AgentInstance) {
 	
 	instance.InUse = false
 	instance.LastUsed = time.Now()
+	
+	if instance.ephemeral && instance.Session != nil {
+		instance.Session.Close()
+	}
+}
+
+// Close encerra todas as sessões do pool.
+func (p *AgentPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	
+	for id, instance := range p.instances {
+		if instance.Session != nil {
+			instance.Session.Close()
+		}
+		delete(p.instances, id)
+	}
 }
 
 func (p *AgentPool) cleanup() {
@@ -108,12 +225,19 @@ func (p *AgentPool) cleanup() {
 	for range ticker.C {
 		p.mutex.Lock()
 		for id, instance := range p.instances {
-			if !instance.InUse && time.Since(instance.LastUsed) > p.maxIdle {
+			if instance.InUse {
+				continue
+			}
+			
+			// Health check: remove sessões mortas ou ociosas
+			idle := time.Since(instance.LastUsed) > p.maxIdle
+			if idle || (instance.Session != nil && !instance.Session.Healthy()) {
+				if instance.Session != nil {
+					instance.Session.Close()
+				}
 				delete(p.instances, id)
 			}
 		}
 		p.mutex.Unlock()
 	}
 }
-
-
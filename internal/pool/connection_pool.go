@@ -1,11 +1,9 @@
 package pool
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"os/exec"
-	"strings"
+	"plaxo-orchestra/internal/backend"
 	"sync"
 	"time"
 )
@@ -142,18 +140,17 @@ func (ap *AsyncProcessor) processJob(job Job) JobResult {
 	}
 	defer ap.pool.Release(conn)
 	
-	// Execute Amazon Q CLI with streaming support
+	// Execute backend call
 	ctx, cancel := context.WithTimeout(job.Context, 45*time.Second)
 	defer cancel()
 	
-	cmd := exec.CommandContext(ctx, "q", "chat", "--no-interactive", job.Request)
-	output, err := cmd.Output()
+	response, err := backend.Default().Complete(ctx, backend.Request{AgentID: "async_processor", Prompt: job.Request})
 	if err != nil {
-		return JobResult{Error: fmt.Errorf("Q CLI error: %v", err)}
+		return JobResult{Error: err}
 	}
 	
 	return JobResult{
-		Data: string(output),
+		Data: response.Content,
 	}
 }
 
@@ -173,32 +170,11 @@ func (sp *StreamingProcessor) ExecuteWithStream(ctx context.Context, request str
 	}
 	defer sp.pool.Release(conn)
 	
-	// Execute Q CLI with streaming
-	cmd := exec.CommandContext(ctx, "q", "chat", "--no-interactive", request)
-	
-	stdout, err := cmd.StdoutPipe()
+	// Execute backend with streaming
+	response, err := backend.Default().Stream(ctx, backend.Request{AgentID: "streaming_processor", Prompt: request}, onOutput)
 	if err != nil {
 		return "", err
 	}
 	
-	if err := cmd.Start(); err != nil {
-		return "", err
-	}
-	
-	var output strings.Builder
-	scanner := bufio.NewScanner(stdout)
-	
-	for scanner.Scan() {
-		line := scanner.Text()
-		output.WriteString(line + "\n")
-		if onOutput != nil {
-			onOutput(line + "\n")
-		}
-	}
-	
-	if err := cmd.Wait(); err != nil {
-		return "", fmt.Errorf("Q CLI error: %v", err)
-	}
-	
-	return output.String(), nil
+	return response.Content, nil
 }
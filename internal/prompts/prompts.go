@@ -0,0 +1,271 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+const (
+	LocalePtBR    = "pt-BR"
+	LocaleEN      = "en"
+	DefaultLocale = LocalePtBR
+)
+
+var versionPattern = regexp.MustCompile(`^\{\{/\*\s*version:\s*(\d+)\s*\*/\}\}\r?\n?`)
+
+// Template é um prompt carregado, com a origem e a versão declarada no cabeçalho.
+type Template struct {
+	Name    string
+	Locale  string
+	Version int
+	Source  string
+	tmpl    *template.Template
+}
+
+// Loader resolve prompts por locale: .plaxo/prompts do projeto primeiro, depois o conjunto embutido.
+type Loader struct {
+	workingDir string
+	locale     string
+	cache      map[string]*Template
+	mutex      sync.Mutex
+}
+
+func NewLoader(workingDir, locale string) *Loader {
+	return &Loader{
+		workingDir: workingDir,
+		locale:     normalizeLocale(locale),
+		cache:      make(map[string]*Template),
+	}
+}
+
+func (l *Loader) Locale() string {
+	return l.locale
+}
+
+// OverrideDir é onde cada projeto pode sobrescrever os prompts (.plaxo/prompts/<locale>/<nome>.tmpl).
+func OverrideDir(workingDir string) string {
+	return filepath.Join(workingDir, ".plaxo", "prompts")
+}
+
+func (l *Loader) Load(name string) (*Template, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if cached, exists := l.cache[name]; exists {
+		return cached, nil
+	}
+
+	t, err := l.load(name)
+	if err != nil {
+		return nil, err
+	}
+	l.cache[name] = t
+	return t, nil
+}
+
+func (l *Loader) load(name string) (*Template, error) {
+	file := name + ".tmpl"
+
+	if l.workingDir != "" {
+		candidates := []string{
+			filepath.Join(OverrideDir(l.workingDir), l.locale, file),
+			filepath.Join(OverrideDir(l.workingDir), file),
+		}
+		for _, path := range candidates {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			t, err := parse(name, l.locale, path, string(data))
+			if err != nil {
+				fmt.Printf("⚠️  Prompt %s inválido, usando o padrão: %v\n", path, err)
+				break
+			}
+			return t, nil
+		}
+	}
+
+	for _, locale := range []string{l.locale, DefaultLocale} {
+		path := "templates/" + locale + "/" + file
+		data, err := embedded.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		return parse(name, locale, "embutido:"+path, string(data))
+	}
+
+	return nil, fmt.Errorf("prompt não encontrado: %s", name)
+}
+
+func parse(name, locale, source, text string) (*Template, error) {
+	t := &Template{Name: name, Locale: locale, Source: source}
+
+	if match := versionPattern.FindStringSubmatch(text); match != nil {
+		t.Version, _ = strconv.Atoi(match[1])
+		text = text[len(match[0]):]
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t.tmpl = tmpl
+	return t, nil
+}
+
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+func (t *Template) Execute(data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("erro renderizando prompt %s (%s): %v", t.Name, t.Source, err)
+	}
+	return buf.String(), nil
+}
+
+func (l *Loader) Render(name string, data interface{}) (string, error) {
+	t, err := l.Load(name)
+	if err != nil {
+		return "", err
+	}
+	return t.Execute(data)
+}
+
+// Names lista os prompts do conjunto embutido.
+func Names() []string {
+	entries, err := fs.ReadDir(embedded, "templates/"+DefaultLocale)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Export copia os prompts embutidos de um locale para .plaxo/prompts/<locale> para edição.
+func Export(workingDir, locale string) (string, error) {
+	locale = normalizeLocale(locale)
+	dir := filepath.Join(OverrideDir(workingDir), locale)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	for _, name := range Names() {
+		target := filepath.Join(dir, name+".tmpl")
+		if _, err := os.Stat(target); err == nil {
+			continue // Não sobrescreve ajustes do projeto
+		}
+
+		data, err := embedded.ReadFile("templates/" + locale + "/" + name + ".tmpl")
+		if err != nil {
+			data, err = embedded.ReadFile("templates/" + DefaultLocale + "/" + name + ".tmpl")
+			if err != nil {
+				return "", err
+			}
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// DetectLocale escolhe o locale: orchestra.yaml, depois PLAXO_LOCALE, depois LANG.
+func DetectLocale(workingDir string) string {
+	if data, err := os.ReadFile(filepath.Join(workingDir, "orchestra.yaml")); err == nil {
+		var config struct {
+			Locale string `yaml:"locale"`
+		}
+		if yaml.Unmarshal(data, &config) == nil && config.Locale != "" {
+			return normalizeLocale(config.Locale)
+		}
+	}
+
+	for _, env := range []string{"PLAXO_LOCALE", "LANG"} {
+		if value := os.Getenv(env); value != "" && value != "C" && value != "POSIX" {
+			return normalizeLocale(value)
+		}
+	}
+	return DefaultLocale
+}
+
+func normalizeLocale(locale string) string {
+	lower := strings.ToLower(locale)
+	switch {
+	case strings.HasPrefix(lower, "en"):
+		return LocaleEN
+	case strings.HasPrefix(lower, "pt"), lower == "":
+		return LocalePtBR
+	default:
+		return DefaultLocale
+	}
+}
+
+var (
+	defaultLoader = NewLoader("", DefaultLocale)
+	defaultMutex  sync.RWMutex
+)
+
+func SetDefault(l *Loader) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultLoader = l
+}
+
+func Default() *Loader {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultLoader
+}
+
+// Render usa o loader padrão; se o prompt do projeto falhar, cai para o embutido e,
+// se os dados da execução também quebrarem o embutido, para um prompt mínimo com os dados.
+func Render(name string, data interface{}) string {
+	loader := Default()
+	text, err := loader.Render(name, data)
+	if err == nil {
+		return text
+	}
+
+	fmt.Printf("⚠️  %v\n", err)
+	text, err = NewLoader("", loader.Locale()).Render(name, data)
+	if err == nil {
+		return text
+	}
+
+	fmt.Printf("⚠️  %v (usando prompt mínimo)\n", err)
+	return minimal(name, data)
+}
+
+// minimal descreve a tarefa pelo nome do prompt e pelos dados recebidos, em YAML.
+func minimal(name string, data interface{}) string {
+	content, err := yaml.Marshal(data)
+	if err != nil {
+		content = []byte(fmt.Sprintf("%+v", data))
+	}
+	return fmt.Sprintf("Tarefa: %s\n\nDados:\n%s", name, content)
+}
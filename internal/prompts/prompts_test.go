@@ -0,0 +1,59 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedTemplatesParse(t *testing.T) {
+	for _, locale := range []string{LocalePtBR, LocaleEN} {
+		loader := NewLoader("", locale)
+		for _, name := range Names() {
+			tmpl, err := loader.Load(name)
+			if err != nil {
+				t.Errorf("%s/%s: %v", locale, name, err)
+				continue
+			}
+			if tmpl.Version == 0 {
+				t.Errorf("%s/%s: sem cabeçalho de versão", locale, name)
+			}
+		}
+	}
+}
+
+func TestRenderFallback(t *testing.T) {
+	root := t.TempDir()
+	override := filepath.Join(OverrideDir(root), LocalePtBR)
+	if err := os.MkdirAll(override, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(override, "file_review.tmpl"), []byte("{{.Inexistente.Campo}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := Default()
+	SetDefault(NewLoader(root, LocalePtBR))
+	defer SetDefault(previous)
+
+	tests := []struct {
+		name     string
+		template string
+		data     interface{}
+		contains string
+	}{
+		{"prompt do projeto quebrado cai para o embutido", "file_review", map[string]interface{}{"File": "a.go"}, "a.go"},
+		{"dados que quebram o embutido viram prompt mínimo", "file_review", struct{ Outro string }{"valor"}, "Tarefa: file_review"},
+		{"prompt mínimo leva os dados", "file_review", struct{ Outro string }{"valor"}, "valor"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := Render(test.template, test.data)
+			if !strings.Contains(text, test.contains) {
+				t.Errorf("prompt sem %q:\n%s", test.contains, text)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package retrieval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	indexVersion = 1
+	chunkLines   = 40
+	chunkStride  = 30
+	maxFileSize  = 512 * 1024
+)
+
+// Chunk é um trecho de arquivo indexado, com as frequências dos seus termos.
+type Chunk struct {
+	StartLine int            `json:"start_line"`
+	EndLine   int            `json:"end_line"`
+	Text      string         `json:"text"`
+	Terms     map[string]int `json:"terms"`
+	Length    int            `json:"length"`
+}
+
+type FileEntry struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Hash    string    `json:"hash"`
+	Chunks  []Chunk   `json:"chunks"`
+}
+
+// Index é o índice BM25 dos arquivos de um domínio, salvo em .plaxo/index/<domínio>.json.
+type Index struct {
+	Version int                   `json:"version"`
+	Domain  string                `json:"domain"`
+	Files   map[string]*FileEntry `json:"files"`
+
+	root  string
+	path  string
+	dirty bool
+	mutex sync.RWMutex
+}
+
+// IndexPath devolve onde o índice de um domínio é salvo; "user/profile" vira "user-profile.json".
+func IndexPath(workingDir, domain string) string {
+	name := strings.ReplaceAll(domain, "/", "-")
+	return filepath.Join(workingDir, ".plaxo", "index", name+".json")
+}
+
+var (
+	// opened guarda os índices já carregados: agentes do mesmo domínio compartilham um só, com o mesmo lock
+	opened   = make(map[string]*Index)
+	openedMu sync.Mutex
+)
+
+// Open carrega o índice salvo do domínio, ou um vazio se ainda não existir.
+// Dentro do processo, chamadas seguintes devolvem o mesmo índice.
+func Open(workingDir, domain string) (*Index, error) {
+	path := IndexPath(workingDir, domain)
+
+	openedMu.Lock()
+	defer openedMu.Unlock()
+	if index, exists := opened[path]; exists {
+		return index, nil
+	}
+
+	index, err := load(workingDir, domain, path)
+	if err != nil {
+		return nil, err
+	}
+	opened[path] = index
+	return index, nil
+}
+
+func load(workingDir, domain, path string) (*Index, error) {
+	index := &Index{
+		Version: indexVersion,
+		Domain:  domain,
+		Files:   make(map[string]*FileEntry),
+		root:    workingDir,
+		path:    path,
+	}
+
+	data, err := os.ReadFile(index.path)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Index
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Version != indexVersion {
+		// Índice corrompido ou de outra versão é reconstruído do zero
+		index.dirty = true
+		return index, nil
+	}
+	if saved.Files != nil {
+		index.Files = saved.Files
+	}
+	return index, nil
+}
+
+// Update indexa os arquivos novos ou alterados; arquivos com mesmo mtime e tamanho não são relidos.
+// Arquivos que deixaram de ser indexáveis (ex: passaram de 512KB) saem do índice.
+func (ix *Index) Update(files []string) (int, error) {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+
+	changed := 0
+	for _, file := range files {
+		key := ix.key(file)
+		entry := ix.Files[key]
+
+		info, err := os.Stat(file)
+		if err != nil || info.IsDir() || info.Size() > maxFileSize {
+			if entry != nil {
+				delete(ix.Files, key)
+				ix.dirty = true
+			}
+			continue
+		}
+
+		if entry != nil && entry.ModTime.Equal(info.ModTime()) && entry.Size == info.Size() {
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return changed, fmt.Errorf("erro lendo %s: %v", file, err)
+		}
+
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+		if entry != nil && entry.Hash == hash {
+			// Só o mtime mudou: o conteúdo indexado continua válido
+			entry.ModTime = info.ModTime()
+			ix.dirty = true
+			continue
+		}
+
+		ix.Files[key] = &FileEntry{
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+			Hash:    hash,
+			Chunks:  chunkFile(string(content)),
+		}
+		ix.dirty = true
+		changed++
+	}
+	return changed, nil
+}
+
+// Prune remove do índice os arquivos fora de files: apagados, movidos ou que deixaram de ser código do domínio.
+func (ix *Index) Prune(files []string) int {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+
+	current := make(map[string]bool, len(files))
+	for _, file := range files {
+		current[ix.key(file)] = true
+	}
+
+	removed := 0
+	for key := range ix.Files {
+		if !current[key] {
+			delete(ix.Files, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		ix.dirty = true
+	}
+	return removed
+}
+
+// Save grava o índice se houve mudanças desde que foi carregado.
+func (ix *Index) Save() error {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+
+	if !ix.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+
+	// Temporário exclusivo: outro processo pode estar salvando o mesmo domínio
+	tmp, err := os.CreateTemp(filepath.Dir(ix.path), filepath.Base(ix.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), ix.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	ix.dirty = false
+	return nil
+}
+
+// key guarda os caminhos relativos ao projeto para que o índice sobreviva a mudanças de diretório.
+func (ix *Index) key(file string) string {
+	if rel, err := filepath.Rel(ix.root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+func chunkFile(content string) []Chunk {
+	lines := strings.Split(content, "\n")
+	var chunks []Chunk
+
+	for start := 0; start < len(lines); start += chunkStride {
+		end := min(start+chunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+
+		terms := make(map[string]int)
+		length := 0
+		for _, term := range Tokenize(text) {
+			terms[term]++
+			length++
+		}
+		if length > 0 {
+			chunks = append(chunks, Chunk{StartLine: start + 1, EndLine: end, Text: text, Terms: terms, Length: length})
+		}
+
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
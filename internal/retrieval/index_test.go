@@ -0,0 +1,154 @@
+package retrieval
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexUpdate(t *testing.T) {
+	root := t.TempDir()
+	login := filepath.Join(root, "auth", "login.go")
+	token := filepath.Join(root, "auth", "token.go")
+	writeFile(t, login, "func Login(user, password string) {}")
+	writeFile(t, token, "func IssueToken(user string) string {}")
+
+	index, err := Open(root, "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{login, token}
+
+	tests := []struct {
+		name    string
+		prepare func()
+		changed int
+		indexed []string
+	}{
+		{"primeira indexação", func() {}, 2, []string{"auth/login.go", "auth/token.go"}},
+		{"nada mudou", func() {}, 0, []string{"auth/login.go", "auth/token.go"}},
+		{"só o mtime mudou", func() {
+			later := time.Now().Add(time.Hour)
+			os.Chtimes(login, later, later)
+		}, 0, []string{"auth/login.go", "auth/token.go"}},
+		{"conteúdo mudou", func() {
+			writeFile(t, token, "func IssueToken(user string, ttl int) string {}")
+		}, 1, []string{"auth/login.go", "auth/token.go"}},
+		{"arquivo passou do limite sai do índice", func() {
+			writeFile(t, token, strings.Repeat("x", maxFileSize+1))
+		}, 0, []string{"auth/login.go"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			changed, err := index.Update(files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != test.changed {
+				t.Errorf("changed = %d, esperado %d", changed, test.changed)
+			}
+			if got := indexedFiles(index); strings.Join(got, ",") != strings.Join(test.indexed, ",") {
+				t.Errorf("indexados = %v, esperado %v", got, test.indexed)
+			}
+		})
+	}
+}
+
+func TestIndexPrune(t *testing.T) {
+	root := t.TempDir()
+	kept := filepath.Join(root, "auth", "login.go")
+	moved := filepath.Join(root, "auth", "legacy.go")
+	writeFile(t, kept, "func Login() {}")
+	writeFile(t, moved, "func Legacy() {}")
+
+	index, _ := Open(root, "auth")
+	index.Update([]string{kept, moved})
+
+	if removed := index.Prune([]string{kept}); removed != 1 {
+		t.Errorf("removidos = %d, esperado 1", removed)
+	}
+	if got := indexedFiles(index); len(got) != 1 || got[0] != "auth/login.go" {
+		t.Errorf("indexados = %v", got)
+	}
+}
+
+func TestIndexSaveConcurrent(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "auth", "login.go")
+	writeFile(t, file, "func Login() {}")
+
+	first, _ := Open(root, "auth")
+	second, _ := Open(root, "auth")
+	if first != second {
+		t.Fatal("o mesmo domínio deveria compartilhar o índice no processo")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first.Update([]string{file})
+			if err := first.Save(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, _ := os.ReadDir(filepath.Dir(IndexPath(root, "auth")))
+	if len(entries) != 1 {
+		t.Errorf("sobraram arquivos temporários: %v", entries)
+	}
+	loaded, err := load(root, "auth", IndexPath(root, "auth"))
+	if err != nil || len(loaded.Files) != 1 {
+		t.Errorf("índice salvo inválido: %v, %d arquivos", err, len(loaded.Files))
+	}
+}
+
+func TestSearch(t *testing.T) {
+	root := t.TempDir()
+	login := filepath.Join(root, "auth", "login.go")
+	cart := filepath.Join(root, "auth", "cart.go")
+	writeFile(t, login, "func Login(password string) bool { return checkPassword(password) }")
+	writeFile(t, cart, "func AddToCart(item string) {}")
+
+	index, _ := Open(root, "auth")
+	index.Update([]string{login, cart})
+
+	results := index.Search("validar password do login", 5)
+	if len(results) == 0 || results[0].File != "auth/login.go" {
+		t.Fatalf("resultado inesperado: %+v", results)
+	}
+	if results[0].Location() != "auth/login.go:1-1" {
+		t.Errorf("Location = %s", results[0].Location())
+	}
+}
+
+func indexedFiles(index *Index) []string {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	var files []string
+	for key := range index.Files {
+		files = append(files, key)
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,194 @@
+package retrieval
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Parâmetros usuais do BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Result é um trecho encontrado, com a referência arquivo:linha.
+type Result struct {
+	File      string
+	StartLine int
+	EndLine   int
+	Text      string
+	Score     float64
+}
+
+func (r Result) Location() string {
+	return fmt.Sprintf("%s:%d-%d", r.File, r.StartLine, r.EndLine)
+}
+
+// Search devolve os k trechos mais relevantes para a consulta.
+func (ix *Index) Search(query string, k int) []Result {
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+
+	terms := unique(Tokenize(query))
+	if len(terms) == 0 || k <= 0 {
+		return nil
+	}
+
+	// Frequência de documentos e tamanho médio dos trechos
+	docs := 0
+	totalLength := 0
+	docFreq := make(map[string]int)
+	for _, entry := range ix.Files {
+		for _, chunk := range entry.Chunks {
+			docs++
+			totalLength += chunk.Length
+			for _, term := range terms {
+				if chunk.Terms[term] > 0 {
+					docFreq[term]++
+				}
+			}
+		}
+	}
+	if docs == 0 {
+		return nil
+	}
+	avgLength := float64(totalLength) / float64(docs)
+
+	var results []Result
+	for file, entry := range ix.Files {
+		for _, chunk := range entry.Chunks {
+			score := 0.0
+			for _, term := range terms {
+				tf := float64(chunk.Terms[term])
+				if tf == 0 {
+					continue
+				}
+				df := float64(docFreq[term])
+				idf := math.Log(1 + (float64(docs)-df+0.5)/(df+0.5))
+				norm := bm25K1 * (1 - bm25B + bm25B*float64(chunk.Length)/avgLength)
+				score += idf * tf * (bm25K1 + 1) / (tf + norm)
+			}
+			if score > 0 {
+				results = append(results, Result{File: file, StartLine: chunk.StartLine, EndLine: chunk.EndLine, Text: chunk.Text, Score: score})
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		return results[i].StartLine < results[j].StartLine
+	})
+
+	return dropOverlaps(results, k)
+}
+
+// dropOverlaps evita devolver dois trechos sobrepostos do mesmo arquivo.
+func dropOverlaps(results []Result, k int) []Result {
+	var kept []Result
+	for _, result := range results {
+		overlaps := false
+		for _, other := range kept {
+			if other.File == result.File && result.StartLine <= other.EndLine && other.StartLine <= result.EndLine {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, result)
+		}
+		if len(kept) == k {
+			break
+		}
+	}
+	return kept
+}
+
+// Format monta os trechos para o prompt, cada um precedido da sua referência arquivo:linha.
+func Format(results []Result) string {
+	var sections []string
+	for _, result := range results {
+		sections = append(sections, fmt.Sprintf("// %s\n%s", result.Location(), strings.TrimRight(result.Text, "\n")))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// Tokenize separa identificadores em termos minúsculos, quebrando camelCase e snake_case.
+func Tokenize(text string) []string {
+	var terms []string
+
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, field := range fields {
+		parts := splitIdentifier(field)
+		if len(parts) > 1 {
+			// Mantém o identificador inteiro além das partes
+			if whole := strings.ToLower(strings.ReplaceAll(field, "_", "")); len(whole) > 1 {
+				terms = append(terms, whole)
+			}
+		}
+		for _, part := range parts {
+			if len(part) > 1 && !stopwords[part] {
+				terms = append(terms, part)
+			}
+		}
+	}
+	return terms
+}
+
+func splitIdentifier(identifier string) []string {
+	var parts []string
+	var current []rune
+
+	runes := []rune(identifier)
+	for i, r := range runes {
+		if r == '_' {
+			if len(current) > 0 {
+				parts = append(parts, strings.ToLower(string(current)))
+				current = nil
+			}
+			continue
+		}
+
+		// Fronteira camelCase: aB, ou ABc (fim de sigla)
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				parts = append(parts, strings.ToLower(string(current)))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		parts = append(parts, strings.ToLower(string(current)))
+	}
+	return parts
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+var stopwords = map[string]bool{
+	"the": true, "and": true, "of": true, "to": true, "in": true, "is": true, "for": true, "on": true,
+	"de": true, "da": true, "do": true, "em": true, "um": true, "uma": true, "para": true, "com": true,
+	"que": true, "os": true, "as": true, "no": true, "na": true, "se": true,
+}
@@ -0,0 +1,161 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Access string
+
+const (
+	Read  Access = "read"
+	Write Access = "write"
+)
+
+func (a Access) Label() string {
+	if a == Write {
+		return "escrita"
+	}
+	return "leitura"
+}
+
+// Policy são os caminhos, relativos à raiz do projeto, que um agente pode ler e escrever.
+// Lista vazia libera o projeto inteiro; nada fora da raiz é permitido.
+type Policy struct {
+	Agent string
+	Root  string
+	Read  []string
+	Write []string
+}
+
+// Violation é um acesso de um agente fora da sua política.
+type Violation struct {
+	Agent  string
+	Access Access
+	// Path é o caminho pedido, relativo à raiz quando está dentro dela
+	Path    string
+	Allowed []string
+	// Outside indica que o caminho sai do projeto, o que nenhuma política permite
+	Outside bool
+}
+
+func (v *Violation) Error() string {
+	if v.Outside {
+		return fmt.Sprintf("%s: %s de %s fora do projeto", v.Agent, v.Access.Label(), v.Path)
+	}
+	return fmt.Sprintf("%s sem permissão de %s em %s (permitido: %s)", v.Agent, v.Access.Label(), v.Path, strings.Join(v.Allowed, ", "))
+}
+
+func (p Policy) paths(access Access) []string {
+	if access == Write {
+		return p.Write
+	}
+	return p.Read
+}
+
+// Check resolve o caminho (relativo à raiz ou absoluto) e confere se o agente pode acessá-lo;
+// devolve o caminho absoluto, ou uma *Violation já contabilizada nas métricas.
+func (p Policy) Check(access Access, path string) (string, error) {
+	abs := filepath.Clean(filepath.Join(p.Root, filepath.FromSlash(path)))
+	if filepath.IsAbs(path) {
+		abs = filepath.Clean(path)
+	}
+
+	rel, ok := inside(p.Root, abs)
+	target := rel
+	if ok {
+		// Um link simbólico dentro do projeto não pode levar para fora dele, nem para fora dos caminhos permitidos:
+		// as permissões valem para onde o link aponta
+		target, ok = inside(resolve(p.Root), resolve(abs))
+	}
+	if !ok {
+		return "", p.violation(access, path, true)
+	}
+	if !p.Allows(access, target) {
+		return "", p.violation(access, rel, false)
+	}
+	return abs, nil
+}
+
+func (p Policy) CheckRead(path string) (string, error) {
+	return p.Check(Read, path)
+}
+
+func (p Policy) CheckWrite(path string) (string, error) {
+	return p.Check(Write, path)
+}
+
+// Allows indica se o caminho relativo à raiz está dentro de algum caminho permitido para o acesso.
+func (p Policy) Allows(access Access, rel string) bool {
+	allowed := p.paths(access)
+	if len(allowed) == 0 {
+		return true
+	}
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	for _, path := range allowed {
+		path = strings.Trim(filepath.ToSlash(path), "/")
+		if path == "" || path == "." || rel == path || strings.HasPrefix(rel, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Policy) violation(access Access, path string, outside bool) *Violation {
+	v := &Violation{Agent: p.Agent, Access: access, Path: filepath.ToSlash(path), Allowed: p.paths(access), Outside: outside}
+	Report(v)
+	return v
+}
+
+// Outside é a violação de um caminho que sai do projeto; quem a barrar deve contabilizá-la com Report.
+func Outside(agent string, access Access, path string) *Violation {
+	return &Violation{Agent: agent, Access: access, Path: filepath.ToSlash(path), Outside: true}
+}
+
+// inside devolve o caminho relativo a root, com barras, se abs estiver dentro dele.
+func inside(root, abs string) (string, bool) {
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// resolve segue os links simbólicos do maior prefixo existente do caminho (o arquivo pode ainda não existir).
+func resolve(path string) string {
+	suffix := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, suffix)
+		}
+		if _, err := os.Lstat(dir); err == nil || filepath.Dir(dir) == dir {
+			return path
+		}
+		suffix = filepath.Join(filepath.Base(dir), suffix)
+	}
+}
+
+// Escalation leva as violações de uma escrita a uma pessoa.
+type Escalation interface {
+	// Escalate devolve as violações liberadas (as demais ficam de fora) ou erro para barrar a escrita inteira;
+	// pending é gravado com o pedido para que a retomada aplique a mesma escrita.
+	Escalate(ctx context.Context, violations []*Violation, pending []byte) ([]*Violation, error)
+	// Pending devolve o que foi gravado com uma escalada do agente na etapa atual que ainda não foi aplicada.
+	Pending(ctx context.Context, agent string) ([]byte, bool)
+}
+
+type escalationKey struct{}
+
+// With faz as escritas fora da política serem escaladas em vez de falhar.
+func With(ctx context.Context, escalation Escalation) context.Context {
+	return context.WithValue(ctx, escalationKey{}, escalation)
+}
+
+// From devolve a escalação da execução, ou nil quando violações devem falhar.
+func From(ctx context.Context) Escalation {
+	escalation, _ := ctx.Value(escalationKey{}).(Escalation)
+	return escalation
+}
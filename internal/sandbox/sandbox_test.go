@@ -0,0 +1,114 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{"auth", "products", "shared"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"auth/products": "../products",
+		"auth/fora":     outside,
+		"products/auth": "../auth",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("sem links simbólicos: %v", err)
+		}
+	}
+
+	policy := Policy{Agent: "auth", Root: root, Read: []string{"auth", "shared"}, Write: []string{"auth"}}
+
+	tests := []struct {
+		name    string
+		access  Access
+		path    string
+		denied  bool
+		outside bool
+	}{
+		{name: "escreve no domínio", access: Write, path: "auth/login.go"},
+		{name: "lê caminho compartilhado", access: Read, path: "shared/types.go"},
+		{name: "escreve em outro domínio", access: Write, path: "products/list.go", denied: true},
+		{name: "link para outro domínio", access: Write, path: "auth/products/list.go", denied: true},
+		{name: "link de outro domínio para o próprio", access: Write, path: "products/auth/login.go"},
+		{name: "link para fora do projeto", access: Read, path: "auth/fora/segredo", outside: true},
+		{name: "sobe para fora da raiz", access: Read, path: "../etc/passwd", outside: true},
+		{name: "absoluto fora da raiz", access: Read, path: outside, outside: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			abs, err := policy.Check(test.access, test.path)
+			if !test.denied && !test.outside {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if abs != filepath.Join(root, filepath.FromSlash(test.path)) {
+					t.Errorf("caminho = %s", abs)
+				}
+				return
+			}
+
+			var violation *Violation
+			if !errors.As(err, &violation) {
+				t.Fatalf("esperava *Violation, veio %v", err)
+			}
+			if violation.Outside != test.outside || violation.Access != test.access {
+				t.Errorf("violação = %+v", violation)
+			}
+			if test.denied && violation.Path != test.path {
+				t.Errorf("Path = %s, esperado o caminho pedido %s", violation.Path, test.path)
+			}
+		})
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	tests := []struct {
+		allowed []string
+		rel     string
+		want    bool
+	}{
+		{nil, "qualquer/coisa.go", true},
+		{[]string{"auth"}, "auth", true},
+		{[]string{"auth/"}, "auth/login.go", true},
+		{[]string{"auth"}, "authz/login.go", false},
+		{[]string{"user/profile"}, "user/settings.go", false},
+		{[]string{"."}, "products/list.go", true},
+	}
+
+	for _, test := range tests {
+		policy := Policy{Agent: "auth", Write: test.allowed}
+		if got := policy.Allows(Write, test.rel); got != test.want {
+			t.Errorf("Allows(%v, %s) = %v, esperado %v", test.allowed, test.rel, got, test.want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	root := t.TempDir()
+	PersistStats(root)
+	t.Cleanup(func() { stats = newStats() })
+
+	violation := &Violation{Agent: "stats", Access: Write, Path: "products/x.go"}
+	Report(violation)
+	Report(&Violation{Agent: "stats", Access: Read, Path: "products/y.go"})
+	Escalated(violation, true)
+
+	want := Counters{Reads: 1, Writes: 1, Escalated: 1, Approved: 1}
+	if got := Stats()["stats"]; got != want {
+		t.Errorf("contadores = %+v, esperado %+v", got, want)
+	}
+	if saved := LoadStats(root)["stats"]; saved != want {
+		t.Errorf("contadores salvos = %+v, esperado %+v", saved, want)
+	}
+}
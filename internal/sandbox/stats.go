@@ -0,0 +1,71 @@
+package sandbox
+
+import "plaxo-orchestra/internal/observability"
+
+// Counters conta as violações de um agente e o que foi decidido nas escaladas.
+type Counters struct {
+	Reads     int `json:"reads"`
+	Writes    int `json:"writes"`
+	Escalated int `json:"escalated"`
+	Approved  int `json:"approved"`
+}
+
+// Violations é o total de acessos barrados ou escalados.
+func (c Counters) Violations() int {
+	return c.Reads + c.Writes
+}
+
+func (c Counters) add(other Counters) Counters {
+	c.Reads += other.Reads
+	c.Writes += other.Writes
+	c.Escalated += other.Escalated
+	c.Approved += other.Approved
+	return c
+}
+
+var stats = newStats()
+
+func newStats() *observability.Counters[Counters] {
+	return observability.NewCounters("sandbox.json", Counters.add)
+}
+
+// StatsPath devolve onde os contadores do projeto são salvos.
+func StatsPath(workingDir string) string {
+	return stats.Path(workingDir)
+}
+
+// PersistStats passa a acumular os contadores no arquivo do projeto.
+func PersistStats(workingDir string) {
+	stats.Persist(workingDir)
+}
+
+// LoadStats lê os contadores salvos do projeto.
+func LoadStats(workingDir string) map[string]Counters {
+	return stats.Load(workingDir)
+}
+
+// Stats devolve uma cópia dos contadores atuais.
+func Stats() map[string]Counters {
+	return stats.Snapshot()
+}
+
+// Report contabiliza uma violação barrada ou escalada.
+func Report(v *Violation) {
+	stats.Record(v.Agent, func(c *Counters) {
+		if v.Access == Write {
+			c.Writes++
+		} else {
+			c.Reads++
+		}
+	})
+}
+
+// Escalated registra a decisão humana sobre uma violação escalada.
+func Escalated(v *Violation, approved bool) {
+	stats.Record(v.Agent, func(c *Counters) {
+		c.Escalated++
+		if approved {
+			c.Approved++
+		}
+	})
+}
@@ -1,10 +1,9 @@
 package stream
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"os/exec"
+	"plaxo-orchestra/internal/backend"
 	"strings"
 	"time"
 )
@@ -50,61 +49,15 @@ func (sh *StreamHandler) ExecuteWithStream(ctx context.Context, request string)
 	sh.onProgress("🎯 Iniciando processamento...\n")
 	time.Sleep(200 * time.Millisecond)
 	
-	// Execute Q CLI with streaming
-	cmd := exec.CommandContext(ctx, "q", "chat", "--no-interactive", request)
-	
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		result.Error = err
-		sh.onError(err)
-		return result
-	}
-	
-	stderr, err := cmd.StderrPipe()
+	// Execute backend with streaming
+	response, err := backend.Default().Stream(ctx, backend.Request{Prompt: request}, sh.onProgress)
 	if err != nil {
 		result.Error = err
 		sh.onError(err)
 		return result
 	}
 	
-	if err := cmd.Start(); err != nil {
-		result.Error = err
-		sh.onError(err)
-		return result
-	}
-	
-	// Stream output in real-time
-	var content strings.Builder
-	
-	// Read stdout
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Text()
-			content.WriteString(line + "\n")
-			sh.onProgress(line + "\n")
-		}
-	}()
-	
-	// Read stderr
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.Contains(line, "Error") || strings.Contains(line, "error") {
-				sh.onProgress("⚠️  " + line + "\n")
-			}
-		}
-	}()
-	
-	// Wait for completion
-	if err := cmd.Wait(); err != nil {
-		result.Error = err
-		sh.onError(err)
-		return result
-	}
-	
-	result.Content = content.String()
+	result.Content = response.Content
 	sh.onComplete(result.Content)
 	
 	return result
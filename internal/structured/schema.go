@@ -0,0 +1,208 @@
+package structured
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema é o subconjunto de JSON Schema gerado a partir dos tipos Go.
+//
+// Tags reconhecidas nos campos: `desc:"..."` (descrição), `enum:"a,b,c"` e
+// `minitems:"N"`. Campos com omitempty no json não são obrigatórios.
+type Schema struct {
+	Type                 string             `json:"type"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	MinItems             int                `json:"minItems,omitempty"`
+}
+
+// SchemaOf gera o schema do tipo apontado por v.
+func SchemaOf(v interface{}) *Schema {
+	return schemaFor(reflect.TypeOf(v))
+}
+
+func schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, omitempty := jsonName(field)
+			if name == "-" {
+				continue
+			}
+
+			property := schemaFor(field.Type)
+			property.Description = field.Tag.Get("desc")
+			if enum := field.Tag.Get("enum"); enum != "" {
+				property.Enum = strings.Split(enum, ",")
+			}
+			if minItems, err := strconv.Atoi(field.Tag.Get("minitems")); err == nil {
+				property.MinItems = minItems
+			}
+
+			schema.Properties[name] = property
+			if !omitempty {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		sort.Strings(schema.Required)
+		return schema
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{}
+	}
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name, false
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	omitempty := false
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
+
+// Validate confere um valor decodificado de JSON contra o schema e devolve os erros encontrados.
+func (s *Schema) Validate(value interface{}) []string {
+	var errors []string
+	s.validate(value, "$", &errors)
+	return errors
+}
+
+func (s *Schema) validate(value interface{}, path string, errors *[]string) {
+	if value == nil {
+		*errors = append(*errors, fmt.Sprintf("%s: esperado %s, recebido null", path, s.Type))
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*errors = append(*errors, fmt.Sprintf("%s: esperado object, recebido %s", path, typeName(value)))
+			return
+		}
+		for _, name := range s.Required {
+			if _, exists := object[name]; !exists {
+				*errors = append(*errors, fmt.Sprintf("%s.%s: campo obrigatório ausente", path, name))
+			}
+		}
+		for _, name := range sortedNames(object) {
+			if property, known := s.Properties[name]; known {
+				property.validate(object[name], path+"."+name, errors)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(object[name], path+"."+name, errors)
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			*errors = append(*errors, fmt.Sprintf("%s: esperado array, recebido %s", path, typeName(value)))
+			return
+		}
+		if len(array) < s.MinItems {
+			*errors = append(*errors, fmt.Sprintf("%s: esperado ao menos %d itens, recebido %d", path, s.MinItems, len(array)))
+		}
+		for i, item := range array {
+			s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errors)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			*errors = append(*errors, fmt.Sprintf("%s: esperado string, recebido %s", path, typeName(value)))
+			return
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, text) {
+			*errors = append(*errors, fmt.Sprintf("%s: valor %q não está entre %s", path, text, strings.Join(s.Enum, ", ")))
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			*errors = append(*errors, fmt.Sprintf("%s: esperado integer, recebido %s", path, typeName(value)))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			*errors = append(*errors, fmt.Sprintf("%s: esperado number, recebido %s", path, typeName(value)))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*errors = append(*errors, fmt.Sprintf("%s: esperado boolean, recebido %s", path, typeName(value)))
+		}
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func sortedNames(object map[string]interface{}) []string {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package structured
+
+import (
+	"fmt"
+
+	"plaxo-orchestra/internal/observability"
+)
+
+// Counters mostra com que frequência o caminho estruturado funciona para cada chamada.
+type Counters struct {
+	Calls     int `json:"calls"`
+	FirstTry  int `json:"first_try"`
+	Repaired  int `json:"repaired"`
+	Invalid   int `json:"invalid"`
+	Errors    int `json:"errors"`
+	Fallbacks int `json:"fallbacks"`
+}
+
+// SuccessRate é a fração de chamadas que terminaram com JSON válido.
+func (c Counters) SuccessRate() float64 {
+	if c.Calls == 0 {
+		return 0
+	}
+	return float64(c.FirstTry+c.Repaired) / float64(c.Calls)
+}
+
+func (c Counters) add(other Counters) Counters {
+	c.Calls += other.Calls
+	c.FirstTry += other.FirstTry
+	c.Repaired += other.Repaired
+	c.Invalid += other.Invalid
+	c.Errors += other.Errors
+	c.Fallbacks += other.Fallbacks
+	return c
+}
+
+var stats = observability.NewCounters("structured.json", Counters.add)
+
+// StatsPath devolve onde os contadores do projeto são salvos.
+func StatsPath(workingDir string) string {
+	return stats.Path(workingDir)
+}
+
+// PersistStats passa a acumular os contadores no arquivo do projeto.
+func PersistStats(workingDir string) {
+	stats.Persist(workingDir)
+}
+
+// LoadStats lê os contadores salvos do projeto.
+func LoadStats(workingDir string) map[string]Counters {
+	return stats.Load(workingDir)
+}
+
+// Stats devolve uma cópia dos contadores atuais.
+func Stats() map[string]Counters {
+	return stats.Snapshot()
+}
+
+// Fallback registra que o chamador desistiu da saída estruturada e usou a heurística.
+func Fallback(name string, err error) {
+	fmt.Printf("⚠️  %s: usando heurística (%v)\n", name, err)
+	stats.Record(name, func(c *Counters) { c.Fallbacks++ })
+}
@@ -0,0 +1,129 @@
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"plaxo-orchestra/internal/prompts"
+	"reflect"
+	"strings"
+)
+
+// DefaultMaxRepairs é quantas vezes a resposta inválida é devolvida ao modelo para correção.
+const DefaultMaxRepairs = 2
+
+// Completer envia um prompt ao modelo e devolve o texto da resposta.
+type Completer func(ctx context.Context, prompt string) (string, error)
+
+type Request struct {
+	// Name identifica a chamada nas métricas (ex: "semantic_analysis")
+	Name       string
+	Prompt     string
+	MaxRepairs int
+	// Check faz validações que o schema não expressa, depois que o alvo foi preenchido
+	Check func() []string
+}
+
+// ValidationError indica que nenhuma resposta passou na validação após os reparos.
+type ValidationError struct {
+	Name     string
+	Attempts int
+	Errors   []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("saída estruturada %s inválida após %d tentativas: %s", e.Name, e.Attempts, strings.Join(e.Errors, "; "))
+}
+
+// Generate pede ao modelo um JSON no formato de target, valida e re-pergunta com os erros até MaxRepairs vezes.
+func Generate(ctx context.Context, complete Completer, req Request, target interface{}) error {
+	schema := SchemaOf(target)
+	schemaJSON, _ := json.MarshalIndent(schema, "", "  ")
+
+	maxRepairs := req.MaxRepairs
+	if maxRepairs <= 0 {
+		maxRepairs = DefaultMaxRepairs
+	}
+
+	prompt := prompts.Render("structured_output", map[string]interface{}{
+		"Prompt": req.Prompt,
+		"Schema": string(schemaJSON),
+	})
+
+	var errors []string
+	for attempt := 0; attempt <= maxRepairs; attempt++ {
+		response, err := complete(ctx, prompt)
+		if err != nil {
+			stats.Record(req.Name, func(c *Counters) { c.Calls++; c.Errors++ })
+			return err
+		}
+
+		errors = decode(response, schema, target)
+		if len(errors) == 0 && req.Check != nil {
+			errors = req.Check()
+		}
+		if len(errors) == 0 {
+			stats.Record(req.Name, func(c *Counters) {
+				c.Calls++
+				if attempt == 0 {
+					c.FirstTry++
+				} else {
+					c.Repaired++
+				}
+			})
+			return nil
+		}
+
+		prompt = prompts.Render("structured_repair", map[string]interface{}{
+			"Prompt":   req.Prompt,
+			"Schema":   string(schemaJSON),
+			"Response": response,
+			"Errors":   errors,
+		})
+	}
+
+	stats.Record(req.Name, func(c *Counters) { c.Calls++; c.Invalid++ })
+	return &ValidationError{Name: req.Name, Attempts: maxRepairs + 1, Errors: errors}
+}
+
+func decode(response string, schema *Schema, target interface{}) []string {
+	raw, err := extractJSON(response)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return []string{fmt.Sprintf("JSON inválido: %v", err)}
+	}
+	if errors := schema.Validate(value); len(errors) > 0 {
+		return errors
+	}
+
+	// Cada tentativa começa do zero: campos ausentes no reparo não podem herdar valores da resposta anterior
+	zeroed := reflect.ValueOf(target).Elem()
+	zeroed.Set(reflect.Zero(zeroed.Type()))
+	if err := json.Unmarshal([]byte(raw), target); err != nil {
+		return []string{fmt.Sprintf("JSON incompatível: %v", err)}
+	}
+	return nil
+}
+
+// extractJSON acha o primeiro valor JSON completo na resposta, ignorando texto e cercas de markdown ao redor.
+func extractJSON(text string) (string, error) {
+	for offset := 0; offset < len(text); {
+		start := strings.IndexAny(text[offset:], "{[")
+		if start == -1 {
+			break
+		}
+		start += offset
+
+		decoder := json.NewDecoder(strings.NewReader(text[start:]))
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == nil {
+			return string(raw), nil
+		}
+		offset = start + 1
+	}
+	return "", fmt.Errorf("nenhum JSON encontrado na resposta")
+}
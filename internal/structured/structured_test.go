@@ -0,0 +1,154 @@
+package structured
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type issue struct {
+	Domain string `json:"domain"`
+	Text   string `json:"text"`
+}
+
+type verdict struct {
+	Status   string            `json:"status" enum:"pass,fail"`
+	Issues   []issue           `json:"issues,omitempty"`
+	Keywords map[string]string `json:"keywords,omitempty"`
+	Score    int               `json:"score,omitempty"`
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		err  bool
+	}{
+		{"JSON puro", `{"a":1}`, `{"a":1}`, false},
+		{"cerca de markdown", "Aqui está:\n```json\n{\"a\": [1, 2]}\n```", `{"a": [1, 2]}`, false},
+		{"chave solta antes do JSON", "use {x} e depois {\"a\":true}", `{"a":true}`, false},
+		{"array", "resultado: [1,2]", `[1,2]`, false},
+		{"sem JSON", "nada aqui", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := extractJSON(test.text)
+			if (err != nil) != test.err {
+				t.Fatalf("erro = %v, esperado erro: %v", err, test.err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, esperado %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := SchemaOf(&verdict{})
+
+	tests := []struct {
+		name  string
+		json  string
+		wants []string
+	}{
+		{"válido", `{"status":"pass"}`, nil},
+		{"obrigatório ausente", `{}`, []string{"$.status: campo obrigatório ausente"}},
+		{"fora do enum", `{"status":"ok"}`, []string{`$.status: valor "ok" não está entre pass, fail`}},
+		{"tipo errado em item", `{"status":"fail","issues":[{"domain":1,"text":"x"}]}`, []string{"$.issues[0].domain: esperado string, recebido number"}},
+		{"inteiro com fração", `{"status":"pass","score":1.5}`, []string{"$.score: esperado integer, recebido number"}},
+		{"mapa com valor errado", `{"status":"pass","keywords":{"a":true}}`, []string{"$.keywords.a: esperado string, recebido boolean"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var target verdict
+			got := decode(test.json, schema, &target)
+			if strings.Join(got, "|") != strings.Join(test.wants, "|") {
+				t.Errorf("erros = %v, esperado %v", got, test.wants)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		check     func(*verdict) []string
+		want      verdict
+		attempts  int
+		invalid   bool
+	}{
+		{
+			name:      "primeira resposta válida",
+			responses: []string{`{"status":"pass"}`},
+			want:      verdict{Status: "pass"},
+			attempts:  1,
+		},
+		{
+			name:      "reparo não herda campos da resposta rejeitada",
+			responses: []string{`{"status":"talvez","issues":[{"domain":"auth","text":"x"}],"keywords":{"a":"b"}}`, `{"status":"pass"}`},
+			want:      verdict{Status: "pass"},
+			attempts:  2,
+		},
+		{
+			name:      "Check pede reparo",
+			responses: []string{`{"status":"fail","issues":[{"domain":"auth","text":"x"}],"score":3}`, `{"status":"fail","issues":[{"domain":"user","text":"y"}]}`},
+			check: func(v *verdict) []string {
+				for _, issue := range v.Issues {
+					if issue.Domain != "user" {
+						return []string{"domínio desconhecido"}
+					}
+				}
+				return nil
+			},
+			want:     verdict{Status: "fail", Issues: []issue{{Domain: "user", Text: "y"}}},
+			attempts: 2,
+		},
+		{
+			name:      "esgota os reparos",
+			responses: []string{"não sei", "ainda não", "desisto"},
+			attempts:  3,
+			invalid:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var target verdict
+			calls := 0
+			complete := func(ctx context.Context, prompt string) (string, error) {
+				response := test.responses[calls]
+				calls++
+				return response, nil
+			}
+			request := Request{Name: "teste", Prompt: "avalie"}
+			if test.check != nil {
+				request.Check = func() []string { return test.check(&target) }
+			}
+
+			err := Generate(context.Background(), complete, request, &target)
+			var invalid *ValidationError
+			if errors.As(err, &invalid) != test.invalid {
+				t.Fatalf("erro = %v", err)
+			}
+			if calls != test.attempts {
+				t.Errorf("tentativas = %d, esperado %d", calls, test.attempts)
+			}
+			if test.invalid {
+				return
+			}
+			if target.Status != test.want.Status || target.Score != test.want.Score || len(target.Keywords) != len(test.want.Keywords) || len(target.Issues) != len(test.want.Issues) {
+				t.Fatalf("alvo = %+v, esperado %+v", target, test.want)
+			}
+			for i := range target.Issues {
+				if target.Issues[i] != test.want.Issues[i] {
+					t.Errorf("issues[%d] = %+v, esperado %+v", i, target.Issues[i], test.want.Issues[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Ferramentas embutidas; todas menos run_tests vêm listadas nos manifestos novos.
+func init() {
+	Register(readFile{})
+	Register(listDir{})
+	Register(grep{})
+	Register(runTests{})
+	Register(gitDiff{})
+}
+
+// Limites das ferramentas embutidas
+const (
+	maxGrepMatches = 50
+	testTimeout    = 3 * time.Minute
+)
+
+type readFile struct{}
+
+func (readFile) Name() string { return "read_file" }
+
+func (readFile) Description() string {
+	return "lê um arquivo; argumentos: caminho[:início-fim] (ex: user/login.go:10-40)"
+}
+
+func (readFile) Run(ctx context.Context, scope Scope, args string) (string, error) {
+	path, start, end := splitLines(args)
+	abs, err := scope.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if end == 0 || end > len(lines) {
+		end = len(lines)
+	}
+	if start < 1 {
+		start = 1
+	}
+	if start > end {
+		return "", fmt.Errorf("intervalo %d-%d fora do arquivo (%d linhas)", start, end, len(lines))
+	}
+
+	var b strings.Builder
+	for i := start; i <= end; i++ {
+		fmt.Fprintf(&b, "%4d  %s\n", i, lines[i-1])
+	}
+	return b.String(), nil
+}
+
+// splitLines separa "caminho:10-40" em caminho e intervalo de linhas.
+func splitLines(args string) (string, int, int) {
+	args = strings.TrimSpace(args)
+	i := strings.LastIndex(args, ":")
+	if i == -1 {
+		return args, 0, 0
+	}
+	var start, end int
+	if n, _ := fmt.Sscanf(args[i+1:], "%d-%d", &start, &end); n == 0 {
+		return args, 0, 0
+	}
+	if end == 0 {
+		end = start
+	}
+	return args[:i], start, end
+}
+
+type listDir struct{}
+
+func (listDir) Name() string { return "list_dir" }
+
+func (listDir) Description() string {
+	return "lista um diretório; argumentos: caminho (vazio lista o diretório do domínio)"
+}
+
+func (listDir) Run(ctx context.Context, scope Scope, args string) (string, error) {
+	abs, err := scope.Resolve(args)
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if entry.IsDir() {
+			lines = append(lines, entry.Name()+"/")
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d bytes)", entry.Name(), info.Size()))
+	}
+	if len(lines) == 0 {
+		return "(vazio)", nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+type grep struct{}
+
+func (grep) Name() string { return "grep" }
+
+func (grep) Description() string {
+	return "procura uma expressão regular nos arquivos do domínio; argumentos: padrão (ex: func Validate)"
+}
+
+func (grep) Run(ctx context.Context, scope Scope, args string) (string, error) {
+	pattern, err := regexp.Compile(strings.TrimSpace(args))
+	if err != nil {
+		return "", fmt.Errorf("padrão inválido: %v", err)
+	}
+
+	var matches []string
+	for _, dir := range scope.Dirs() {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || ctx.Err() != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if path != dir && (strings.HasPrefix(info.Name(), ".") || info.Name() == "node_modules" || info.Name() == "vendor") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if len(matches) >= maxGrepMatches {
+				return filepath.SkipAll
+			}
+			matches = append(matches, grepFile(scope.Root, path, pattern, maxGrepMatches-len(matches))...)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(matches) == 0 {
+		return "(nenhuma ocorrência)", nil
+	}
+	if len(matches) >= maxGrepMatches {
+		matches = append(matches, fmt.Sprintf("... (mostrando as primeiras %d ocorrências)", maxGrepMatches))
+	}
+	return strings.Join(matches, "\n"), nil
+}
+
+func grepFile(root, path string, pattern *regexp.Regexp, limit int) []string {
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 512)], 0) != -1 {
+		return nil
+	}
+	rel, _ := filepath.Rel(root, path)
+
+	var matches []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan() && len(matches) < limit; line++ {
+		if pattern.MatchString(scanner.Text()) {
+			matches = append(matches, fmt.Sprintf("%s:%d: %s", filepath.ToSlash(rel), line, strings.TrimSpace(scanner.Text())))
+		}
+	}
+	return matches
+}
+
+type runTests struct{}
+
+func (runTests) Name() string { return "run_tests" }
+
+func (runTests) Description() string {
+	return "roda os testes do domínio (go test, npm test ou pytest, conforme o projeto); argumentos: caminho opcional"
+}
+
+func (runTests) Run(ctx context.Context, scope Scope, args string) (string, error) {
+	var targets []string
+	if strings.TrimSpace(args) != "" {
+		abs, err := scope.Resolve(args)
+		if err != nil {
+			return "", err
+		}
+		targets = []string{abs}
+	} else {
+		targets = scope.Dirs()
+	}
+
+	var name string
+	var cmdArgs []string
+	dir := scope.Root
+	switch {
+	case exists(filepath.Join(scope.Root, "go.mod")):
+		name, cmdArgs = "go", []string{"test"}
+		for _, target := range targets {
+			rel, _ := filepath.Rel(scope.Root, target)
+			cmdArgs = append(cmdArgs, "./"+filepath.ToSlash(filepath.Join(rel, "...")))
+		}
+	case exists(filepath.Join(scope.Root, "package.json")):
+		// npm test não recebe caminhos: só roda quando o domínio tem o próprio package.json
+		if len(targets) != 1 || targets[0] == scope.Root || !exists(filepath.Join(targets[0], "package.json")) {
+			return "", fmt.Errorf("npm test rodaria o projeto inteiro; informe um diretório do domínio com package.json próprio")
+		}
+		name, cmdArgs, dir = "npm", []string{"test", "--silent"}, targets[0]
+	case exists(filepath.Join(scope.Root, "pyproject.toml")) || exists(filepath.Join(scope.Root, "pytest.ini")):
+		name, cmdArgs = "python", append([]string{"-m", "pytest", "-q"}, targets...)
+	default:
+		return "", fmt.Errorf("nenhum projeto de testes reconhecido (go.mod, package.json, pyproject.toml)")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, cmdArgs...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+
+	result := fmt.Sprintf("$ %s %s\n%s", name, strings.Join(cmdArgs, " "), output)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return result, fmt.Errorf("testes excederam %s", testTimeout)
+		}
+		// Falha de teste é resultado, não erro da ferramenta
+		return result + "\n" + err.Error(), nil
+	}
+	return result, nil
+}
+
+type gitDiff struct{}
+
+func (gitDiff) Name() string { return "git_diff" }
+
+func (gitDiff) Description() string {
+	return "mostra as alterações não commitadas do domínio; argumentos: caminho opcional"
+}
+
+func (gitDiff) Run(ctx context.Context, scope Scope, args string) (string, error) {
+	var targets []string
+	if strings.TrimSpace(args) != "" {
+		abs, err := scope.Resolve(args)
+		if err != nil {
+			return "", err
+		}
+		targets = []string{abs}
+	} else {
+		targets = scope.Dirs()
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"diff", "HEAD", "--"}, targets...)...)
+	cmd.Dir = scope.Root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git diff: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(output) == 0 {
+		return "(sem alterações)", nil
+	}
+	return string(output), nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"plaxo-orchestra/internal/markdown"
+	"plaxo-orchestra/internal/sandbox"
+)
+
+// Tool é uma ferramenta executada localmente a pedido de um agente, com o escopo do domínio dele.
+// Times registram as suas com Register; o manifesto de cada agente lista as que ele pode usar.
+type Tool interface {
+	Name() string
+	// Description explica ao modelo o que a ferramenta faz e o formato dos argumentos
+	Description() string
+	Run(ctx context.Context, scope Scope, args string) (string, error)
+}
+
+// Scope limita as ferramentas aos caminhos que o agente pode ler, relativos à raiz do projeto.
+type Scope struct {
+	Root  string
+	Agent string
+	Paths []string
+}
+
+// Resolve converte um caminho pedido pelo agente em absoluto; fora do escopo devolve uma *sandbox.Violation.
+// Vazio resolve para o primeiro diretório do agente (ou a raiz, se ele não declara nenhum).
+func (s Scope) Resolve(path string) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), "`\"'")
+	if path == "" {
+		return filepath.Join(s.Root, filepath.FromSlash(s.home())), nil
+	}
+	return s.policy().CheckRead(path)
+}
+
+// Allows indica se o caminho relativo à raiz está dentro de algum diretório do agente.
+func (s Scope) Allows(rel string) bool {
+	return s.policy().Allows(sandbox.Read, rel)
+}
+
+func (s Scope) policy() sandbox.Policy {
+	return sandbox.Policy{Agent: s.Agent, Root: s.Root, Read: s.Paths}
+}
+
+// Dirs são os diretórios absolutos do escopo.
+func (s Scope) Dirs() []string {
+	if len(s.Paths) == 0 {
+		return []string{s.Root}
+	}
+	var dirs []string
+	for _, path := range s.Paths {
+		dirs = append(dirs, filepath.Join(s.Root, filepath.FromSlash(path)))
+	}
+	return dirs
+}
+
+func (s Scope) home() string {
+	if len(s.Paths) == 0 {
+		return "."
+	}
+	return s.Paths[0]
+}
+
+var (
+	registry = make(map[string]Tool)
+	mutex    sync.RWMutex
+)
+
+// Register disponibiliza a ferramenta para os agentes cujo manifesto a lista; substitui outra com o mesmo nome.
+func Register(tool Tool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	registry[tool.Name()] = tool
+}
+
+func Lookup(name string) (Tool, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	tool, ok := registry[name]
+	return tool, ok
+}
+
+// Names lista as ferramentas registradas, em ordem alfabética.
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Allowed devolve as ferramentas registradas entre as permitidas, na ordem do manifesto.
+func Allowed(names []string) []Tool {
+	var allowed []Tool
+	for _, name := range names {
+		if tool, ok := Lookup(name); ok {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// Um agente chama uma ferramenta com uma linha "@tool <nome>: <argumentos>" (argumentos opcionais).
+var directive = regexp.MustCompile(`^[ \t>*-]*@tool[ \t]+([A-Za-z0-9_.-]+)[ \t]*(?::[ \t]*(.*?))?[ \t]*$`)
+
+type Call struct {
+	Tool string
+	Args string
+}
+
+func (c Call) String() string {
+	if c.Args == "" {
+		return c.Tool
+	}
+	return c.Tool + " " + c.Args
+}
+
+// Parse devolve as chamadas de ferramenta de uma resposta, na ordem em que aparecem; exemplos em blocos de código não rodam.
+func Parse(output string) []Call {
+	var calls []Call
+	for _, line := range markdown.OutsideFences(output) {
+		if match := directive.FindStringSubmatch(line); match != nil {
+			calls = append(calls, Call{Tool: match[1], Args: match[2]})
+		}
+	}
+	return calls
+}
+
+// Result é a saída (ou o erro) de uma chamada.
+type Result struct {
+	Call   Call
+	Output string
+	Error  string
+}
+
+// Limite de caracteres devolvidos ao agente por chamada
+const maxOutput = 8000
+
+// Run executa as chamadas permitidas a quem pediu; as demais voltam com erro para o agente.
+func Run(ctx context.Context, scope Scope, allowed []string, calls []Call) []Result {
+	permitted := make(map[string]bool)
+	for _, name := range allowed {
+		permitted[name] = true
+	}
+
+	var results []Result
+	for _, call := range calls {
+		result := Result{Call: call}
+		tool, ok := Lookup(call.Tool)
+		switch {
+		case !permitted[call.Tool]:
+			result.Error = fmt.Sprintf("ferramenta %s não permitida; disponíveis: %s", call.Tool, strings.Join(allowed, ", "))
+		case !ok:
+			result.Error = fmt.Sprintf("ferramenta %s não registrada", call.Tool)
+		default:
+			output, err := tool.Run(ctx, scope, call.Args)
+			if err != nil {
+				result.Error = err.Error()
+			}
+			result.Output = truncate(output, maxOutput)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func truncate(s string, limit int) string {
+	if runes := []rune(s); len(runes) > limit {
+		return string(runes[:limit]) + fmt.Sprintf("\n... (saída cortada em %d caracteres)", limit)
+	}
+	return s
+}
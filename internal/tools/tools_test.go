@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"plaxo-orchestra/internal/sandbox"
+)
+
+func project(t *testing.T, files map[string]string) Scope {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Scope{Root: root, Agent: "auth", Paths: []string{"auth"}}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"@tool read_file: auth/login.go:1-10", "read_file auth/login.go:1-10"},
+		{"- @tool list_dir", "list_dir"},
+		{"Vou usar @tool grep: x", ""},
+		{"@tool git_diff:\n@tool grep: func Login", "git_diff|grep func Login"},
+		{"Exemplo:\n```\n@tool run_tests\n```\n~~~md\n@tool git_diff\n~~~\n@tool list_dir: auth", "list_dir auth"},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, call := range Parse(test.output) {
+			got = append(got, call.String())
+		}
+		if strings.Join(got, "|") != test.want {
+			t.Errorf("Parse(%q) = %v, esperado %s", test.output, got, test.want)
+		}
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	scope := project(t, map[string]string{
+		"auth/login.go":    "package auth\n\nfunc Login() {}\n\nfunc Logout() {}\n",
+		"auth/.secret":     "x",
+		"products/list.go": "package products\n\nfunc Login() {}\n",
+	})
+
+	tests := []struct {
+		name    string
+		tool    string
+		args    string
+		want    string
+		absent  string
+		outside bool
+		err     string
+	}{
+		{name: "lê trecho", tool: "read_file", args: "auth/login.go:3-3", want: "   3  func Login() {}", absent: "package"},
+		{name: "intervalo inválido", tool: "read_file", args: "auth/login.go:50-60", err: "fora do arquivo"},
+		{name: "lê fora do domínio", tool: "read_file", args: "products/list.go", outside: true},
+		{name: "sai da raiz", tool: "read_file", args: "../../etc/passwd", outside: true},
+		{name: "lista o domínio", tool: "list_dir", args: "", want: "login.go (", absent: ".secret"},
+		{name: "grep só no domínio", tool: "grep", args: "func Log", want: "auth/login.go:5: func Logout() {}", absent: "products"},
+		{name: "grep sem ocorrência", tool: "grep", args: "nada", want: "(nenhuma ocorrência)"},
+		{name: "padrão inválido", tool: "grep", args: "(", err: "padrão inválido"},
+		{name: "testes sem projeto", tool: "run_tests", err: "nenhum projeto de testes"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tool, ok := Lookup(test.tool)
+			if !ok {
+				t.Fatalf("%s não registrada", test.tool)
+			}
+			output, err := tool.Run(context.Background(), scope, test.args)
+			if test.outside {
+				var violation *sandbox.Violation
+				if !errors.As(err, &violation) {
+					t.Fatalf("esperava *sandbox.Violation, veio %v", err)
+				}
+				return
+			}
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("erro = %v, esperado %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(output, test.want) || (test.absent != "" && strings.Contains(output, test.absent)) {
+				t.Errorf("saída:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestRunTestsNpmScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		paths []string
+		args  string
+	}{
+		{"package.json só na raiz", map[string]string{"package.json": "{}", "auth/index.js": ""}, []string{"auth"}, ""},
+		{"domínio sem caminhos", map[string]string{"package.json": "{}", "auth/package.json": "{}"}, nil, ""},
+		{"subdiretório sem package.json", map[string]string{"package.json": "{}", "auth/package.json": "{}", "auth/lib/x.js": ""}, []string{"auth"}, "auth/lib"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scope := project(t, test.files)
+			scope.Paths = test.paths
+			tool, _ := Lookup("run_tests")
+			_, err := tool.Run(context.Background(), scope, test.args)
+			if err == nil || !strings.Contains(err.Error(), "npm test rodaria o projeto inteiro") {
+				t.Errorf("erro = %v", err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	scope := project(t, map[string]string{"auth/big.txt": strings.Repeat("a", maxOutput*2)})
+	results := Run(context.Background(), scope, []string{"read_file"}, []Call{
+		{Tool: "read_file", Args: "auth/big.txt"},
+		{Tool: "run_tests"},
+		{Tool: "inexistente"},
+	})
+
+	if !strings.Contains(results[0].Output, "saída cortada") || len([]rune(results[0].Output)) > maxOutput+100 {
+		t.Errorf("saída não foi cortada: %d letras", len([]rune(results[0].Output)))
+	}
+	if !strings.Contains(results[1].Error, "não permitida") {
+		t.Errorf("run_tests sem permissão: %+v", results[1])
+	}
+	if !strings.Contains(results[2].Error, "não permitida") {
+		t.Errorf("ferramenta desconhecida: %+v", results[2])
+	}
+}
@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"context"
+	"plaxo-orchestra/internal/backend"
+)
+
+// Meter envolve um backend registrando no Tracker os tokens de cada chamada.
+func Meter(inner backend.Backend, tracker *Tracker) backend.Backend {
+	return &meteredBackend{inner: inner, tracker: tracker}
+}
+
+type meteredBackend struct {
+	inner   backend.Backend
+	tracker *Tracker
+}
+
+func (m *meteredBackend) Name() string {
+	return m.inner.Name()
+}
+
+func (m *meteredBackend) ContextWindow() int {
+	return backend.ContextWindow(m.inner)
+}
+
+func (m *meteredBackend) Complete(ctx context.Context, req backend.Request) (*backend.Response, error) {
+	response, err := m.inner.Complete(ctx, req)
+	m.record(ctx, req.AgentID, response)
+	return response, err
+}
+
+func (m *meteredBackend) Stream(ctx context.Context, req backend.Request, onChunk func(string)) (*backend.Response, error) {
+	response, err := m.inner.Stream(ctx, req, onChunk)
+	m.record(ctx, req.AgentID, response)
+	return response, err
+}
+
+func (m *meteredBackend) Cancel() {
+	m.inner.Cancel()
+}
+
+func (m *meteredBackend) OpenSession(ctx context.Context, agentID string) (backend.Session, error) {
+	sb, ok := m.inner.(backend.SessionBackend)
+	if !ok {
+		// O histórico passa por m.Complete, que já registra o uso
+		return backend.NewHistorySession(m, agentID), nil
+	}
+
+	session, err := sb.OpenSession(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredSession{Session: session, agentID: agentID, meter: m}, nil
+}
+
+func (m *meteredBackend) record(ctx context.Context, agentID string, response *backend.Response) {
+	if response == nil {
+		return
+	}
+	u := response.Usage
+	m.tracker.Record(ctx, agentID, m.inner.Name(), u.PromptTokens, u.CompletionTokens, u.Estimated)
+}
+
+type meteredSession struct {
+	backend.Session
+	agentID string
+	meter   *meteredBackend
+}
+
+func (s *meteredSession) Send(ctx context.Context, prompt string) (*backend.Response, error) {
+	response, err := s.Session.Send(ctx, prompt)
+	s.meter.record(ctx, s.agentID, response)
+	return response, err
+}
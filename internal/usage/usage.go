@@ -0,0 +1,235 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type contextKey string
+
+const (
+	stepKey contextKey = "usage_step"
+	runKey  contextKey = "usage_run"
+)
+
+// WithStep marca as chamadas feitas com ctx como parte de uma etapa de workflow.
+func WithStep(ctx context.Context, step string) context.Context {
+	return context.WithValue(ctx, stepKey, step)
+}
+
+func StepFrom(ctx context.Context) string {
+	step, _ := ctx.Value(stepKey).(string)
+	return step
+}
+
+// WithRun marca as chamadas feitas com ctx como parte de uma execução, para que etapas
+// com o mesmo id em execuções diferentes não se misturem no resumo.
+func WithRun(ctx context.Context, run string) context.Context {
+	return context.WithValue(ctx, runKey, run)
+}
+
+func RunFrom(ctx context.Context) string {
+	run, _ := ctx.Value(runKey).(string)
+	return run
+}
+
+// Pricing é o custo em dólares por 1000 tokens.
+type Pricing struct {
+	PromptPer1K     float64
+	CompletionPer1K float64
+}
+
+func (p Pricing) Cost(promptTokens, completionTokens int) float64 {
+	return float64(promptTokens)/1000*p.PromptPer1K + float64(completionTokens)/1000*p.CompletionPer1K
+}
+
+type Record struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Session          string    `json:"session"`
+	AgentID          string    `json:"agent_id"`
+	Run              string    `json:"run,omitempty"`
+	Step             string    `json:"step,omitempty"`
+	Backend          string    `json:"backend"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Estimated        bool      `json:"estimated"`
+	Cost             float64   `json:"cost"`
+}
+
+type Totals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	EstimatedCalls   int     `json:"estimated_calls"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t *Totals) add(r Record) {
+	t.Calls++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	t.Cost += r.Cost
+	if r.Estimated {
+		t.EstimatedCalls++
+	}
+}
+
+func (t Totals) Tokens() int {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+type Summary struct {
+	Total     Totals            `json:"total"`
+	ByAgent   map[string]Totals `json:"by_agent"`
+	ByStep    map[string]Totals `json:"by_step"`
+	BySession map[string]Totals `json:"by_session"`
+}
+
+func newSummary() *Summary {
+	return &Summary{
+		ByAgent:   make(map[string]Totals),
+		ByStep:    make(map[string]Totals),
+		BySession: make(map[string]Totals),
+	}
+}
+
+func (s *Summary) add(r Record) {
+	s.Total.add(r)
+	addTo(s.ByAgent, r.AgentID, r)
+	addTo(s.BySession, r.Session, r)
+	if r.Step != "" {
+		addTo(s.ByStep, r.StepKey(), r)
+	}
+}
+
+// StepKey identifica a etapa dentro da sua execução; registros antigos, sem execução, usam só o id.
+func (r Record) StepKey() string {
+	if r.Run == "" {
+		return r.Step
+	}
+	return r.Run + "/" + r.Step
+}
+
+func addTo(m map[string]Totals, key string, r Record) {
+	if key == "" {
+		key = "(sem agente)"
+	}
+	t := m[key]
+	t.add(r)
+	m[key] = t
+}
+
+// Tracker acumula o uso da sessão de CLI atual e o persiste em .plaxo/usage/<sessão>.json.
+type Tracker struct {
+	dir     string
+	session string
+	pricing Pricing
+	records []Record
+	mutex   sync.Mutex
+}
+
+func NewTracker(workingDir string, pricing Pricing) *Tracker {
+	return &Tracker{
+		dir:     Dir(workingDir),
+		session: fmt.Sprintf("session_%s", time.Now().Format("20060102_150405")),
+		pricing: pricing,
+	}
+}
+
+func Dir(workingDir string) string {
+	return filepath.Join(workingDir, ".plaxo", "usage")
+}
+
+func (t *Tracker) Session() string {
+	return t.session
+}
+
+func (t *Tracker) Record(ctx context.Context, agentID, backendName string, promptTokens, completionTokens int, estimated bool) {
+	record := Record{
+		Timestamp:        time.Now(),
+		Session:          t.session,
+		AgentID:          agentID,
+		Run:              RunFrom(ctx),
+		Step:             StepFrom(ctx),
+		Backend:          backendName,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Estimated:        estimated,
+		Cost:             t.pricing.Cost(promptTokens, completionTokens),
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.records = append(t.records, record)
+	if err := t.save(); err != nil {
+		fmt.Printf("⚠️  Erro salvando uso de tokens: %v\n", err)
+	}
+}
+
+// Summary resume apenas a sessão atual.
+func (t *Tracker) Summary() *Summary {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	summary := newSummary()
+	for _, r := range t.records {
+		summary.add(r)
+	}
+	return summary
+}
+
+func (t *Tracker) save() error {
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(t.records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(t.dir, t.session+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadSummary agrega todas as sessões persistidas do projeto.
+func LoadSummary(workingDir string) *Summary {
+	summary := newSummary()
+
+	entries, err := os.ReadDir(Dir(workingDir))
+	if err != nil {
+		return summary
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(Dir(workingDir), entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		var records []Record
+		if err := json.Unmarshal(data, &records); err != nil {
+			continue
+		}
+		for _, r := range records {
+			summary.add(r)
+		}
+	}
+
+	return summary
+}
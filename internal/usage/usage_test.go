@@ -0,0 +1,146 @@
+package usage
+
+import (
+	"context"
+	"testing"
+
+	"plaxo-orchestra/internal/backend"
+)
+
+func TestTrackerSummary(t *testing.T) {
+	tracker := NewTracker(t.TempDir(), Pricing{PromptPer1K: 1, CompletionPer1K: 2})
+
+	first := WithStep(WithRun(context.Background(), "run_1"), "analyze")
+	second := WithStep(WithRun(context.Background(), "run_2"), "analyze")
+
+	tracker.Record(first, "auth", "q", 100, 50, true)
+	tracker.Record(first, "auth", "q", 200, 100, true)
+	tracker.Record(second, "user", "q", 1000, 0, false)
+	tracker.Record(context.Background(), "", "q", 10, 10, false)
+
+	summary := tracker.Summary()
+
+	tests := []struct {
+		name string
+		got  Totals
+		want Totals
+	}{
+		{"total", summary.Total, Totals{Calls: 4, PromptTokens: 1310, CompletionTokens: 160, EstimatedCalls: 2, Cost: 1.31 + 0.32}},
+		{"agente", summary.ByAgent["auth"], Totals{Calls: 2, PromptTokens: 300, CompletionTokens: 150, EstimatedCalls: 2, Cost: 0.3 + 0.3}},
+		{"sem agente", summary.ByAgent["(sem agente)"], Totals{Calls: 1, PromptTokens: 10, CompletionTokens: 10, Cost: 0.01 + 0.02}},
+		{"etapa da primeira execução", summary.ByStep["run_1/analyze"], Totals{Calls: 2, PromptTokens: 300, CompletionTokens: 150, EstimatedCalls: 2, Cost: 0.3 + 0.3}},
+		{"mesma etapa em outra execução", summary.ByStep["run_2/analyze"], Totals{Calls: 1, PromptTokens: 1000, Cost: 1}},
+		{"sessão", summary.BySession[tracker.Session()], Totals{Calls: 4, PromptTokens: 1310, CompletionTokens: 160, EstimatedCalls: 2, Cost: 1.31 + 0.32}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, want := test.got, test.want
+			if got.Calls != want.Calls || got.PromptTokens != want.PromptTokens || got.CompletionTokens != want.CompletionTokens ||
+				got.EstimatedCalls != want.EstimatedCalls || !closeTo(got.Cost, want.Cost) {
+				t.Errorf("totais = %+v, esperado %+v", got, want)
+			}
+		})
+	}
+
+	if len(summary.ByStep) != 2 {
+		t.Errorf("etapas = %v, esperado só as duas com id", summary.ByStep)
+	}
+}
+
+func TestLoadSummary(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithStep(WithRun(context.Background(), "run_1"), "plan")
+
+	// Duas sessões de CLI gravando no mesmo projeto
+	for i, session := range []string{"session_a", "session_b"} {
+		tracker := NewTracker(dir, Pricing{})
+		tracker.session = session
+		tracker.Record(ctx, "auth", "q", 10*(i+1), 5, false)
+	}
+
+	summary := LoadSummary(dir)
+	if summary.Total.Calls != 2 || summary.Total.PromptTokens != 30 || summary.Total.CompletionTokens != 10 {
+		t.Errorf("total = %+v", summary.Total)
+	}
+	if len(summary.BySession) != 2 {
+		t.Errorf("sessões = %v", summary.BySession)
+	}
+	if summary.ByStep["run_1/plan"].Calls != 2 {
+		t.Errorf("etapas = %v", summary.ByStep)
+	}
+
+	if empty := LoadSummary(t.TempDir()); empty.Total.Calls != 0 {
+		t.Errorf("projeto sem uso = %+v", empty.Total)
+	}
+}
+
+// fixedBackend responde sempre o mesmo conteúdo; sem usage informado, estima como os backends reais.
+type fixedBackend struct {
+	usage *backend.Usage
+}
+
+func (f *fixedBackend) Name() string { return "fixed" }
+func (f *fixedBackend) Cancel()      {}
+
+func (f *fixedBackend) Complete(ctx context.Context, req backend.Request) (*backend.Response, error) {
+	content := "resposta"
+	if f.usage != nil {
+		return &backend.Response{Content: content, Usage: *f.usage}, nil
+	}
+	return &backend.Response{Content: content, Usage: backend.EstimateUsage(req.Prompt, content)}, nil
+}
+
+func (f *fixedBackend) Stream(ctx context.Context, req backend.Request, onChunk func(string)) (*backend.Response, error) {
+	return f.Complete(ctx, req)
+}
+
+func TestMeter(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage *backend.Usage
+		want  Totals
+	}{
+		{"tokens informados pelo backend", &backend.Usage{PromptTokens: 7, CompletionTokens: 3}, Totals{Calls: 1, PromptTokens: 7, CompletionTokens: 3}},
+		{"estimativa quando o backend não informa", nil, Totals{Calls: 1, PromptTokens: 2, CompletionTokens: 2, EstimatedCalls: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := NewTracker(t.TempDir(), Pricing{})
+			metered := Meter(&fixedBackend{usage: test.usage}, tracker)
+
+			if _, err := metered.Complete(context.Background(), backend.Request{AgentID: "auth", Prompt: "pergunta"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := tracker.Summary().ByAgent["auth"]; got != test.want {
+				t.Errorf("totais = %+v, esperado %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMeterHistorySession(t *testing.T) {
+	tracker := NewTracker(t.TempDir(), Pricing{})
+	metered := Meter(&fixedBackend{}, tracker).(backend.SessionBackend)
+
+	session, err := metered.OpenSession(context.Background(), "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prompt := range []string{"um", "dois"} {
+		if _, err := session.Send(context.Background(), prompt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cada turno é registrado uma única vez, pelo Complete do backend medido
+	if got := tracker.Summary().ByAgent["auth"]; got.Calls != 2 || got.EstimatedCalls != 2 {
+		t.Errorf("totais = %+v", got)
+	}
+}
+
+func closeTo(a, b float64) bool {
+	diff := a - b
+	return diff < 1e-9 && diff > -1e-9
+}
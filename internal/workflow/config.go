@@ -0,0 +1,83 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config é a seção "workflow" do orchestra.yaml.
+type Config struct {
+	MaxConcurrency int `yaml:"max_concurrency"`
+	// Segundos por tentativa de etapa; zero desativa o limite
+	StepTimeout int `yaml:"step_timeout"`
+	Retries     int `yaml:"retries"`
+	// Rodadas de correção dos domínios apontados quando a validação de integração reprova
+	ValidationRetries int `yaml:"validation_retries"`
+	// Consultas aninhadas entre agentes (A pergunta a B, que pergunta a C...) e total por execução; zero desativa
+	ConsultDepth     int `yaml:"consult_depth"`
+	MaxConsultations int `yaml:"max_consultations"`
+	// O que fazer quando um agente escreve fora das suas permissões: falhar a etapa ou pedir aprovação
+	Sandbox string `yaml:"sandbox"`
+}
+
+const (
+	SandboxFail     = "fail"
+	SandboxEscalate = "escalate"
+)
+
+func DefaultConfig() Config {
+	return Config{MaxConcurrency: 4, StepTimeout: 300, Retries: 1, ValidationRetries: 2, ConsultDepth: 2, MaxConsultations: 6, Sandbox: SandboxFail}
+}
+
+// LoadConfig lê a seção workflow do orchestra.yaml, completando com os padrões.
+func LoadConfig(workingDir string) Config {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(filepath.Join(workingDir, "orchestra.yaml"))
+	if err != nil {
+		return cfg
+	}
+
+	// Ponteiros distinguem "retries: 0" de campo ausente
+	var file struct {
+		Workflow *struct {
+			MaxConcurrency int  `yaml:"max_concurrency"`
+			StepTimeout    int  `yaml:"step_timeout"`
+			Retries        *int `yaml:"retries"`
+
+			ValidationRetries *int `yaml:"validation_retries"`
+			ConsultDepth      *int `yaml:"consult_depth"`
+			MaxConsultations  *int `yaml:"max_consultations"`
+
+			Sandbox string `yaml:"sandbox"`
+		} `yaml:"workflow"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil || file.Workflow == nil {
+		return cfg
+	}
+
+	if file.Workflow.MaxConcurrency > 0 {
+		cfg.MaxConcurrency = file.Workflow.MaxConcurrency
+	}
+	if file.Workflow.StepTimeout > 0 {
+		cfg.StepTimeout = file.Workflow.StepTimeout
+	}
+	if file.Workflow.Retries != nil && *file.Workflow.Retries >= 0 {
+		cfg.Retries = *file.Workflow.Retries
+	}
+	if file.Workflow.ValidationRetries != nil && *file.Workflow.ValidationRetries >= 0 {
+		cfg.ValidationRetries = *file.Workflow.ValidationRetries
+	}
+	if file.Workflow.ConsultDepth != nil && *file.Workflow.ConsultDepth >= 0 {
+		cfg.ConsultDepth = *file.Workflow.ConsultDepth
+	}
+	if file.Workflow.MaxConsultations != nil && *file.Workflow.MaxConsultations >= 0 {
+		cfg.MaxConsultations = *file.Workflow.MaxConsultations
+	}
+	if file.Workflow.Sandbox == SandboxFail || file.Workflow.Sandbox == SandboxEscalate {
+		cfg.Sandbox = file.Workflow.Sandbox
+	}
+	return cfg
+}
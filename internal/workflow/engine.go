@@ -0,0 +1,303 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"plaxo-orchestra/internal/usage"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+	StatusWaiting   Status = "waiting"
+)
+
+// ErrWaiting faz a etapa parar sem novas tentativas até uma decisão externa (ex: aprovação humana).
+var ErrWaiting = errors.New("aguardando aprovação")
+
+// Permanent marca um erro que não adianta repetir: a etapa falha sem novas tentativas.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Result é o resultado tipado de uma etapa.
+type Result struct {
+	StepID     string    `json:"step_id"`
+	Agent      string    `json:"agent"`
+	Status     Status    `json:"status"`
+	Output     string    `json:"output,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Attempts   int       `json:"attempts"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+
+	Err error `json:"-"`
+}
+
+func (r *Result) Duration() time.Duration {
+	if r.StartedAt.IsZero() || r.FinishedAt.IsZero() {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+func (r *Result) Succeeded() bool {
+	return r.Status == StatusSucceeded
+}
+
+// StepFunc executa uma etapa; inputs traz os resultados de todas as etapas das quais ela depende.
+type StepFunc func(ctx context.Context, step *Step, inputs map[string]*Result) (string, error)
+
+// Engine executa workflows respeitando dependências, concorrência máxima, timeout e retry.
+type Engine struct {
+	MaxConcurrency int
+	StepTimeout    time.Duration
+	Retry          RetryPolicy
+	// ContinueOnError mantém executando os ramos que não dependem da etapa que falhou
+	ContinueOnError bool
+
+	// Chamados na goroutine do escalonador, um de cada vez
+	OnStepStart func(step *Step)
+	OnStepDone  func(step *Step, result *Result)
+}
+
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		MaxConcurrency: cfg.MaxConcurrency,
+		StepTimeout:    time.Duration(cfg.StepTimeout) * time.Second,
+		Retry:          RetryPolicy{MaxAttempts: cfg.Retries + 1, Backoff: 2 * time.Second},
+	}
+}
+
+// FailedError indica que uma ou mais etapas falharam.
+type FailedError struct {
+	Failed []string
+	Errors map[string]error
+}
+
+func (e *FailedError) Error() string {
+	var parts []string
+	for _, id := range e.Failed {
+		parts = append(parts, fmt.Sprintf("%s: %v", id, e.Errors[id]))
+	}
+	return fmt.Sprintf("etapas falharam: %s", strings.Join(parts, "; "))
+}
+
+func (e *FailedError) Unwrap() []error {
+	var errs []error
+	for _, id := range e.Failed {
+		errs = append(errs, e.Errors[id])
+	}
+	return errs
+}
+
+type completion struct {
+	step   *Step
+	result *Result
+}
+
+// Run valida o workflow e executa as etapas. Etapas já presentes em previous com sucesso não são reexecutadas.
+func (e *Engine) Run(ctx context.Context, w *Workflow, run StepFunc, previous map[string]*Result) (map[string]*Result, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*Result)
+	for id, result := range previous {
+		if w.Step(id) != nil && result.Succeeded() {
+			results[id] = result
+		}
+	}
+
+	// Sem execução gravada, o uso das etapas é agrupado pelo nome do workflow
+	if usage.RunFrom(ctx) == "" {
+		ctx = usage.WithRun(ctx, w.Name)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := e.MaxConcurrency
+	if limit <= 0 {
+		limit = len(w.Steps)
+	}
+
+	done := make(chan completion)
+	running := 0
+	stopped := false
+	failed := &FailedError{Errors: make(map[string]error)}
+
+	for {
+		// Etapas cujas dependências falharam ou foram puladas são puladas
+		for _, step := range w.Steps {
+			if results[step.ID] != nil {
+				continue
+			}
+			if dep := blockedBy(step, results); dep != "" {
+				results[step.ID] = &Result{StepID: step.ID, Agent: step.Agent, Status: StatusSkipped, Error: "dependência " + dep + " não concluída"}
+				e.notifyDone(step, results[step.ID])
+			}
+		}
+
+		if !stopped {
+			for _, step := range w.Steps {
+				if running >= limit {
+					break
+				}
+				if results[step.ID] != nil || !ready(step, results) {
+					continue
+				}
+
+				results[step.ID] = &Result{StepID: step.ID, Agent: step.Agent, Status: StatusRunning}
+				if e.OnStepStart != nil {
+					e.OnStepStart(step)
+				}
+
+				inputs := make(map[string]*Result)
+				for _, id := range w.Ancestors(step.ID) {
+					inputs[id] = results[id]
+				}
+
+				running++
+				go func(step *Step) {
+					done <- completion{step: step, result: e.execute(runCtx, step, run, inputs)}
+				}(step)
+			}
+		}
+
+		if running == 0 {
+			break
+		}
+
+		c := <-done
+		running--
+		results[c.step.ID] = c.result
+		e.notifyDone(c.step, c.result)
+
+		if c.result.Status == StatusFailed || c.result.Status == StatusWaiting {
+			failed.Failed = append(failed.Failed, c.step.ID)
+			failed.Errors[c.step.ID] = c.result.Err
+			if !e.ContinueOnError {
+				stopped = true
+				cancel()
+			}
+		}
+	}
+
+	// Etapas que não chegaram a rodar após uma parada
+	for _, step := range w.Steps {
+		if results[step.ID] == nil {
+			results[step.ID] = &Result{StepID: step.ID, Agent: step.Agent, Status: StatusSkipped, Error: "workflow interrompido"}
+			e.notifyDone(step, results[step.ID])
+		}
+	}
+
+	if len(failed.Failed) > 0 {
+		sort.Strings(failed.Failed)
+		return results, failed
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+func (e *Engine) notifyDone(step *Step, result *Result) {
+	if e.OnStepDone != nil {
+		e.OnStepDone(step, result)
+	}
+}
+
+func (e *Engine) execute(ctx context.Context, step *Step, run StepFunc, inputs map[string]*Result) *Result {
+	result := &Result{StepID: step.ID, Agent: step.Agent, StartedAt: time.Now()}
+
+	retry := step.Retry
+	if retry.MaxAttempts <= 0 {
+		retry = e.Retry
+	}
+	attempts := max(1, retry.MaxAttempts)
+
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = e.StepTimeout
+	}
+	if step.Human {
+		attempts, timeout = 1, 0
+	}
+
+	for result.Attempts < attempts {
+		result.Attempts++
+
+		stepCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			stepCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		output, err := run(usage.WithStep(stepCtx, step.ID), step, inputs)
+		cancel()
+
+		if err == nil {
+			result.Status = StatusSucceeded
+			result.Output = output
+			result.FinishedAt = time.Now()
+			return result
+		}
+
+		result.Err = err
+		if errors.Is(err, ErrWaiting) {
+			result.Status = StatusWaiting
+			result.Error = err.Error()
+			result.FinishedAt = time.Now()
+			return result
+		}
+		var permanent *permanentError
+		if ctx.Err() != nil || errors.As(err, &permanent) {
+			break
+		}
+		if result.Attempts < attempts {
+			select {
+			case <-time.After(retry.Backoff * time.Duration(result.Attempts)):
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	result.Status = StatusFailed
+	result.Error = result.Err.Error()
+	result.FinishedAt = time.Now()
+	return result
+}
+
+func ready(step *Step, results map[string]*Result) bool {
+	for _, dep := range step.DependsOn {
+		if results[dep] == nil || !results[dep].Succeeded() {
+			return false
+		}
+	}
+	return true
+}
+
+// blockedBy devolve a dependência que terminou sem sucesso, se houver.
+func blockedBy(step *Step, results map[string]*Result) string {
+	for _, dep := range step.DependsOn {
+		if r := results[dep]; r != nil && (r.Status == StatusFailed || r.Status == StatusSkipped || r.Status == StatusWaiting) {
+			return dep
+		}
+	}
+	return ""
+}
@@ -0,0 +1,235 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"plaxo-orchestra/internal/usage"
+)
+
+func TestEngineRun(t *testing.T) {
+	failing := errors.New("falhou")
+
+	tests := []struct {
+		name            string
+		steps           []*Step
+		continueOnError bool
+		previous        map[string]*Result
+		// behave devolve o erro da tentativa (1, 2, ...) de cada etapa
+		behave   func(id string, attempt int) error
+		status   map[string]Status
+		attempts map[string]int
+		wantErr  bool
+	}{
+		{
+			name:     "todas concluem",
+			steps:    []*Step{step("a"), step("b", "a")},
+			behave:   func(string, int) error { return nil },
+			status:   map[string]Status{"a": StatusSucceeded, "b": StatusSucceeded},
+			attempts: map[string]int{"a": 1, "b": 1},
+		},
+		{
+			name:  "retry recupera falha temporária",
+			steps: []*Step{step("a")},
+			behave: func(id string, attempt int) error {
+				if attempt == 1 {
+					return failing
+				}
+				return nil
+			},
+			status:   map[string]Status{"a": StatusSucceeded},
+			attempts: map[string]int{"a": 2},
+		},
+		{
+			name:     "erro permanente não repete",
+			steps:    []*Step{step("a")},
+			behave:   func(string, int) error { return Permanent(failing) },
+			status:   map[string]Status{"a": StatusFailed},
+			attempts: map[string]int{"a": 1},
+			wantErr:  true,
+		},
+		{
+			name:     "aguardando aprovação para sem repetir",
+			steps:    []*Step{step("a"), step("b", "a")},
+			behave:   func(string, int) error { return fmt.Errorf("gate: %w", ErrWaiting) },
+			status:   map[string]Status{"a": StatusWaiting, "b": StatusSkipped},
+			attempts: map[string]int{"a": 1},
+			wantErr:  true,
+		},
+		{
+			name:  "falha pula dependentes e interrompe o resto",
+			steps: []*Step{step("a"), step("b", "a"), step("c", "a")},
+			behave: func(id string, attempt int) error {
+				if id == "b" {
+					return Permanent(failing)
+				}
+				return nil
+			},
+			status:  map[string]Status{"a": StatusSucceeded, "b": StatusFailed},
+			wantErr: true,
+		},
+		{
+			name:            "ContinueOnError mantém ramos independentes",
+			steps:           []*Step{step("a"), step("b", "a"), step("c")},
+			continueOnError: true,
+			behave: func(id string, attempt int) error {
+				if id == "a" {
+					return Permanent(failing)
+				}
+				return nil
+			},
+			status:  map[string]Status{"a": StatusFailed, "b": StatusSkipped, "c": StatusSucceeded},
+			wantErr: true,
+		},
+		{
+			name:     "etapas concluídas antes não são reexecutadas",
+			steps:    []*Step{step("a"), step("b", "a")},
+			previous: map[string]*Result{"a": {StepID: "a", Status: StatusSucceeded, Output: "anterior"}},
+			behave:   func(string, int) error { return nil },
+			status:   map[string]Status{"a": StatusSucceeded, "b": StatusSucceeded},
+			attempts: map[string]int{"b": 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := &Engine{Retry: RetryPolicy{MaxAttempts: 2}, ContinueOnError: test.continueOnError}
+			var mutex sync.Mutex
+			calls := make(map[string]int)
+
+			run := func(ctx context.Context, s *Step, inputs map[string]*Result) (string, error) {
+				mutex.Lock()
+				calls[s.ID]++
+				attempt := calls[s.ID]
+				mutex.Unlock()
+				for _, dep := range s.DependsOn {
+					if inputs[dep] == nil || !inputs[dep].Succeeded() {
+						return "", fmt.Errorf("%s rodou sem a dependência %s", s.ID, dep)
+					}
+				}
+				return s.ID + " ok", test.behave(s.ID, attempt)
+			}
+
+			results, err := engine.Run(context.Background(), New("teste", test.steps...), run, test.previous)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("erro = %v", err)
+			}
+			for id, status := range test.status {
+				if results[id].Status != status {
+					t.Errorf("%s: status %s, esperado %s (%s)", id, results[id].Status, status, results[id].Error)
+				}
+			}
+			for id, attempts := range test.attempts {
+				if calls[id] != attempts {
+					t.Errorf("%s: %d chamadas, esperado %d", id, calls[id], attempts)
+				}
+			}
+			if test.previous != nil && calls["a"] != 0 {
+				t.Error("etapa concluída foi reexecutada")
+			}
+		})
+	}
+}
+
+func TestEngineConcurrencyLimit(t *testing.T) {
+	engine := &Engine{MaxConcurrency: 2}
+	var running, peak int32
+
+	run := func(ctx context.Context, s *Step, inputs map[string]*Result) (string, error) {
+		now := atomic.AddInt32(&running, 1)
+		for {
+			previous := atomic.LoadInt32(&peak)
+			if now <= previous || atomic.CompareAndSwapInt32(&peak, previous, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return "", nil
+	}
+
+	w := New("teste", step("a"), step("b"), step("c"), step("d"), step("e"))
+	if _, err := engine.Run(context.Background(), w, run, nil); err != nil {
+		t.Fatal(err)
+	}
+	if peak > 2 {
+		t.Errorf("%d etapas simultâneas, limite 2", peak)
+	}
+}
+
+func TestEngineStepTimeout(t *testing.T) {
+	engine := &Engine{StepTimeout: 20 * time.Millisecond, Retry: RetryPolicy{MaxAttempts: 1}}
+	run := func(ctx context.Context, s *Step, inputs map[string]*Result) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	results, err := engine.Run(context.Background(), New("teste", step("a")), run, nil)
+	if err == nil || !errors.Is(results["a"].Err, context.DeadlineExceeded) {
+		t.Fatalf("esperava timeout, veio %v", err)
+	}
+}
+
+func TestEngineRejectsInvalidWorkflow(t *testing.T) {
+	engine := &Engine{}
+	_, err := engine.Run(context.Background(), New("teste", step("a", "b")), nil, nil)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("esperava *ValidationError, veio %v", err)
+	}
+}
+
+func TestEngineHumanStep(t *testing.T) {
+	engine := &Engine{StepTimeout: 10 * time.Millisecond, Retry: RetryPolicy{MaxAttempts: 3}}
+	calls := 0
+	run := func(ctx context.Context, s *Step, inputs map[string]*Result) (string, error) {
+		calls++
+		if _, ok := ctx.Deadline(); ok {
+			return "", errors.New("aprovação não pode ter prazo")
+		}
+		time.Sleep(30 * time.Millisecond)
+		return "", errors.New("rejeitado")
+	}
+
+	human := &Step{ID: "aprovar", Agent: HumanAgent, Human: true}
+	results, err := engine.Run(context.Background(), New("teste", human), run, nil)
+	if err == nil || results["aprovar"].Error != "rejeitado" {
+		t.Fatalf("resultado = %+v, erro %v", results["aprovar"], err)
+	}
+	if calls != 1 {
+		t.Errorf("%d chamadas, esperado 1", calls)
+	}
+}
+
+func TestEngineUsageContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"execução gravada", usage.WithRun(context.Background(), "run_1"), "run_1/a"},
+		{"sem execução usa o nome do workflow", context.Background(), "teste/a"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			run := func(ctx context.Context, s *Step, inputs map[string]*Result) (string, error) {
+				got = usage.RunFrom(ctx) + "/" + usage.StepFrom(ctx)
+				return "", nil
+			}
+
+			if _, err := (&Engine{}).Run(test.ctx, New("teste", step("a")), run, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("etapa = %q, esperado %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Formatos de exportação do DAG.
+const (
+	FormatMermaid = "mermaid"
+	FormatDOT     = "dot"
+	FormatJSON    = "json"
+)
+
+var Formats = []string{FormatMermaid, FormatDOT, FormatJSON}
+
+// ExportedStep é uma etapa do DAG exportado; Status e Duration só existem para execuções.
+type ExportedStep struct {
+	ID        string   `json:"id"`
+	Agent     string   `json:"agent"`
+	Action    string   `json:"action,omitempty"`
+	Output    string   `json:"output,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Level     int      `json:"level"`
+	Status    Status   `json:"status,omitempty"`
+	Attempts  int      `json:"attempts,omitempty"`
+	Duration  string   `json:"duration,omitempty"`
+}
+
+type ExportedWorkflow struct {
+	Workflow string         `json:"workflow"`
+	Input    string         `json:"input,omitempty"`
+	Levels   [][]string     `json:"levels"`
+	Steps    []ExportedStep `json:"steps"`
+}
+
+// Describe monta a visão exportável de w; com results, anota status e duração de cada etapa.
+func Describe(w *Workflow, input string, results map[string]*Result) (*ExportedWorkflow, error) {
+	levels, err := w.Levels()
+	if err != nil {
+		return nil, err
+	}
+
+	exported := &ExportedWorkflow{Workflow: w.Name, Input: input}
+	for depth, level := range levels {
+		var ids []string
+		for _, step := range level {
+			ids = append(ids, step.ID)
+
+			item := ExportedStep{
+				ID:        step.ID,
+				Agent:     step.Agent,
+				Action:    step.Action,
+				Output:    contextString(step, "output"),
+				DependsOn: step.DependsOn,
+				Level:     depth,
+			}
+			if results != nil {
+				item.Status = StatusPending
+				if result := results[step.ID]; result != nil {
+					item.Status = result.Status
+					item.Attempts = result.Attempts
+					if !result.FinishedAt.IsZero() {
+						item.Duration = result.Duration().Round(time.Millisecond).String()
+					}
+				}
+			}
+			exported.Steps = append(exported.Steps, item)
+		}
+		exported.Levels = append(exported.Levels, ids)
+	}
+	return exported, nil
+}
+
+// Export renderiza o DAG no formato pedido (mermaid, dot ou json).
+func Export(w *Workflow, input string, results map[string]*Result, format string) (string, error) {
+	exported, err := Describe(w, input, results)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case FormatMermaid:
+		return exported.Mermaid(), nil
+	case FormatDOT:
+		return exported.DOT(), nil
+	case FormatJSON:
+		var b strings.Builder
+		encoder := json.NewEncoder(&b)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(exported); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("formato desconhecido: %s (use %s)", format, strings.Join(Formats, ", "))
+}
+
+// Mermaid desenha um flowchart com uma subgraph por camada de execução paralela.
+func (e *ExportedWorkflow) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+
+	nodes := make(map[string]string)
+	for i, step := range e.Steps {
+		nodes[step.ID] = fmt.Sprintf("s%d", i)
+	}
+
+	for depth, level := range e.Levels {
+		fmt.Fprintf(&b, "  subgraph level%d [\"Camada %d\"]\n", depth, depth+1)
+		for _, id := range level {
+			step := e.step(id)
+			lines := []string{"<b>" + mermaidEscape(step.ID) + "</b>", mermaidEscape(step.Agent)}
+			for _, line := range step.details() {
+				lines = append(lines, mermaidEscape(line))
+			}
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", nodes[id], strings.Join(lines, "<br/>"))
+		}
+		b.WriteString("  end\n")
+	}
+
+	for _, step := range e.Steps {
+		for _, dep := range step.DependsOn {
+			fmt.Fprintf(&b, "  %s --> %s\n", nodes[dep], nodes[step.ID])
+		}
+	}
+
+	used := make(map[Status]bool)
+	for _, step := range e.Steps {
+		if step.Status != "" {
+			fmt.Fprintf(&b, "  class %s %s\n", nodes[step.ID], step.Status)
+			used[step.Status] = true
+		}
+	}
+	for _, status := range []Status{StatusPending, StatusRunning, StatusSucceeded, StatusFailed, StatusSkipped, StatusWaiting} {
+		if used[status] {
+			fmt.Fprintf(&b, "  classDef %s fill:%s\n", status, statusColor(status))
+		}
+	}
+	return b.String()
+}
+
+// DOT desenha o grafo para o Graphviz, alinhando na mesma linha as etapas de cada camada.
+func (e *ExportedWorkflow) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(e.Workflow))
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];\n")
+
+	for _, step := range e.Steps {
+		label := step.ID + "\n" + step.Agent
+		for _, line := range step.details() {
+			label += "\n" + line
+		}
+		attrs := "label=" + dotQuote(label)
+		if step.Status != "" {
+			attrs += ", fillcolor=" + dotQuote(statusColor(step.Status))
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(step.ID), attrs)
+	}
+
+	for _, level := range e.Levels {
+		var ids []string
+		for _, id := range level {
+			ids = append(ids, dotQuote(id))
+		}
+		fmt.Fprintf(&b, "  { rank=same; %s; }\n", strings.Join(ids, "; "))
+	}
+
+	for _, step := range e.Steps {
+		for _, dep := range step.DependsOn {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(dep), dotQuote(step.ID))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (e *ExportedWorkflow) step(id string) *ExportedStep {
+	for i := range e.Steps {
+		if e.Steps[i].ID == id {
+			return &e.Steps[i]
+		}
+	}
+	return nil
+}
+
+// details são as linhas de ação, saída esperada e execução mostradas em cada nó.
+func (s *ExportedStep) details() []string {
+	var lines []string
+	if s.Action != "" {
+		lines = append(lines, "ação: "+truncate(s.Action, 60))
+	}
+	if s.Output != "" {
+		lines = append(lines, "saída: "+truncate(s.Output, 60))
+	}
+	if s.Status != "" {
+		line := string(s.Status)
+		if s.Duration != "" {
+			line += " em " + s.Duration
+		}
+		if s.Attempts > 1 {
+			line += fmt.Sprintf(" (%d tentativas)", s.Attempts)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func statusColor(status Status) string {
+	switch status {
+	case StatusSucceeded:
+		return "#c8e6c9"
+	case StatusFailed:
+		return "#ffcdd2"
+	case StatusRunning:
+		return "#bbdefb"
+	case StatusWaiting:
+		return "#fff9c4"
+	case StatusSkipped:
+		return "#eeeeee"
+	}
+	return "#ffffff"
+}
+
+func contextString(step *Step, key string) string {
+	if value, ok := step.Context[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+func truncate(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > limit {
+		return string(runes[:limit-3]) + "..."
+	}
+	return s
+}
+
+// mermaidEscape troca os caracteres que fecham o rótulo ou viram HTML.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer("\"", "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+func dotQuote(s string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(s) + "\""
+}
@@ -0,0 +1,228 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"plaxo-orchestra/internal/blackboard"
+	"plaxo-orchestra/internal/consult"
+)
+
+// Tipos de execução, usados para saber quem retoma cada uma.
+const (
+	RunKindChat         = "chat"
+	RunKindOrchestrate  = "orchestrate"
+	RunKindCoordination = "coordination"
+)
+
+// StepSpec é a forma serializável de um Step.
+type StepSpec struct {
+	ID        string                 `json:"id"`
+	Agent     string                 `json:"agent"`
+	Action    string                 `json:"action,omitempty"`
+	DependsOn []string               `json:"depends_on,omitempty"`
+	Human     bool                   `json:"human,omitempty"`
+	Context   map[string]interface{} `json:"context,omitempty"`
+}
+
+// Run é o estado de uma execução, gravado em .plaxo/runs/<id>.json após cada etapa.
+type Run struct {
+	ID       string             `json:"id"`
+	Kind     string             `json:"kind"`
+	Workflow string             `json:"workflow"`
+	Input    string             `json:"input"`
+	Steps    []StepSpec         `json:"steps"`
+	Results  map[string]*Result `json:"results"`
+	Status   Status             `json:"status"`
+	Error    string             `json:"error,omitempty"`
+	// Quadro compartilhado entre os agentes da execução
+	Blackboard *blackboard.Board `json:"blackboard,omitempty"`
+	// Consultas entre agentes feitas durante a execução
+	Consultations *consult.Trace `json:"consultations,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+
+	root string
+}
+
+func RunsDir(root string) string {
+	return filepath.Join(root, ".plaxo", "runs")
+}
+
+// NewRun cria e grava o checkpoint inicial de uma execução de w.
+func NewRun(root, kind, input string, w *Workflow) (*Run, error) {
+	now := time.Now()
+	run := &Run{
+		ID:        now.Format("20060102_150405.000") + "_" + strings.NewReplacer("/", "-", " ", "-").Replace(w.Name),
+		Kind:      kind,
+		Workflow:  w.Name,
+		Input:     input,
+		Results:   make(map[string]*Result),
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+		root:      root,
+	}
+	for _, step := range w.Steps {
+		run.Steps = append(run.Steps, StepSpec{ID: step.ID, Agent: step.Agent, Action: step.Action, DependsOn: step.DependsOn, Human: step.Human, Context: step.Context})
+	}
+	return run, run.Save()
+}
+
+// LoadRun lê uma execução gravada.
+func LoadRun(root, id string) (*Run, error) {
+	path := filepath.Join(RunsDir(root), id+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("execução %s não encontrada: %v", id, err)
+	}
+
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("execução inválida %s: %v", path, err)
+	}
+	if run.Results == nil {
+		run.Results = make(map[string]*Result)
+	}
+	run.root = root
+	return &run, nil
+}
+
+// ListRuns devolve as execuções gravadas em ordem cronológica.
+func ListRuns(root string) ([]*Run, error) {
+	entries, err := os.ReadDir(RunsDir(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*Run
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		run, err := LoadRun(root, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].CreatedAt.Before(runs[j].CreatedAt) })
+	return runs, nil
+}
+
+// Board devolve o quadro compartilhado da execução, criando-o na primeira vez.
+func (r *Run) Board() *blackboard.Board {
+	if r.Blackboard == nil {
+		r.Blackboard = blackboard.New()
+	}
+	return r.Blackboard
+}
+
+// Trace devolve o registro de consultas entre agentes, criando-o na primeira vez.
+func (r *Run) Trace() *consult.Trace {
+	if r.Consultations == nil {
+		r.Consultations = consult.NewTrace()
+	}
+	return r.Consultations
+}
+
+// Rebuild reconstrói o workflow gravado.
+func (r *Run) Rebuild() *Workflow {
+	w := New(r.Workflow)
+	for _, spec := range r.Steps {
+		w.Add(&Step{ID: spec.ID, Agent: spec.Agent, Action: spec.Action, DependsOn: spec.DependsOn, Human: spec.Human || spec.Agent == HumanAgent, Context: spec.Context})
+	}
+	return w
+}
+
+// Previous devolve as etapas já concluídas, para o Engine não reexecutá-las.
+func (r *Run) Previous() map[string]*Result {
+	previous := make(map[string]*Result)
+	for id, result := range r.Results {
+		if result.Succeeded() {
+			previous[id] = result
+		}
+	}
+	return previous
+}
+
+// Completed conta as etapas concluídas com sucesso.
+func (r *Run) Completed() int {
+	return len(r.Previous())
+}
+
+// Resumable indica se ainda há etapas para executar.
+func (r *Run) Resumable() bool {
+	return r.Status != StatusSucceeded
+}
+
+// Track encadeia nos hooks do Engine a gravação do checkpoint a cada etapa.
+func (r *Run) Track(e *Engine) {
+	onStart, onDone := e.OnStepStart, e.OnStepDone
+
+	e.OnStepStart = func(step *Step) {
+		r.Status = StatusRunning
+		r.Results[step.ID] = &Result{StepID: step.ID, Agent: step.Agent, Status: StatusRunning, StartedAt: time.Now()}
+		r.checkpoint()
+		if onStart != nil {
+			onStart(step)
+		}
+	}
+	e.OnStepDone = func(step *Step, result *Result) {
+		r.Results[step.ID] = result
+		r.checkpoint()
+		if onDone != nil {
+			onDone(step, result)
+		}
+	}
+}
+
+// Finish grava o resultado final da execução.
+func (r *Run) Finish(err error) error {
+	r.Status = StatusSucceeded
+	r.Error = ""
+	switch {
+	case errors.Is(err, ErrWaiting):
+		r.Status = StatusWaiting
+		r.Error = err.Error()
+	case err != nil:
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+	return r.Save()
+}
+
+func (r *Run) checkpoint() {
+	if err := r.Save(); err != nil {
+		fmt.Printf("⚠️  Erro salvando checkpoint de %s: %v\n", r.ID, err)
+	}
+}
+
+func (r *Run) Save() error {
+	if err := os.MkdirAll(RunsDir(r.root), 0755); err != nil {
+		return err
+	}
+
+	r.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(RunsDir(r.root), r.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
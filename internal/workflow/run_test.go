@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestRunCheckpointAndResume(t *testing.T) {
+	root := t.TempDir()
+	w := New("pedido", step("a"), step("b", "a"), step("c", "b"))
+
+	tests := []struct {
+		name string
+		// fail é a etapa que falha nesta rodada; vazio conclui tudo
+		fail      string
+		calls     []string
+		status    Status
+		completed int
+	}{
+		{"primeira rodada para em b", "b", []string{"a", "b"}, StatusFailed, 1},
+		{"retomada não repete a", "c", []string{"b", "c"}, StatusFailed, 2},
+		{"última retomada conclui", "", []string{"c"}, StatusSucceeded, 3},
+	}
+
+	run, err := NewRun(root, RunKindOrchestrate, "entrada", w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loaded, err := LoadRun(root, run.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var calls []string
+			engine := &Engine{Retry: RetryPolicy{MaxAttempts: 1}}
+			loaded.Track(engine)
+			_, runErr := engine.Run(context.Background(), loaded.Rebuild(), func(ctx context.Context, s *Step, inputs map[string]*Result) (string, error) {
+				calls = append(calls, s.ID)
+				if s.ID == test.fail {
+					return "", fmt.Errorf("%s falhou", s.ID)
+				}
+				return s.ID + " ok", nil
+			}, loaded.Previous())
+			if err := loaded.Finish(runErr); err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(calls) != fmt.Sprint(test.calls) {
+				t.Errorf("etapas executadas = %v, esperado %v", calls, test.calls)
+			}
+			saved, _ := LoadRun(root, run.ID)
+			if saved.Status != test.status || saved.Completed() != test.completed {
+				t.Errorf("status %s com %d concluídas, esperado %s com %d", saved.Status, saved.Completed(), test.status, test.completed)
+			}
+			if saved.Resumable() != (test.status != StatusSucceeded) {
+				t.Errorf("Resumable = %v", saved.Resumable())
+			}
+		})
+	}
+}
+
+func TestRunFinishWaiting(t *testing.T) {
+	run, err := NewRun(t.TempDir(), RunKindCoordination, "entrada", New("pedido", step("a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Finish(fmt.Errorf("etapa a: %w", ErrWaiting)); err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != StatusWaiting || !run.Resumable() {
+		t.Errorf("status = %s", run.Status)
+	}
+
+	runs, err := ListRuns(run.root)
+	if err != nil || len(runs) != 1 || runs[0].ID != run.ID {
+		t.Fatalf("ListRuns = %v, %v", runs, err)
+	}
+}
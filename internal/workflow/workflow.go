@@ -0,0 +1,203 @@
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Step é uma etapa do DAG. ID é único no workflow; vários passos podem usar o mesmo Agent.
+type Step struct {
+	ID        string
+	Agent     string
+	Action    string
+	DependsOn []string
+	// Timeout e Retry zerados usam os padrões do Engine
+	Timeout time.Duration
+	Retry   RetryPolicy
+	// Human marca as etapas decididas por uma pessoa (aprovações): sem timeout e sem novas tentativas,
+	// para que a revisão não expire nem pergunte tudo de novo
+	Human   bool
+	Context map[string]interface{}
+}
+
+// HumanAgent é o agente das etapas de aprovação.
+const HumanAgent = "human"
+
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+type Workflow struct {
+	Name  string
+	Steps []*Step
+}
+
+func New(name string, steps ...*Step) *Workflow {
+	return &Workflow{Name: name, Steps: steps}
+}
+
+func (w *Workflow) Add(step *Step) *Workflow {
+	w.Steps = append(w.Steps, step)
+	return w
+}
+
+func (w *Workflow) Step(id string) *Step {
+	for _, step := range w.Steps {
+		if step.ID == id {
+			return step
+		}
+	}
+	return nil
+}
+
+// ValidationError lista todos os problemas do DAG encontrados antes da execução.
+type ValidationError struct {
+	Workflow string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("workflow %q inválido: %s", e.Workflow, strings.Join(e.Problems, "; "))
+}
+
+// Validate confere IDs vazios ou repetidos, dependências desconhecidas e ciclos.
+func (w *Workflow) Validate() error {
+	var problems []string
+	ids := make(map[string]bool)
+
+	for i, step := range w.Steps {
+		switch {
+		case step.ID == "":
+			problems = append(problems, fmt.Sprintf("etapa %d sem ID", i+1))
+		case ids[step.ID]:
+			problems = append(problems, fmt.Sprintf("ID de etapa repetido: %s", step.ID))
+		}
+		ids[step.ID] = true
+	}
+
+	for _, step := range w.Steps {
+		for _, dep := range step.DependsOn {
+			if !ids[dep] {
+				problems = append(problems, fmt.Sprintf("%s depende de etapa desconhecida %s", step.ID, dep))
+			} else if dep == step.ID {
+				problems = append(problems, fmt.Sprintf("%s depende de si mesma", step.ID))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		if cycle := w.findCycle(); cycle != nil {
+			problems = append(problems, fmt.Sprintf("ciclo de dependências: %s", strings.Join(cycle, " → ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Workflow: w.Name, Problems: problems}
+	}
+	return nil
+}
+
+// findCycle devolve o caminho de um ciclo, se existir (DFS com três cores).
+func (w *Workflow) findCycle() []string {
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := make(map[string]int)
+	var stack []string
+	var cycle []string
+
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		color[id] = gray
+		stack = append(stack, id)
+
+		for _, dep := range w.Step(id).DependsOn {
+			switch color[dep] {
+			case gray:
+				for i, s := range stack {
+					if s == dep {
+						cycle = append(append([]string{}, stack[i:]...), dep)
+						return true
+					}
+				}
+			case white:
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		color[id] = black
+		return false
+	}
+
+	for _, step := range w.Steps {
+		if color[step.ID] == white && visit(step.ID) {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// Levels agrupa as etapas em camadas que podem rodar em paralelo, na ordem de declaração.
+func (w *Workflow) Levels() ([][]*Step, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	level := make(map[string]int)
+	var depth func(step *Step) int
+	depth = func(step *Step) int {
+		if d, ok := level[step.ID]; ok {
+			return d
+		}
+		d := 0
+		for _, dep := range step.DependsOn {
+			d = max(d, depth(w.Step(dep))+1)
+		}
+		level[step.ID] = d
+		return d
+	}
+
+	var levels [][]*Step
+	for _, step := range w.Steps {
+		d := depth(step)
+		for len(levels) <= d {
+			levels = append(levels, nil)
+		}
+		levels[d] = append(levels[d], step)
+	}
+	return levels, nil
+}
+
+// Ancestors devolve os IDs de todas as etapas das quais id depende, direta ou indiretamente, ordenados.
+func (w *Workflow) Ancestors(id string) []string {
+	seen := make(map[string]bool)
+	var walk func(id string)
+	walk = func(id string) {
+		step := w.Step(id)
+		if step == nil {
+			return
+		}
+		for _, dep := range step.DependsOn {
+			if !seen[dep] {
+				seen[dep] = true
+				walk(dep)
+			}
+		}
+	}
+	walk(id)
+
+	ancestors := make([]string, 0, len(seen))
+	for dep := range seen {
+		ancestors = append(ancestors, dep)
+	}
+	sort.Strings(ancestors)
+	return ancestors
+}
@@ -0,0 +1,86 @@
+package workflow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func step(id string, deps ...string) *Step {
+	return &Step{ID: id, Agent: "agente", DependsOn: deps}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []*Step
+		problems []string
+	}{
+		{"DAG válido", []*Step{step("a"), step("b", "a"), step("c", "a", "b")}, nil},
+		{"ID vazio", []*Step{step("")}, []string{"etapa 1 sem ID"}},
+		{"ID repetido", []*Step{step("a"), step("a")}, []string{"ID de etapa repetido: a"}},
+		{"dependência desconhecida", []*Step{step("a", "x")}, []string{"a depende de etapa desconhecida x"}},
+		{"depende de si mesma", []*Step{step("a", "a")}, []string{"a depende de si mesma"}},
+		{"ciclo", []*Step{step("a", "c"), step("b", "a"), step("c", "b")}, []string{"ciclo de dependências: a → c → b → a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := New("teste", test.steps...).Validate()
+			if test.problems == nil {
+				if err != nil {
+					t.Fatalf("esperava válido, veio %v", err)
+				}
+				return
+			}
+
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("esperava *ValidationError, veio %v", err)
+			}
+			if strings.Join(invalid.Problems, "|") != strings.Join(test.problems, "|") {
+				t.Errorf("problemas = %q, esperado %q", invalid.Problems, test.problems)
+			}
+		})
+	}
+}
+
+func TestLevels(t *testing.T) {
+	w := New("teste", step("a"), step("b"), step("c", "a"), step("d", "b", "c"), step("e", "a"))
+
+	levels, err := w.Levels()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, level := range levels {
+		var ids []string
+		for _, s := range level {
+			ids = append(ids, s.ID)
+		}
+		got = append(got, strings.Join(ids, ","))
+	}
+	if want := "a,b|c,e|d"; strings.Join(got, "|") != want {
+		t.Errorf("camadas = %s, esperado %s", strings.Join(got, "|"), want)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	w := New("teste", step("a"), step("b", "a"), step("c"), step("d", "b", "c"))
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"a", ""},
+		{"b", "a"},
+		{"d", "a,b,c"},
+		{"inexistente", ""},
+	}
+	for _, test := range tests {
+		if got := strings.Join(w.Ancestors(test.id), ","); got != test.want {
+			t.Errorf("Ancestors(%s) = %s, esperado %s", test.id, got, test.want)
+		}
+	}
+}